@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// ackRequest is the body of POST /api/v1/incidents/{ns}/{deployment}/ack.
+type ackRequest struct {
+	By string `json:"by"`
+}
+
+// ackBackend is where the ack command reads and acknowledges incidents: the
+// state file directly, or a running daemon's REST API.
+type ackBackend interface {
+	Acknowledge(namespace, deployment, user string, now time.Time) (state.Incident, error)
+	Incidents() []state.Incident
+}
+
+// runAckCommand implements
+//
+//	ack <namespace/deployment> [-by name]
+//	ack list
+//
+// so responders can claim an incident, or see who is already on one, from a
+// terminal as well as from Slack or an email reply.
+func runAckCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("ack", flag.ExitOnError)
+	configPath := flags.String("config", "./config.yaml", "Path to config file")
+	server := flags.String("server", "", "Base URL of a running daemon, e.g. http://k8s-health:8080")
+	by := flags.String("by", os.Getenv("USER"), "Who is acknowledging")
+
+	positional := parseInterleaved(flags, args)
+
+	var backend ackBackend
+	if *server != "" {
+		backend = newAPIClient(*server)
+	} else {
+		store, err := openStateFile(*configPath)
+		if err != nil {
+			return err
+		}
+		backend = store
+	}
+
+	switch {
+	case len(positional) == 1 && positional[0] == "list":
+		incidents := backend.Incidents()
+		if client, ok := backend.(*apiClient); ok && client.err != nil {
+			return client.err
+		}
+		return printIncidents(out, incidents)
+
+	case len(positional) == 1:
+		namespace, deployment, err := splitService(positional[0])
+		if err != nil {
+			return err
+		}
+		if *by == "" {
+			return fmt.Errorf("-by is required")
+		}
+		if _, err := backend.Acknowledge(namespace, deployment, *by, time.Now()); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Acknowledged %s as %s\n", positional[0], *by)
+		return nil
+	}
+
+	return fmt.Errorf("usage: ack <namespace/deployment> [-by name] | ack list")
+}
+
+func printIncidents(out io.Writer, incidents []state.Incident) error {
+	if len(incidents) == 0 {
+		fmt.Fprintln(out, "No open incidents.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tSINCE\tACKNOWLEDGED BY\tREASON")
+	for _, incident := range incidents {
+		service := incident.Namespace
+		if incident.Deployment != "" {
+			service = state.Key(incident.Namespace, incident.Deployment)
+		}
+		ack := "-"
+		if incident.AcknowledgedBy != "" {
+			ack = fmt.Sprintf("%s (%s)", incident.AcknowledgedBy, incident.AcknowledgedAt.Format(time.RFC3339))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", service, incident.StartedAt.Format(time.RFC3339), ack, incident.Reason)
+	}
+	return w.Flush()
+}
+
+// serveIncidents implements the incidents REST API:
+//
+//	GET  /api/v1/incidents                      list open incidents
+//	POST /api/v1/incidents/{ns}/{deployment}/ack acknowledge one (ackRequest body)
+func (m *monitor) serveIncidents(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/incidents":
+		principal := auth.FromContext(r.Context())
+		incidents := []state.Incident{}
+		for _, incident := range m.store.Incidents() {
+			if principal.CanView(incident.Namespace) {
+				incidents = append(incidents, incident)
+			}
+		}
+		writeJSON(w, http.StatusOK, incidents)
+
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/ack"):
+		service := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/incidents/"), "/ack")
+		namespace, deployment, err := splitService(service)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		principal := auth.FromContext(r.Context())
+		if !principal.CanEdit(namespace) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		var req ackRequest
+		err = json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req)
+		// Authenticated callers act under their own name
+		if principal != nil {
+			req.By = principal.Name
+		}
+		if (err != nil && err != io.EOF) || req.By == "" {
+			http.Error(w, `a JSON body with "by" is required`, http.StatusBadRequest)
+			return
+		}
+		incident, err := m.store.Acknowledge(namespace, deployment, req.By, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, incident)
+
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (c *apiClient) Acknowledge(namespace, deployment, user string, now time.Time) (state.Incident, error) {
+	body, _ := json.Marshal(ackRequest{By: user})
+	resp, err := c.httpClient.Post(c.baseURL+"/api/v1/incidents/"+namespace+"/"+deployment+"/ack",
+		"application/json", bytes.NewReader(body))
+	if err != nil {
+		return state.Incident{}, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, http.StatusOK); err != nil {
+		return state.Incident{}, err
+	}
+
+	var incident state.Incident
+	err = json.NewDecoder(resp.Body).Decode(&incident)
+	return incident, err
+}
+
+// Incidents returns nil on error; the error is kept in c.err.
+func (c *apiClient) Incidents() []state.Incident {
+	resp, err := c.httpClient.Get(c.baseURL + "/api/v1/incidents")
+	if err != nil {
+		c.err = err
+		return nil
+	}
+	defer resp.Body.Close()
+	if c.err = checkResponse(resp, http.StatusOK); c.err != nil {
+		return nil
+	}
+
+	var incidents []state.Incident
+	c.err = json.NewDecoder(resp.Body).Decode(&incidents)
+	return incidents
+}
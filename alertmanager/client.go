@@ -0,0 +1,164 @@
+// alertmanager/client.go
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/state"
+	"github.com/Bharath-H-R/k8s-health/transport"
+)
+
+// Silences are mirrored as Alertmanager silences matching exactly these two
+// labels, the ones kube-state-metrics puts on deployment alerts.
+const (
+	NamespaceLabel  = "namespace"
+	DeploymentLabel = "deployment"
+)
+
+type matcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual *bool  `json:"isEqual,omitempty"`
+}
+
+type silence struct {
+	ID        string    `json:"id,omitempty"`
+	Matchers  []matcher `json:"matchers"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	CreatedBy string    `json:"createdBy"`
+	Comment   string    `json:"comment"`
+	Status    *struct {
+		State string `json:"state"`
+	} `json:"status,omitempty"`
+}
+
+// Client manages silences through the Alertmanager v2 API.
+type Client struct {
+	config     config.AlertmanagerConfig
+	httpClient *http.Client
+}
+
+func NewClient(cfg config.AlertmanagerConfig) *Client {
+	return &Client{
+		config:     cfg,
+		httpClient: transport.HTTPClient(config.ProxyConfig{}, nil, 10*time.Second),
+	}
+}
+
+// CreateSilence creates an Alertmanager silence for a deployment silence and
+// returns its ID.
+func (c *Client) CreateSilence(ctx context.Context, s state.Silence) (string, error) {
+	comment := s.Reason
+	if comment == "" {
+		comment = "Silenced in k8s-health"
+	}
+	startsAt := s.StartsAt
+	if startsAt.IsZero() {
+		startsAt = s.CreatedAt
+	}
+	body, _ := json.Marshal(silence{
+		Matchers: []matcher{
+			{Name: NamespaceLabel, Value: s.Namespace},
+			{Name: DeploymentLabel, Value: s.Deployment},
+		},
+		StartsAt:  startsAt,
+		EndsAt:    s.Until,
+		CreatedBy: s.CreatedBy,
+		Comment:   comment,
+	})
+
+	var created struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/v2/silences", body, &created); err != nil {
+		return "", fmt.Errorf("failed to create alertmanager silence: %w", err)
+	}
+	return created.SilenceID, nil
+}
+
+// ExpireSilence ends an Alertmanager silence early.
+func (c *Client) ExpireSilence(ctx context.Context, id string) error {
+	if err := c.do(ctx, http.MethodDelete, "/api/v2/silence/"+url.PathEscape(id), nil, nil); err != nil {
+		return fmt.Errorf("failed to expire alertmanager silence %s: %w", id, err)
+	}
+	return nil
+}
+
+// Silences returns the active and pending Alertmanager silences that silence
+// exactly one deployment, keyed by ID. Other silences can't be mirrored and
+// are left out.
+func (c *Client) Silences(ctx context.Context) (map[string]state.Silence, error) {
+	var all []silence
+	if err := c.do(ctx, http.MethodGet, "/api/v2/silences", nil, &all); err != nil {
+		return nil, fmt.Errorf("failed to list alertmanager silences: %w", err)
+	}
+
+	silences := make(map[string]state.Silence)
+	for _, s := range all {
+		if s.Status == nil || s.Status.State == "expired" || len(s.Matchers) != 2 {
+			continue
+		}
+		labels := make(map[string]string, 2)
+		for _, m := range s.Matchers {
+			if !m.IsRegex && (m.IsEqual == nil || *m.IsEqual) {
+				labels[m.Name] = m.Value
+			}
+		}
+		if labels[NamespaceLabel] == "" || labels[DeploymentLabel] == "" {
+			continue
+		}
+		silences[s.ID] = state.Silence{
+			Namespace:      labels[NamespaceLabel],
+			Deployment:     labels[DeploymentLabel],
+			Until:          s.EndsAt,
+			StartsAt:       s.StartsAt,
+			Reason:         s.Comment,
+			CreatedBy:      s.CreatedBy,
+			CreatedAt:      s.StartsAt,
+			AlertmanagerID: s.ID,
+		}
+	}
+	return silences, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.config.URL, "/")+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("alertmanager returned status %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
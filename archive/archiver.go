@@ -0,0 +1,215 @@
+// Package archive stores the full context of each alert in object storage,
+// so notifications can stay small and link to it.
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/transport"
+)
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="font-family: sans-serif;">
+<h2>{{.Title}}</h2>
+<p>Archived {{.ArchivedAt.Format "2006-01-02 15:04:05 MST"}}</p>
+<ul>{{range .Files}}
+<li><a href="{{.URL}}">{{.Name}}</a></li>{{end}}
+</ul>
+</body></html>
+`))
+
+// Archiver uploads alert artifacts under
+// <prefix><cluster>/<namespace>/<deployment>/<incident>/<timestamp>/.
+type Archiver struct {
+	s3         *s3Client
+	k8sClient  kubernetes.Interface
+	cluster    string
+	prefix     string
+	linkExpiry time.Duration
+}
+
+type file struct {
+	Name string
+	URL  string
+}
+
+func New(cfg config.ArchiveConfig, proxy config.ProxyConfig, k8sClient kubernetes.Interface, cluster string) (*Archiver, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid archive endpoint %q", cfg.Endpoint)
+	}
+
+	accessKey, secretKey := cfg.AccessKeyID, cfg.SecretAccessKey
+	var sessionToken string
+	if accessKey == "" {
+		accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("archive credentials not configured")
+	}
+
+	return &Archiver{
+		s3: &s3Client{
+			endpoint:     endpoint,
+			region:       cfg.Region,
+			bucket:       cfg.Bucket,
+			accessKey:    accessKey,
+			secretKey:    secretKey,
+			sessionToken: sessionToken,
+			httpClient:   transport.HTTPClient(proxy, nil, 60*time.Second),
+		},
+		k8sClient:  k8sClient,
+		cluster:    cluster,
+		prefix:     cfg.Prefix,
+		linkExpiry: cfg.LinkExpiry,
+	}, nil
+}
+
+// Archive uploads the rendered alert, the events of the failing pods and
+// their full logs (spilled to disk, or the tail otherwise), and returns a
+// link to an index of them that is valid for the configured expiry.
+func (a *Archiver) Archive(ctx context.Context, failedService health.FailedService, alertHTML string) (string, error) {
+	dep := failedService.Deployment
+	now := time.Now()
+	b := &batch{
+		archiver: a,
+		dir: path.Join(a.prefix+keySegment(a.cluster), dep.Namespace, dep.Name,
+			failedService.IncidentID, now.UTC().Format("20060102T150405Z")) + "/",
+		now: now,
+	}
+
+	if err := b.putBytes(ctx, "alert.html", "text/html; charset=utf-8", []byte(alertHTML)); err != nil {
+		return "", err
+	}
+
+	events, err := a.events(ctx, dep.Namespace, failedService.Pods)
+	if err != nil {
+		return "", err
+	}
+	if err := b.putBytes(ctx, "events.json", "application/json", events); err != nil {
+		return "", err
+	}
+
+	for _, pod := range failedService.Pods {
+		if pod.Container == "" {
+			continue
+		}
+		if err := b.putLogs(ctx, "logs/"+keySegment(pod.Pod+"_"+pod.Container)+".log", pod); err != nil {
+			return "", err
+		}
+	}
+
+	var index bytes.Buffer
+	if err := indexTemplate.Execute(&index, struct {
+		Title      string
+		ArchivedAt time.Time
+		Files      []file
+	}{
+		Title:      fmt.Sprintf("%s/%s %s", dep.Namespace, dep.Name, failedService.IncidentID),
+		ArchivedAt: now,
+		Files:      b.files,
+	}); err != nil {
+		return "", fmt.Errorf("failed to render archive index: %w", err)
+	}
+	if err := b.putBytes(ctx, "index.html", "text/html; charset=utf-8", index.Bytes()); err != nil {
+		return "", err
+	}
+	return b.files[len(b.files)-1].URL, nil
+}
+
+// batch uploads the files of one archive and remembers their links.
+type batch struct {
+	archiver *Archiver
+	dir      string
+	now      time.Time
+	files    []file
+}
+
+func (b *batch) put(ctx context.Context, name, contentType string, body io.Reader, size int64) error {
+	key := b.dir + name
+	if err := b.archiver.s3.put(ctx, key, contentType, body, size); err != nil {
+		return err
+	}
+	b.files = append(b.files, file{Name: name, URL: b.archiver.s3.presign(key, b.archiver.linkExpiry, b.now)})
+	return nil
+}
+
+func (b *batch) putBytes(ctx context.Context, name, contentType string, content []byte) error {
+	return b.put(ctx, name, contentType, bytes.NewReader(content), int64(len(content)))
+}
+
+// putLogs uploads a pod's spilled logs, falling back to the tail if they
+// weren't spilled or have been pruned since.
+func (b *batch) putLogs(ctx context.Context, name string, pod health.PodFailure) error {
+	if pod.LogFile != "" {
+		if f, err := os.Open(pod.LogFile); err == nil {
+			defer f.Close()
+			info, err := f.Stat()
+			if err != nil {
+				return err
+			}
+			return b.put(ctx, name, "text/plain; charset=utf-8", f, info.Size())
+		}
+	}
+	return b.putBytes(ctx, name, "text/plain; charset=utf-8", []byte(pod.Logs))
+}
+
+// events returns the events of the given pods as JSON, oldest first.
+func (a *Archiver) events(ctx context.Context, namespace string, pods []health.PodFailure) ([]byte, error) {
+	var all []corev1.Event
+	for _, pod := range pods {
+		events, err := a.k8sClient.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: "involvedObject.kind=Pod,involvedObject.name=" + pod.Pod,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events for pod %s: %w", pod.Pod, err)
+		}
+		all = append(all, events.Items...)
+	}
+	return json.MarshalIndent(all, "", "  ")
+}
+
+// Prune deletes archives older than retention and returns how many objects
+// it removed.
+func (a *Archiver) Prune(ctx context.Context, retention time.Duration, now time.Time) (int, error) {
+	objects, err := a.s3.list(ctx, a.prefix+keySegment(a.cluster)+"/")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list archives: %w", err)
+	}
+
+	removed := 0
+	for _, object := range objects {
+		if now.Sub(object.LastModified) < retention {
+			continue
+		}
+		if err := a.s3.delete(ctx, object.Key); err != nil {
+			return removed, fmt.Errorf("failed to delete %s: %w", object.Key, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// keySegment makes a name safe to use as a single segment of an object key.
+func keySegment(name string) string {
+	return strings.NewReplacer("/", "_", " ", "_").Replace(name)
+}
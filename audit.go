@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// sendInfraReport sends cluster-level findings, such as PDBs blocking node
+// drains, to the infra team.
+func (m *monitor) sendInfraReport(ctx context.Context) {
+	excluded := make(map[string]bool)
+	for _, ns := range m.cfg.ExcludedNamespaces {
+		excluded[ns] = true
+	}
+
+	pdbs, err := health.FindBlockingPDBs(ctx, m.k8sClient, m.cfg.PDB.BlockedThreshold,
+		func(namespace string) bool {
+			return excluded[namespace] || (m.shards != nil && !m.shards.Owns(namespace))
+		})
+	if err != nil {
+		log.Printf("Failed to check pod disruption budgets: %v", err)
+		return
+	}
+
+	now := time.Now()
+	report := health.InfraReport{
+		GeneratedAt: now,
+		BlockedPDBs: pdbs,
+		Window:      m.cfg.Report.Window,
+	}
+	for _, pdb := range pdbs {
+		log.Printf("PDB %s/%s blocks evictions: %s", pdb.Namespace, pdb.Name, pdb.Reason)
+	}
+
+	history := m.store.History(now.Add(-m.cfg.Report.Window))
+	if len(history) > 0 {
+		report.TopOffenders = state.TopOffenders(history, m.cfg.Report.TopOffenders, now)
+		report.Heatmap = state.NewHeatmap(history, int(m.cfg.Report.Window/(24*time.Hour)), now)
+	}
+
+	if len(report.BlockedPDBs) == 0 && len(report.TopOffenders) == 0 {
+		return
+	}
+	if m.dryRun || m.cfg.InfraEmail == "" {
+		log.Printf("Infra report has %d finding(s) and %d incident(s) in history (no email sent)", len(report.BlockedPDBs), len(history))
+		return
+	}
+
+	if err := m.emailSender.SendInfraReport(m.cfg.InfraEmail, report, m.cfg.PDB.BlockedThreshold); err != nil {
+		log.Printf("Failed to send infra report: %v", err)
+	} else {
+		log.Printf("Infra report sent to %s", m.cfg.InfraEmail)
+	}
+}
+
+// runAudit scores every monitored deployment against best practices and
+// sends each owner one low-severity report. It never changes the cluster.
+func (m *monitor) runAudit(ctx context.Context) {
+	log.Println("Starting Kubernetes best-practice audit...")
+	startTime := time.Now()
+
+	deployments, err := m.scanner.ScanDeployments(ctx)
+	if err != nil {
+		log.Printf("Failed to scan deployments: %v", err)
+		return
+	}
+
+	auditor := health.NewAuditor(m.k8sClient)
+
+	// Crashes that recovered between scans never alerted, so the digest is
+	// the only place they show up
+	intermittent := make(map[string][]state.CrashSummary)
+	var recovered []state.Crash
+	for _, crash := range m.store.Crashes(startTime.Add(-m.cfg.Report.IntermittentWindow)) {
+		if crash.SelfRecovered {
+			recovered = append(recovered, crash)
+		}
+	}
+	for _, summary := range state.SummarizeCrashes(recovered) {
+		key := state.Key(summary.Namespace, summary.Deployment)
+		intermittent[key] = append(intermittent[key], summary)
+	}
+
+	// Image advisories only go to owners of workloads that are failing
+	failing := make(map[string]bool)
+	if m.images != nil {
+		for key, result := range m.store.LastScan() {
+			failing[key] = !result.Healthy
+		}
+	}
+
+	// Group reports by owner so each owner gets a single email
+	reportsByOwner := make(map[string][]health.AuditReport)
+	stoppedByOwner := make(map[string][]health.DeploymentInfo)
+	dlByOwner := make(map[string]map[string]bool)
+	addDL := func(dep health.DeploymentInfo) {
+		if dlByOwner[dep.OwnerEmail] == nil {
+			dlByOwner[dep.OwnerEmail] = make(map[string]bool)
+		}
+		dlByOwner[dep.OwnerEmail][dep.OwnerDlEmail] = true
+	}
+	var findings int
+
+	for _, dep := range deployments {
+		// Hygiene findings don't matter while a deployment is stopped, but
+		// owners should know it still exists
+		if dep.Stopped != "" && !m.cfg.AlertOnStopped && !m.scaledByKEDA(ctx, dep) {
+			stoppedByOwner[dep.OwnerEmail] = append(stoppedByOwner[dep.OwnerEmail], dep)
+			addDL(dep)
+			continue
+		}
+		// Legacy ReplicaSets and pods are only monitored until migrated
+		if dep.Kind != "" {
+			continue
+		}
+
+		report, err := auditor.Audit(ctx, dep)
+		if err != nil {
+			log.Printf("Error auditing %s/%s: %v", dep.Namespace, dep.Name, err)
+			continue
+		}
+		report.Intermittent = intermittent[state.Key(dep.Namespace, dep.Name)]
+		if failing[state.Key(dep.Namespace, dep.Name)] {
+			report.ImageAdvisories = m.imageAdvisories(ctx, dep, startTime)
+		}
+		if len(report.Findings) == 0 && len(report.Intermittent) == 0 && len(report.ImageAdvisories) == 0 {
+			continue
+		}
+
+		findings += len(report.Findings)
+		reportsByOwner[dep.OwnerEmail] = append(reportsByOwner[dep.OwnerEmail], *report)
+		addDL(dep)
+	}
+
+	log.Printf("Audit found %d issue(s) across %d owner(s)", findings, len(reportsByOwner))
+
+	for owner := range dlByOwner {
+		reports, stopped := reportsByOwner[owner], stoppedByOwner[owner]
+		if m.dryRun {
+			log.Printf("Dry run: audit report for %s covers %d deployment(s) and %d stopped deployment(s) (no email sent)", owner, len(reports), len(stopped))
+			continue
+		}
+
+		var cc []string
+		for dl := range dlByOwner[owner] {
+			if dl != owner {
+				cc = append(cc, dl)
+			}
+		}
+
+		if err := m.emailSender.SendAuditReport(owner, cc, reports, stopped); err != nil {
+			log.Printf("Failed to send audit report to %s: %v", owner, err)
+		} else {
+			log.Printf("Audit report sent to %s", owner)
+		}
+		// Small delay to avoid overwhelming SMTP server
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	m.sendInfraReport(ctx)
+
+	log.Printf("Audit completed in %v", time.Since(startTime))
+}
+
+// scaledByKEDA reports whether a deployment scaled to zero is scaled by a
+// KEDA ScaledObject, so it's idle by design rather than stopped.
+func (m *monitor) scaledByKEDA(ctx context.Context, dep health.DeploymentInfo) bool {
+	if dep.Stopped != health.StoppedScaledToZero {
+		return false
+	}
+	so, err := health.FindScaledObject(ctx, m.k8sClient, dep)
+	if err != nil {
+		log.Printf("Warning: %s/%s: %v", dep.Namespace, dep.Name, err)
+	}
+	return so != nil
+}
+
+// imageAdvisories looks up each container image of a deployment in the image
+// metadata source, returning an advisory per image with something to report.
+func (m *monitor) imageAdvisories(ctx context.Context, dep health.DeploymentInfo, now time.Time) []string {
+	var advisories []string
+	for _, image := range dep.Images {
+		advisory, err := m.images.Advise(ctx, image.Image, now)
+		if err != nil {
+			log.Printf("Warning: %s/%s: %v", dep.Namespace, dep.Name, err)
+			continue
+		}
+		if advisory != "" {
+			advisories = append(advisories, fmt.Sprintf("%s (%s): %s", image.Container, image.Image, advisory))
+		}
+	}
+	return advisories
+}
@@ -0,0 +1,129 @@
+// Package auth authenticates REST API callers and decides which namespaces
+// they may see and change.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+)
+
+// AllNamespaces grants access to every namespace and to cluster-wide data.
+const AllNamespaces = "*"
+
+// ErrUnauthenticated means the request carried no valid credentials.
+var ErrUnauthenticated = errors.New("missing or invalid credentials")
+
+// Principal is an authenticated API caller.
+type Principal struct {
+	Name       string
+	Namespaces []string
+	// ReadOnly principals may view but not silence, acknowledge or recheck
+	ReadOnly bool
+}
+
+// CanView reports whether p may see a namespace's services.
+func (p *Principal) CanView(namespace string) bool {
+	if p == nil {
+		return true
+	}
+	for _, ns := range p.Namespaces {
+		if ns == AllNamespaces || ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// CanEdit reports whether p may act on a namespace's services.
+func (p *Principal) CanEdit(namespace string) bool {
+	return p == nil || !p.ReadOnly && p.CanView(namespace)
+}
+
+// ClusterWide reports whether p may see data spanning all namespaces.
+func (p *Principal) ClusterWide() bool {
+	return p.CanView(AllNamespaces)
+}
+
+// Authenticator identifies the caller of a request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// TokenAuthenticator accepts the bearer tokens listed in the config.
+type TokenAuthenticator struct {
+	tokens []config.APIToken
+}
+
+func NewTokenAuthenticator(tokens []config.APIToken) *TokenAuthenticator {
+	return &TokenAuthenticator{tokens: tokens}
+}
+
+func (a *TokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	// Compare against every token so timing doesn't reveal which matched
+	var match *config.APIToken
+	for i := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(a.tokens[i].Token)) == 1 {
+			match = &a.tokens[i]
+		}
+	}
+	if match == nil {
+		return nil, ErrUnauthenticated
+	}
+	return &Principal{Name: match.Name, Namespaces: match.Namespaces, ReadOnly: match.ReadOnly}, nil
+}
+
+// ChatPrincipal returns the principal for a Slack or Teams user, with the
+// namespaces users grants them, or none if they aren't listed.
+func ChatPrincipal(users []config.ChatUser, user string) *Principal {
+	for _, u := range users {
+		if u.User == user {
+			return &Principal{Name: user, Namespaces: u.Namespaces, ReadOnly: u.ReadOnly}
+		}
+	}
+	return &Principal{Name: user}
+}
+
+type contextKey struct{}
+
+// Middleware rejects requests that authn can't authenticate and makes the
+// principal available to next through FromContext.
+func Middleware(authn Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := authn.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="k8s-health-monitor"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, principal)))
+	})
+}
+
+// FromContext returns the request's principal, or nil if authentication is
+// disabled, in which case every check passes.
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(contextKey{}).(*Principal)
+	return principal
+}
+
+// Chain tries each authenticator in turn and returns the first principal.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, authn := range c {
+		if principal, err := authn.Authenticate(r); err == nil {
+			return principal, nil
+		}
+	}
+	return nil, ErrUnauthenticated
+}
@@ -0,0 +1,432 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/transport"
+)
+
+const (
+	sessionCookie = "k8s_health_session"
+	stateCookie   = "k8s_health_oidc_state"
+)
+
+// OIDC signs users in with an OpenID Connect provider (Azure AD, Google,
+// ...) using the authorization code flow, and keeps them signed in with an
+// HMAC-signed session cookie. Their groups map to namespaces.
+type OIDC struct {
+	cfg        config.OIDCConfig
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]*rsa.PublicKey
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// session is the signed content of the session cookie.
+type session struct {
+	Name       string   `json:"name"`
+	Namespaces []string `json:"namespaces"`
+	ReadOnly   bool     `json:"read_only"`
+	Expiry     int64    `json:"exp"`
+}
+
+func NewOIDC(cfg config.OIDCConfig) *OIDC {
+	return &OIDC{
+		cfg:        cfg,
+		httpClient: transport.HTTPClient(config.ProxyConfig{}, nil, 15*time.Second),
+	}
+}
+
+// Authenticate accepts requests carrying a valid session cookie.
+func (o *OIDC) Authenticate(r *http.Request) (*Principal, error) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+	payload, ok := o.verifyValue(cookie.Value)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	var s session
+	if err := json.Unmarshal(payload, &s); err != nil || time.Now().Unix() > s.Expiry {
+		return nil, ErrUnauthenticated
+	}
+	return &Principal{Name: s.Name, Namespaces: s.Namespaces, ReadOnly: s.ReadOnly}, nil
+}
+
+// Login redirects to the provider. After signing in, the user returns to the
+// path in the "next" query parameter.
+func (o *OIDC) Login(w http.ResponseWriter, r *http.Request) {
+	d, err := o.provider(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	next := r.URL.Query().Get("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+		next = "/"
+	}
+	state := randomString()
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    o.signValue([]byte(state + "|" + next)),
+		Path:     "/",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(o.cfg.RedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {o.cfg.ClientID},
+		"redirect_uri":  {o.cfg.RedirectURL},
+		"scope":         {strings.Join(o.cfg.Scopes, " ")},
+		"state":         {state},
+		"nonce":         {state},
+	}
+	http.Redirect(w, r, d.AuthorizationEndpoint+"?"+query.Encode(), http.StatusFound)
+}
+
+// Callback completes the sign-in and sets the session cookie.
+func (o *OIDC) Callback(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil {
+		http.Error(w, "sign-in expired, please retry", http.StatusBadRequest)
+		return
+	}
+	payload, ok := o.verifyValue(cookie.Value)
+	state, next, _ := strings.Cut(string(payload), "|")
+	if !ok || state == "" || r.URL.Query().Get("state") != state {
+		http.Error(w, "invalid sign-in state", http.StatusBadRequest)
+		return
+	}
+	if msg := r.URL.Query().Get("error"); msg != "" {
+		http.Error(w, "sign-in failed: "+msg, http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := o.exchange(r.Context(), r.URL.Query().Get("code"), state)
+	if err != nil {
+		http.Error(w, "sign-in failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	principal := o.principal(claims)
+	if len(principal.Namespaces) == 0 {
+		http.Error(w, fmt.Sprintf("%s is not in any group with access", principal.Name), http.StatusForbidden)
+		return
+	}
+
+	content, _ := json.Marshal(session{
+		Name:       principal.Name,
+		Namespaces: principal.Namespaces,
+		ReadOnly:   principal.ReadOnly,
+		Expiry:     time.Now().Add(o.cfg.SessionTTL).Unix(),
+	})
+	http.SetCookie(w, &http.Cookie{Name: stateCookie, Path: "/", MaxAge: -1})
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    o.signValue(content),
+		Path:     "/",
+		MaxAge:   int(o.cfg.SessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(o.cfg.RedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, next, http.StatusFound)
+}
+
+// ClearSession signs the user out.
+func ClearSession(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+}
+
+// principal maps the ID token's groups to namespaces. The user is read-only
+// unless a matching group grants write access.
+func (o *OIDC) principal(claims map[string]interface{}) *Principal {
+	name, _ := claims["email"].(string)
+	if name == "" {
+		name, _ = claims["sub"].(string)
+	}
+
+	groups := make(map[string]bool)
+	switch v := claims[o.cfg.GroupsClaim].(type) {
+	case []interface{}:
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups[s] = true
+			}
+		}
+	case string:
+		groups[v] = true
+	}
+
+	principal := &Principal{Name: name, ReadOnly: true}
+	seen := make(map[string]bool)
+	for _, mapping := range o.cfg.Groups {
+		if !groups[mapping.Group] {
+			continue
+		}
+		for _, ns := range mapping.Namespaces {
+			if !seen[ns] {
+				seen[ns] = true
+				principal.Namespaces = append(principal.Namespaces, ns)
+			}
+		}
+		if !mapping.ReadOnly {
+			principal.ReadOnly = false
+		}
+	}
+	return principal
+}
+
+// exchange redeems an authorization code and returns the verified claims of
+// the ID token.
+func (o *OIDC) exchange(ctx context.Context, code, nonce string) (map[string]interface{}, error) {
+	d, err := o.provider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {o.cfg.RedirectURL},
+		"client_id":     {o.cfg.ClientID},
+		"client_secret": {o.cfg.ClientSecret},
+	}
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := o.do(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()), &token); err != nil {
+		return nil, fmt.Errorf("token exchange: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+
+	claims, err := o.verifyIDToken(ctx, token.IDToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims["nonce"] != nonce {
+		return nil, fmt.Errorf("ID token nonce mismatch")
+	}
+	return claims, nil
+}
+
+// verifyIDToken checks an RS256-signed ID token's signature, issuer,
+// audience and expiry.
+func (o *OIDC) verifyIDToken(ctx context.Context, token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed ID token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported ID token algorithm %q", header.Alg)
+	}
+
+	key, err := o.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed ID token signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, fmt.Errorf("invalid ID token signature")
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if claims["iss"] != o.discovery.Issuer {
+		return nil, fmt.Errorf("ID token issuer mismatch")
+	}
+	if !hasAudience(claims["aud"], o.cfg.ClientID) {
+		return nil, fmt.Errorf("ID token audience mismatch")
+	}
+	if exp, _ := claims["exp"].(float64); time.Now().Unix() > int64(exp) {
+		return nil, fmt.Errorf("ID token expired")
+	}
+	return claims, nil
+}
+
+func hasAudience(aud interface{}, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []interface{}:
+		for _, a := range v {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// provider returns the provider's discovery document, fetching it once.
+func (o *OIDC) provider(ctx context.Context) (*discovery, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.discovery != nil {
+		return o.discovery, nil
+	}
+	var d discovery
+	wellKnown := strings.TrimRight(o.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := o.do(ctx, http.MethodGet, wellKnown, nil, &d); err != nil {
+		return nil, fmt.Errorf("OIDC discovery: %w", err)
+	}
+	o.discovery = &d
+	return o.discovery, nil
+}
+
+// key returns the provider's signing key with the given ID, refetching the
+// key set when it is unknown, e.g. after a rotation.
+func (o *OIDC) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	o.mu.Lock()
+	key, ok := o.keys[kid]
+	o.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := o.do(ctx, http.MethodGet, o.discovery.JWKSURI, nil, &jwks); err != nil {
+		return nil, fmt.Errorf("fetching signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	o.mu.Lock()
+	o.keys = keys
+	o.mu.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown ID token signing key %q", kid)
+}
+
+func (o *OIDC) do(ctx context.Context, method, endpoint string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// signValue returns payload with an HMAC so it can round-trip through a
+// cookie untampered.
+func (o *OIDC) signValue(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(o.cfg.SessionSecret))
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (o *OIDC) verifyValue(value string) ([]byte, bool) {
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, false
+	}
+	mac := hmac.New(sha256.New, []byte(o.cfg.SessionSecret))
+	mac.Write(payload)
+	return payload, hmac.Equal(sum, mac.Sum(nil))
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	content, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("malformed ID token")
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("malformed ID token")
+	}
+	return nil
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
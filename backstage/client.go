@@ -0,0 +1,173 @@
+// backstage/client.go
+package backstage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/transport"
+)
+
+// ComponentAnnotation links a deployment to its Backstage catalog component.
+const ComponentAnnotation = "backstage.io/component"
+
+type entity struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name        string            `json:"name"`
+		Namespace   string            `json:"namespace"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		Owner   string `json:"owner"`
+		Profile struct {
+			Email string `json:"email"`
+		} `json:"profile"`
+	} `json:"spec"`
+}
+
+// Owner is the resolved owning team or user of a component.
+type Owner struct {
+	Team         string
+	Email        string
+	SlackChannel string
+}
+
+// Client resolves deployment ownership from the Backstage catalog. Lookups are
+// cached for the lifetime of the client.
+type Client struct {
+	config     config.BackstageConfig
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]*Owner
+}
+
+func NewClient(cfg config.BackstageConfig) *Client {
+	return &Client{
+		config:     cfg,
+		httpClient: transport.HTTPClient(config.ProxyConfig{}, nil, 10*time.Second),
+		cache:      make(map[string]*Owner),
+	}
+}
+
+// ResolveOwner fills in missing owner fields for deployments annotated with a
+// Backstage component. Owner annotations on the deployment take precedence.
+func (c *Client) ResolveOwner(ctx context.Context, dep *health.DeploymentInfo) error {
+	ref := dep.Annotations[ComponentAnnotation]
+	if ref == "" {
+		return nil
+	}
+
+	owner, err := c.componentOwner(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("failed to resolve backstage component %q: %w", ref, err)
+	}
+
+	if dep.OwnerDlEmail == "" {
+		dep.OwnerDlEmail = owner.Email
+	}
+	if dep.OwnerEmail == "" {
+		dep.OwnerEmail = owner.Email
+	}
+	if dep.SlackChannel == "" {
+		dep.SlackChannel = owner.SlackChannel
+	}
+	if dep.Team == "" {
+		dep.Team = owner.Team
+	}
+	return nil
+}
+
+func (c *Client) componentOwner(ctx context.Context, ref string) (*Owner, error) {
+	c.mu.Lock()
+	owner, ok := c.cache[ref]
+	c.mu.Unlock()
+	if ok {
+		return owner, nil
+	}
+
+	namespace, name := splitRef(ref, "default")
+	component, err := c.getEntity(ctx, "component", namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if component.Spec.Owner == "" {
+		return nil, fmt.Errorf("component has no owner")
+	}
+
+	// Owner refs look like "group:default/payments", "user:alice" or "payments"
+	kind, ownerRef := "group", component.Spec.Owner
+	if i := strings.Index(ownerRef, ":"); i >= 0 {
+		kind, ownerRef = strings.ToLower(ownerRef[:i]), ownerRef[i+1:]
+	}
+	ownerNamespace, ownerName := splitRef(ownerRef, namespace)
+
+	ownerEntity, err := c.getEntity(ctx, kind, ownerNamespace, ownerName)
+	if err != nil {
+		return nil, err
+	}
+	if ownerEntity.Spec.Profile.Email == "" {
+		return nil, fmt.Errorf("%s %s/%s has no profile email", kind, ownerNamespace, ownerName)
+	}
+
+	owner = &Owner{
+		Team:  ownerName,
+		Email: ownerEntity.Spec.Profile.Email,
+	}
+	if c.config.SlackChannelAnnotation != "" {
+		owner.SlackChannel = ownerEntity.Metadata.Annotations[c.config.SlackChannelAnnotation]
+	}
+
+	c.mu.Lock()
+	c.cache[ref] = owner
+	c.mu.Unlock()
+
+	return owner, nil
+}
+
+func (c *Client) getEntity(ctx context.Context, kind, namespace, name string) (*entity, error) {
+	endpoint := fmt.Sprintf("%s/api/catalog/entities/by-name/%s/%s/%s",
+		strings.TrimRight(c.config.BaseURL, "/"),
+		url.PathEscape(kind), url.PathEscape(namespace), url.PathEscape(name))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("backstage returned status %d for %s %s/%s", resp.StatusCode, kind, namespace, name)
+	}
+
+	var e entity
+	if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+		return nil, fmt.Errorf("failed to decode %s %s/%s: %w", kind, namespace, name, err)
+	}
+	return &e, nil
+}
+
+// splitRef splits "namespace/name" refs, falling back to defaultNamespace.
+func splitRef(ref, defaultNamespace string) (string, string) {
+	if i := strings.Index(ref, "/"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return defaultNamespace, ref
+}
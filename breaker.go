@@ -0,0 +1,135 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+	"github.com/Bharath-H-R/k8s-health/health"
+)
+
+// allowNotification counts an alert against the notification circuit
+// breaker and reports whether it may go out.
+func (m *monitor) allowNotification(dep health.DeploymentInfo) bool {
+	cfg := m.cfg.CircuitBreaker
+	if cfg.Threshold <= 0 {
+		return true
+	}
+	allowed, err := m.store.AllowNotification(dep.Namespace, dep.Name, cfg.Threshold, cfg.Window, time.Now())
+	if err != nil {
+		log.Printf("Failed to update the notification circuit breaker: %v", err)
+	}
+	if !allowed {
+		log.Printf("Skipping notification for %s/%s: notification circuit breaker is open", dep.Namespace, dep.Name)
+	}
+	return allowed
+}
+
+// reportBreaker sends the infra team one summary when the notification
+// circuit breaker has tripped.
+func (m *monitor) reportBreaker() {
+	cfg := m.cfg.CircuitBreaker
+	breaker := m.store.Breaker()
+	if cfg.Threshold <= 0 || !breaker.Tripped() || breaker.SummarySent {
+		return
+	}
+
+	log.Printf("Warning: notification circuit breaker tripped at %s after %d alerts within %s; holding back alerts for %d service(s)",
+		breaker.TrippedAt.Format(time.RFC3339), cfg.Threshold, cfg.Window, len(breaker.Held))
+	if cfg.Email == "" {
+		return
+	}
+
+	report := health.InfraReport{
+		GeneratedAt: time.Now(),
+		CircuitBreaker: &health.BreakerTrip{
+			TrippedAt: breaker.TrippedAt,
+			Threshold: cfg.Threshold,
+			Window:    cfg.Window,
+			Held:      breaker.Held,
+		},
+	}
+	if err := m.emailSender.SendInfraReport(cfg.Email, report, m.cfg.PDB.BlockedThreshold); err != nil {
+		// Retried after the next scan
+		log.Printf("Failed to send circuit breaker summary: %v", err)
+		return
+	}
+	m.usage.AddNotification()
+	log.Printf("Circuit breaker summary sent to %s", cfg.Email)
+	if err := m.store.MarkBreakerSummarySent(); err != nil {
+		log.Printf("Failed to save the notification circuit breaker: %v", err)
+	}
+}
+
+// serveBreaker shows the notification circuit breaker on GET /api/v1/breaker
+// and closes it on POST /api/v1/breaker/reset, so held alerts go out from the
+// next scan.
+func (m *monitor) serveBreaker(w http.ResponseWriter, r *http.Request) {
+	principal := auth.FromContext(r.Context())
+	switch {
+	case r.URL.Path == "/api/v1/breaker" && r.Method == http.MethodGet:
+		if !principal.ClusterWide() {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		writeJSON(w, http.StatusOK, m.breakerStatus())
+	case r.URL.Path == "/api/v1/breaker/reset" && r.Method == http.MethodPost:
+		if !principal.CanEdit(auth.AllNamespaces) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		previous, err := m.store.ResetBreaker()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if previous.Tripped() {
+			by := "anonymous"
+			if principal != nil {
+				by = principal.Name
+			}
+			log.Printf("Notification circuit breaker reset by %s; %d held service(s) alert from the next scan",
+				by, len(previous.Held))
+		}
+		writeJSON(w, http.StatusOK, m.breakerStatus())
+	case r.URL.Path == "/api/v1/breaker" || r.URL.Path == "/api/v1/breaker/reset":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+type breakerStatus struct {
+	Tripped   bool      `json:"tripped"`
+	TrippedAt time.Time `json:"tripped_at,omitempty"`
+	// Sent is how many alerts went out within the window
+	Sent      int           `json:"sent"`
+	Threshold int           `json:"threshold"`
+	Window    time.Duration `json:"window_ns"`
+	Held      []string      `json:"held"`
+}
+
+func (m *monitor) breakerStatus() breakerStatus {
+	breaker := m.store.Breaker()
+	// A breaker past its window resets on the next alert
+	status := breakerStatus{
+		Tripped:   breaker.Tripped() && time.Since(breaker.TrippedAt) < m.cfg.CircuitBreaker.Window,
+		TrippedAt: breaker.TrippedAt,
+		Threshold: m.cfg.CircuitBreaker.Threshold,
+		Window:    m.cfg.CircuitBreaker.Window,
+		Held:      breaker.Held,
+	}
+	if !status.Tripped {
+		status.TrippedAt, status.Held = time.Time{}, nil
+	}
+	if status.Held == nil {
+		status.Held = []string{}
+	}
+	for _, sent := range breaker.Sent {
+		if time.Since(sent) < m.cfg.CircuitBreaker.Window {
+			status.Sent++
+		}
+	}
+	return status
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"time"
+)
+
+// channelStatus is the outcome of a notification channel's self-test.
+type channelStatus struct {
+	// Channel is "smtp", "slack" or "webhook/<name>"
+	Channel   string    `json:"channel"`
+	OK        bool      `json:"ok"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// testChannels checks that every configured notification channel can
+// deliver, without sending anything: the SMTP relays accept a connection,
+// the Slack webhook is still valid and the webhooks answer. Broken channels
+// are logged and kept for metrics and the run summary.
+func (m *monitor) testChannels() {
+	now := time.Now()
+	var statuses []channelStatus
+	add := func(channel string, err error) {
+		status := channelStatus{Channel: channel, OK: err == nil, CheckedAt: now}
+		if err != nil {
+			status.Error = err.Error()
+			log.Printf("Warning: notification channel %s is broken: %v", channel, err)
+		}
+		statuses = append(statuses, status)
+	}
+
+	add("smtp", m.emailSender.CheckRelay())
+	if m.slack != nil {
+		add("slack", m.slack.Check())
+	}
+	if m.webhooks != nil {
+		errs := m.webhooks.Check()
+		for _, target := range m.cfg.Webhooks {
+			add("webhook/"+target.Name, errs[target.Name])
+		}
+	}
+
+	m.mu.Lock()
+	m.channels = statuses
+	m.mu.Unlock()
+}
+
+// brokenChannels returns the channels that failed their last self-test,
+// sorted by name.
+func (m *monitor) brokenChannels() []channelStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var broken []channelStatus
+	for _, status := range m.channels {
+		if !status.OK {
+			broken = append(broken, status)
+		}
+	}
+	sort.Slice(broken, func(i, j int) bool { return broken[i].Channel < broken[j].Channel })
+	return broken
+}
+
+// writeChannelMetrics writes whether each channel passed its last self-test.
+func (m *monitor) writeChannelMetrics(w io.Writer) {
+	m.mu.Lock()
+	channels := m.channels
+	m.mu.Unlock()
+	if len(channels) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "# HELP k8s_health_channel_up Whether a notification channel passed its last self-test.\n")
+	fmt.Fprintf(w, "# TYPE k8s_health_channel_up gauge\n")
+	for _, status := range channels {
+		up := 0
+		if status.OK {
+			up = 1
+		}
+		fmt.Fprintf(w, "k8s_health_channel_up{channel=%q} %d\n", status.Channel, up)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+const chatHelp = "Usage:\n" +
+	"`status [namespace/deployment]` current health, or all open incidents\n" +
+	"`ack namespace/deployment` take an incident\n" +
+	"`silence namespace/deployment duration [reason]` mute notifications, e.g. `silence payments/checkout 2h deploying fix`\n" +
+	"`unsilence namespace/deployment` lift a silence early\n" +
+	"`recheck namespace[/deployment]` check right away"
+
+// runChatCommand runs a slash command or bot mention from Slack or Teams on
+// behalf of user and returns the reply. It offers the same operations as the
+// REST API, limited to the namespaces the user may view or change.
+func (m *monitor) runChatCommand(ctx context.Context, user, text string) string {
+	args := strings.Fields(text)
+	if len(args) == 0 {
+		return chatHelp
+	}
+	now := time.Now()
+	principal := m.chatPrincipal(user)
+	if principal != nil && len(principal.Namespaces) == 0 {
+		return fmt.Sprintf(":no_entry: %s has no access to any namespace; ask an admin to add them to api_auth.chat_users", user)
+	}
+	forbidden := func(namespace string) string {
+		return fmt.Sprintf(":no_entry: %s may not do that in %s", user, namespace)
+	}
+
+	switch {
+	case args[0] == "status" && len(args) == 1:
+		var incidents []state.Incident
+		for _, incident := range m.store.Incidents() {
+			if principal.CanView(incident.Namespace) {
+				incidents = append(incidents, incident)
+			}
+		}
+		if len(incidents) == 0 {
+			return ":white_check_mark: No open incidents."
+		}
+		lines := []string{fmt.Sprintf("%d open incident(s):", len(incidents))}
+		for _, incident := range incidents {
+			lines = append(lines, "• "+describeIncident(incident))
+		}
+		return strings.Join(lines, "\n")
+
+	case args[0] == "status" && len(args) == 2:
+		namespace, deployment, err := splitService(args[1])
+		if err != nil {
+			return err.Error()
+		}
+		if !principal.CanView(namespace) {
+			return forbidden(namespace)
+		}
+		result, ok := m.store.LastScan()[state.Key(namespace, deployment)]
+		if !ok {
+			return fmt.Sprintf("%s has not been checked; is it monitored?", args[1])
+		}
+		lines := []string{fmt.Sprintf(":white_check_mark: %s healthy as of %s", args[1], result.CheckedAt.Format(time.RFC1123))}
+		if !result.Healthy {
+			lines[0] = fmt.Sprintf(":red_circle: %s unhealthy as of %s: %s", args[1], result.CheckedAt.Format(time.RFC1123), result.Reason)
+		} else if result.Stopped {
+			lines[0] = fmt.Sprintf(":double_vertical_bar: %s as of %s: %s", args[1], result.CheckedAt.Format(time.RFC1123), result.Reason)
+		}
+		if incident, ok := m.store.Incident(namespace, deployment); ok {
+			lines = append(lines, describeIncident(incident))
+		}
+		if silence, ok := m.store.Silenced(namespace, deployment, now); ok {
+			lines = append(lines, fmt.Sprintf(":no_bell: Silenced until %s by %s", silence.Until.Format(time.RFC1123), silence.CreatedBy))
+		}
+		return strings.Join(lines, "\n")
+
+	case args[0] == "ack" && len(args) == 2:
+		namespace, deployment, err := splitService(args[1])
+		if err != nil {
+			return err.Error()
+		}
+		if !principal.CanEdit(namespace) {
+			return forbidden(namespace)
+		}
+		if _, err := m.store.Acknowledge(namespace, deployment, user, now); err != nil {
+			return fmt.Sprintf(":warning: %v", err)
+		}
+		return fmt.Sprintf(":eyes: %s acknowledged by %s", args[1], user)
+
+	case args[0] == "silence" && len(args) >= 3:
+		namespace, deployment, err := splitService(args[1])
+		if err != nil {
+			return err.Error()
+		}
+		if !principal.CanEdit(namespace) {
+			return forbidden(namespace)
+		}
+		duration, err := time.ParseDuration(args[2])
+		if err != nil || duration <= 0 {
+			return fmt.Sprintf("Invalid duration %q", args[2])
+		}
+		reason := strings.Join(args[3:], " ")
+		if reason == "" {
+			reason = "Silenced from chat"
+		}
+		silence := state.Silence{
+			Namespace:  namespace,
+			Deployment: deployment,
+			Until:      now.Add(duration),
+			Reason:     reason,
+			CreatedBy:  user,
+			CreatedAt:  now,
+		}
+		if err := m.store.AddSilence(silence); err != nil {
+			return fmt.Sprintf(":warning: %v", err)
+		}
+		return fmt.Sprintf(":no_bell: %s silenced for %s by %s", args[1], duration, user)
+
+	case args[0] == "unsilence" && len(args) == 2:
+		namespace, deployment, err := splitService(args[1])
+		if err != nil {
+			return err.Error()
+		}
+		if !principal.CanEdit(namespace) {
+			return forbidden(namespace)
+		}
+		removed, err := m.store.RemoveSilence(namespace, deployment)
+		if err != nil {
+			return fmt.Sprintf(":warning: %v", err)
+		}
+		if !removed {
+			return fmt.Sprintf("%s is not silenced", args[1])
+		}
+		return fmt.Sprintf(":bell: Silence for %s lifted by %s", args[1], user)
+
+	case args[0] == "recheck" && len(args) == 2:
+		namespace, deployment, _ := strings.Cut(args[1], "/")
+		if !principal.CanEdit(namespace) {
+			return forbidden(namespace)
+		}
+		results, err := m.recheckTarget(ctx, namespace, deployment)
+		if err != nil {
+			return fmt.Sprintf(":warning: %v", err)
+		}
+		var lines []string
+		for _, result := range results {
+			if result.Stopped {
+				lines = append(lines, fmt.Sprintf(":double_vertical_bar: %s %s", state.Key(result.Namespace, result.Deployment), result.Reason))
+			} else if result.Healthy {
+				lines = append(lines, fmt.Sprintf(":white_check_mark: %s healthy", state.Key(result.Namespace, result.Deployment)))
+			} else {
+				lines = append(lines, fmt.Sprintf(":red_circle: %s %s: %s", state.Key(result.Namespace, result.Deployment), result.Classification, result.Reason))
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	return chatHelp
+}
+
+// chatPrincipal returns who a chat user is for authorization: nil, allowing
+// everything, while the REST API is open too, else the namespaces
+// api_auth.chat_users grants them.
+func (m *monitor) chatPrincipal(user string) *auth.Principal {
+	if m.authn == nil {
+		return nil
+	}
+	return auth.ChatPrincipal(m.cfg.APIAuth.ChatUsers, user)
+}
+
+func describeIncident(incident state.Incident) string {
+	service := incident.Namespace
+	if incident.Deployment != "" {
+		service = state.Key(incident.Namespace, incident.Deployment)
+	}
+	description := fmt.Sprintf("%s failing since %s: %s", service, incident.StartedAt.Format(time.RFC1123), incident.Reason)
+	if incident.AcknowledgedBy != "" {
+		description += fmt.Sprintf(" (acknowledged by %s)", incident.AcknowledgedBy)
+	}
+	return description
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// parseInterleaved parses flags that may appear before, between or after the
+// positional arguments, which it returns in order.
+func parseInterleaved(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		flags.Parse(args)
+		if flags.NArg() == 0 {
+			return positional
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// openStateFile opens the state store named in the config for commands that
+// edit it directly. A running daemon overwrites such edits on its next save,
+// so those commands also accept -server to go through its API instead.
+func openStateFile(configPath string) (*state.Store, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.State.Path == "" {
+		return nil, fmt.Errorf("state.path is not set; use -server to reach a running daemon")
+	}
+	store, err := state.Open(cfg.State.Path)
+	if err != nil {
+		return nil, err
+	}
+	store.SetCompressed(cfg.State.Compression == config.StateCompressionGzip)
+	return store, nil
+}
+
+// apiClient talks to a running daemon's REST API on behalf of the CLI.
+type apiClient struct {
+	baseURL    string
+	httpClient *http.Client
+	// err is the last error from methods that can't return one
+	err error
+}
+
+// newAPIClient returns a client for the daemon at server, authenticating
+// with the token in $K8S_HEALTH_API_TOKEN if set.
+func newAPIClient(server string) *apiClient {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	if token := os.Getenv("K8S_HEALTH_API_TOKEN"); token != "" {
+		httpClient.Transport = bearerTransport{token: token, next: http.DefaultTransport}
+	}
+	return &apiClient{
+		baseURL:    strings.TrimRight(server, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// bearerTransport adds a bearer token to every request.
+type bearerTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.next.RoundTrip(req)
+}
+
+func checkResponse(resp *http.Response, want int) error {
+	if resp.StatusCode == want {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+func splitService(service string) (string, string, error) {
+	namespace, deployment, ok := strings.Cut(service, "/")
+	if !ok || namespace == "" || deployment == "" {
+		return "", "", fmt.Errorf("expected namespace/deployment, got %q", service)
+	}
+	return namespace, deployment, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// detectClusterIncidents finds failures too widespread to be any one owner's
+// problem and returns them with the keys of the services they cover.
+func (m *monitor) detectClusterIncidents(checked []checkedDeployment) ([]health.ClusterIncident, map[string]bool) {
+	cfg := m.cfg.ClusterIncident
+	covered := make(map[string]bool)
+	var incidents []health.ClusterIncident
+
+	newIncident := func(namespace string, deps []checkedDeployment) health.ClusterIncident {
+		incident := health.ClusterIncident{Namespace: namespace, Total: len(deps), Classifications: make(map[string]int)}
+		for _, c := range deps {
+			if c.result.Healthy {
+				continue
+			}
+			key := state.Key(c.dep.Namespace, c.dep.Name)
+			incident.Failed++
+			incident.Classifications[c.result.Classification]++
+			incident.Services = append(incident.Services, key)
+			covered[key] = true
+		}
+		sort.Strings(incident.Services)
+		return incident
+	}
+
+	failed := 0
+	byNamespace := make(map[string][]checkedDeployment)
+	for _, c := range checked {
+		if !c.result.Healthy {
+			failed++
+		}
+		byNamespace[c.dep.Namespace] = append(byNamespace[c.dep.Namespace], c)
+	}
+
+	if cfg.FailurePercent > 0 && len(checked) >= cfg.MinServices &&
+		float64(failed)*100 >= cfg.FailurePercent*float64(len(checked)) {
+		return []health.ClusterIncident{newIncident("", checked)}, covered
+	}
+
+	if cfg.NamespaceMinServices > 0 {
+		for namespace, deps := range byNamespace {
+			if len(deps) < cfg.NamespaceMinServices {
+				continue
+			}
+			allFailed := true
+			for _, c := range deps {
+				allFailed = allFailed && !c.result.Healthy
+			}
+			if allFailed {
+				incidents = append(incidents, newIncident(namespace, deps))
+			}
+		}
+		sort.Slice(incidents, func(i, j int) bool { return incidents[i].Namespace < incidents[j].Namespace })
+	}
+	return incidents, covered
+}
+
+// notifyClusterIncidents sends one platform alert for the incidents to the
+// infra team, at most once per notification cooldown per incident.
+func (m *monitor) notifyClusterIncidents(incidents []health.ClusterIncident) {
+	now := time.Now()
+	if m.clusterNotified == nil {
+		m.clusterNotified = make(map[string]time.Time)
+	}
+	active := make(map[string]bool, len(incidents))
+	var due []health.ClusterIncident
+	for _, incident := range incidents {
+		scope := describeScope(incident)
+		log.Printf("Warning: cluster-level incident in %s: %d of %d services failing", scope, incident.Failed, incident.Total)
+		active[incident.Namespace] = true
+		if last, ok := m.clusterNotified[incident.Namespace]; !ok || now.Sub(last) >= m.cfg.State.NotifyCooldown {
+			due = append(due, incident)
+		}
+	}
+	// Forget incidents that ended, so a new one alerts straight away
+	for namespace := range m.clusterNotified {
+		if !active[namespace] {
+			delete(m.clusterNotified, namespace)
+		}
+	}
+
+	if len(due) == 0 || m.dryRun {
+		return
+	}
+
+	if m.cfg.InfraEmail != "" {
+		report := health.InfraReport{GeneratedAt: now, ClusterIncidents: due}
+		if err := m.emailSender.SendInfraReport(m.cfg.InfraEmail, report, m.cfg.PDB.BlockedThreshold); err != nil {
+			log.Printf("Failed to send cluster-level incident alert: %v", err)
+		} else {
+			m.usage.AddNotification()
+			log.Printf("Cluster-level incident alert sent to %s", m.cfg.InfraEmail)
+		}
+	}
+	if m.slack != nil {
+		var lines []string
+		for _, incident := range due {
+			lines = append(lines, fmt.Sprintf(":fire: *Cluster-level incident in %s:* %d of %d services failing; per-owner alerts are suppressed.",
+				describeScope(incident), incident.Failed, incident.Total))
+		}
+		if err := m.slack.PostMessage(strings.Join(lines, "\n")); err != nil {
+			log.Printf("Failed to send slack cluster-level incident: %v", err)
+		} else {
+			m.usage.AddNotification()
+		}
+	}
+
+	for _, incident := range due {
+		m.clusterNotified[incident.Namespace] = now
+	}
+}
+
+// findHotspots locates the nodes of failing pods and returns the nodes, node
+// groups and zones the failures concentrate on.
+func (m *monitor) findHotspots(ctx context.Context, checked []checkedDeployment) []health.Hotspot {
+	cfg := m.cfg.Topology
+	if cfg.Disabled {
+		return nil
+	}
+
+	topology := health.NewTopology(m.k8sClient, cfg.NodeGroupLabels)
+	pods := make(map[string][]health.PodFailure)
+	for _, c := range checked {
+		if c.result.Healthy || len(c.result.Pods) == 0 {
+			continue
+		}
+		if err := topology.Locate(ctx, c.result.Pods); err != nil {
+			log.Printf("Warning: %v", err)
+			return nil
+		}
+		pods[state.Key(c.dep.Namespace, c.dep.Name)] = c.result.Pods
+	}
+
+	hotspots := health.FindHotspots(pods, cfg.MinPods, cfg.Percent)
+	for _, hotspot := range hotspots {
+		log.Printf("Warning: %d of %d failing pods, from %d deployments, run on %s %s",
+			hotspot.FailingPods, hotspot.TotalFailingPods, hotspot.Deployments, hotspot.Kind, hotspot.Name)
+	}
+	return hotspots
+}
+
+// hotspotFor returns the first hotspot any of the pods runs in.
+func hotspotFor(hotspots []health.Hotspot, pods []health.PodFailure) *health.Hotspot {
+	for i := range hotspots {
+		for _, pod := range pods {
+			if hotspots[i].Covers(pod) {
+				return &hotspots[i]
+			}
+		}
+	}
+	return nil
+}
+
+func describeScope(incident health.ClusterIncident) string {
+	if incident.Namespace == "" {
+		return "the cluster"
+	}
+	return "namespace " + incident.Namespace
+}
@@ -0,0 +1,209 @@
+// cmdb/servicenow.go
+package cmdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/transport"
+)
+
+// CIAnnotation holds the CMDB configuration item (sys_id or name) of a deployment.
+const CIAnnotation = "cmdb_ci"
+
+// ciPattern is what a CI annotation may contain. Anything else, notably "^",
+// could change the meaning of the encoded query it is put into.
+var ciPattern = regexp.MustCompile(`^[A-Za-z0-9 ._-]+$`)
+
+// ServiceNow timestamps are returned in UTC in this layout.
+const timeLayout = "2006-01-02 15:04:05"
+
+type configItem struct {
+	SysID           string `json:"sys_id"`
+	Name            string `json:"name"`
+	AssignmentGroup string `json:"assignment_group"`
+}
+
+type group struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type freezeRecord struct {
+	Number           string `json:"number"`
+	ShortDescription string `json:"short_description"`
+	StartDate        string `json:"start_date"`
+	EndDate          string `json:"end_date"`
+}
+
+// Client looks up CI ownership and change freezes in ServiceNow.
+type Client struct {
+	config     config.CMDBConfig
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	groups map[string]*group
+}
+
+func NewClient(cfg config.CMDBConfig) *Client {
+	return &Client{
+		config:     cfg,
+		httpClient: transport.HTTPClient(config.ProxyConfig{}, nil, 15*time.Second),
+		groups:     make(map[string]*group),
+	}
+}
+
+// Enrich routes a failed service to its CI's assignment group and flags it if
+// the failure happened during an active change freeze.
+func (c *Client) Enrich(ctx context.Context, failedService *health.FailedService) error {
+	ci := failedService.Deployment.Annotations[CIAnnotation]
+	if ci == "" {
+		return nil
+	}
+	if !ciPattern.MatchString(ci) {
+		return fmt.Errorf("invalid %s annotation %q: only letters, digits, spaces, '.', '_' and '-' are allowed", CIAnnotation, ci)
+	}
+
+	var items []configItem
+	query := fmt.Sprintf("sys_id=%s^ORname=%s", ci, ci)
+	if err := c.query(ctx, "cmdb_ci", query, "sys_id,name,assignment_group", &items); err != nil {
+		return fmt.Errorf("failed to look up CI %q: %w", ci, err)
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("CI %q not found", ci)
+	}
+	// Routing on an ambiguous name could send alerts to another team
+	if len(items) > 1 {
+		return fmt.Errorf("CI %q matches %d configuration items", ci, len(items))
+	}
+	item := items[0]
+
+	if item.AssignmentGroup != "" {
+		g, err := c.group(ctx, item.AssignmentGroup)
+		if err != nil {
+			return err
+		}
+		if g.Email != "" {
+			failedService.Deployment.OwnerDlEmail = g.Email
+		}
+		if failedService.Deployment.Team == "" {
+			failedService.Deployment.Team = g.Name
+		}
+	}
+
+	freeze, err := c.activeFreeze(ctx, item.SysID, failedService.CheckTime)
+	if err != nil {
+		return err
+	}
+	failedService.ChangeFreeze = freeze
+
+	return nil
+}
+
+func (c *Client) group(ctx context.Context, sysID string) (*group, error) {
+	c.mu.Lock()
+	g, ok := c.groups[sysID]
+	c.mu.Unlock()
+	if ok {
+		return g, nil
+	}
+
+	var groups []group
+	if err := c.query(ctx, "sys_user_group", "sys_id="+sysID, "name,email", &groups); err != nil {
+		return nil, fmt.Errorf("failed to look up assignment group: %w", err)
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("assignment group %s not found", sysID)
+	}
+	g = &groups[0]
+
+	c.mu.Lock()
+	c.groups[sysID] = g
+	c.mu.Unlock()
+
+	return g, nil
+}
+
+// activeFreeze runs the configured freeze query for the CI and returns the
+// first record whose window contains at.
+func (c *Client) activeFreeze(ctx context.Context, ciSysID string, at time.Time) (*health.ChangeFreeze, error) {
+	if c.config.FreezeQuery == "" {
+		return nil, nil
+	}
+
+	var records []freezeRecord
+	query := strings.ReplaceAll(c.config.FreezeQuery, "{ci}", ciSysID)
+	if err := c.query(ctx, c.config.FreezeTable, query, "number,short_description,start_date,end_date", &records); err != nil {
+		return nil, fmt.Errorf("failed to query change freezes: %w", err)
+	}
+
+	for _, r := range records {
+		start, err := time.ParseInLocation(timeLayout, r.StartDate, time.UTC)
+		if err != nil {
+			continue
+		}
+		end, err := time.ParseInLocation(timeLayout, r.EndDate, time.UTC)
+		if err != nil {
+			continue
+		}
+		if at.Before(start) || at.After(end) {
+			continue
+		}
+
+		return &health.ChangeFreeze{
+			ID:          r.Number,
+			Description: r.ShortDescription,
+			Start:       start,
+			End:         end,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func (c *Client) query(ctx context.Context, table, query, fields string, out interface{}) error {
+	params := url.Values{}
+	params.Set("sysparm_query", query)
+	params.Set("sysparm_fields", fields)
+	params.Set("sysparm_exclude_reference_link", "true")
+	params.Set("sysparm_limit", "20")
+
+	endpoint := fmt.Sprintf("%s/api/now/table/%s?%s",
+		strings.TrimRight(c.config.BaseURL, "/"), url.PathEscape(table), params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.config.Username != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("servicenow returned status %d for table %s", resp.StatusCode, table)
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("failed to decode servicenow response: %w", err)
+	}
+	return json.Unmarshal(envelope.Result, out)
+}
@@ -3,38 +3,990 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
-
-	"gopkg.in/yaml.v2"
+	"os"
+	"time"
 )
 
 type Config struct {
+	APIVersion string `yaml:"apiVersion"`
+	// Profile is the profile applied when loading, if any
+	Profile string `yaml:"-"`
+	// Warnings are deprecations found while loading, for the caller to log
+	Warnings           []string   `yaml:"-"`
 	SMTPConfig         SMTPConfig `yaml:"smtp"`
 	ExcludedNamespaces []string   `yaml:"excluded_namespaces"`
-	LogTailLines       int        `yaml:"log_tail_lines"`
+	// AllowedEmailDomains restricts owner annotations to these domains and
+	// their subdomains; empty allows any well-formed address
+	AllowedEmailDomains []string `yaml:"allowed_email_domains"`
+	LogTailLines        int      `yaml:"log_tail_lines"`
+	LogLimitBytes       int64    `yaml:"log_limit_bytes"`
+	LogFetchWorkers     int      `yaml:"log_fetch_workers"`
+	// LogBufferBytes bounds the log tail kept in memory per container, and
+	// LogRunBudgetBytes the log bytes read per run
+	LogBufferBytes    int64 `yaml:"log_buffer_bytes"`
+	LogRunBudgetBytes int64 `yaml:"log_run_budget_bytes"`
+	// LogSpillDir stores each failing container's full logs, up to
+	// LogSpillLimitBytes, on disk instead of only the tail
+	LogSpillDir        string               `yaml:"log_spill_dir"`
+	LogSpillLimitBytes int64                `yaml:"log_spill_limit_bytes"`
+	StructuredLogs     StructuredLogsConfig `yaml:"structured_logs"`
+	LogLinks           LogLinksConfig       `yaml:"log_links"`
+	ChangeLinks        ChangeLinksConfig    `yaml:"change_links"`
+	ClusterName        string               `yaml:"cluster_name"`
+	// DefaultLocale is used for deployments without a locale annotation
+	DefaultLocale string `yaml:"default_locale"`
+	// DashboardURLTemplate links alerts to a dashboard; {cluster}, {namespace}
+	// and {deployment} are substituted. The dashboard_url annotation overrides it.
+	DashboardURLTemplate string `yaml:"dashboard_url_template"`
+	// KnowledgeBase maps failure classifications to remediation steps,
+	// replacing the built-in steps for that classification
+	KnowledgeBase map[string][]string `yaml:"knowledge_base"`
+	InfraEmail    string              `yaml:"infra_email"`
+	Branding      BrandingConfig      `yaml:"branding"`
+	PDB           PDBConfig           `yaml:"pdb"`
+	// Namespaces Terminating for longer than this are reported to the infra team
+	StuckNamespaceThreshold time.Duration         `yaml:"stuck_namespace_threshold"`
+	Remediation             RemediationConfig     `yaml:"remediation"`
+	NotReady                NotReadyConfig        `yaml:"not_ready"`
+	Debug                   DebugConfig           `yaml:"debug"`
+	Slack                   SlackConfig           `yaml:"slack"`
+	Teams                   TeamsConfig           `yaml:"teams"`
+	Webhooks                []WebhookConfig       `yaml:"webhooks"`
+	Vault                   VaultConfig           `yaml:"vault"`
+	Proxy                   ProxyConfig           `yaml:"proxy"`
+	TLSPolicy               TLSPolicyConfig       `yaml:"tls_policy"`
+	Sharding                ShardingConfig        `yaml:"sharding"`
+	Daemon                  DaemonConfig          `yaml:"daemon"`
+	State                   StateConfig           `yaml:"state"`
+	EmailReplies            EmailReplyConfig      `yaml:"email_replies"`
+	Backstage               BackstageConfig       `yaml:"backstage"`
+	CMDB                    CMDBConfig            `yaml:"cmdb"`
+	Archive                 ArchiveConfig         `yaml:"archive"`
+	APIAuth                 APIAuthConfig         `yaml:"api_auth"`
+	Report                  ReportConfig          `yaml:"report"`
+	Dependencies            DependencyConfig      `yaml:"dependencies"`
+	ClusterIncident         ClusterIncidentConfig `yaml:"cluster_incident"`
+	Topology                TopologyConfig        `yaml:"topology"`
+	Drill                   DrillConfig           `yaml:"drill"`
+	Scan                    ScanConfig            `yaml:"scan"`
+	// AlertOnStopped checks deployments scaled to zero or paused like any
+	// other; by default they are listed as intentionally stopped instead
+	AlertOnStopped bool                `yaml:"alert_on_stopped"`
+	ImageAdvisory  ImageAdvisoryConfig `yaml:"image_advisory"`
+	Probation      ProbationConfig     `yaml:"probation"`
+	Ingest         IngestConfig        `yaml:"ingest"`
+	Alertmanager   AlertmanagerConfig  `yaml:"alertmanager"`
+	// MaintenanceInvites emails a calendar event to the owners and the infra
+	// DL when a silence is scheduled as a maintenance window through the API
+	MaintenanceInvites bool `yaml:"maintenance_invites"`
+	// Ownership asks owners to re-confirm their deployments periodically
+	Ownership OwnershipConfig `yaml:"ownership"`
+	// Onboarding reports new namespaces to the platform team
+	Onboarding OnboardingConfig `yaml:"onboarding"`
+	// Metadata reads owner, team and tier from labels too
+	Metadata MetadataConfig `yaml:"metadata"`
+	// RunLock keeps one-shot runs from overlapping
+	RunLock RunLockConfig `yaml:"run_lock"`
+	// Pods still Terminating this long after their grace period are
+	// reported as a platform issue
+	StuckPodThreshold time.Duration `yaml:"stuck_pod_threshold"`
+	// RemediationPages links alerts to a wiki page per remediation category
+	RemediationPages RemediationPagesConfig `yaml:"remediation_pages"`
+	// CircuitBreaker holds back alerts when too many go out at once
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
 }
 
 type SMTPConfig struct {
-	Host   string `yaml:"host"`
-	Port   int    `yaml:"port"`
-	From   string `yaml:"from"`
-	NoAuth bool   `yaml:"no_auth"`
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	From string `yaml:"from"`
+	// Auth is SMTPAuthPlain (the default) or SMTPAuthNone for relays that
+	// whitelist the sender
+	Auth string `yaml:"auth"`
+	// NoAuth is set from Auth
+	NoAuth bool `yaml:"-"`
+	// ReplyTo routes owner replies (e.g. "ACK") to an inbound email webhook
+	ReplyTo string `yaml:"reply_to"`
+	// RequestDSN asks the relay for delivery status notifications on failure,
+	// which come back to From and can be fed to /email/bounces
+	RequestDSN bool   `yaml:"request_dsn"`
+	Username   string `yaml:"username"`
+	Password   string `yaml:"password"`
+	// CredentialsSecret supplies username/password keys, overriding the above
+	CredentialsSecret *SecretRef `yaml:"credentials_secret"`
+	// CredentialsVaultPath reads the same keys from Vault instead
+	CredentialsVaultPath string `yaml:"credentials_vault_path"`
+	// Proxy overrides the global proxy; SMTP is tunnelled with HTTP CONNECT
+	Proxy *ProxyConfig `yaml:"proxy"`
+	// TLS applies to STARTTLS
+	TLS *TLSConfig `yaml:"tls"`
+	// Fallbacks are tried in order when the relay above fails. A relay that
+	// failed is tried last for RetryAfter, so a relay under maintenance
+	// doesn't slow down every alert
+	Fallbacks  []SMTPRelay   `yaml:"fallbacks"`
+	RetryAfter time.Duration `yaml:"retry_after"`
+}
+
+// SMTPRelay is a fallback SMTP relay. It shares the primary relay's settings
+// apart from its address and, if set, its credentials.
+type SMTPRelay struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// SMTP authentication modes.
+const (
+	SMTPAuthPlain = "plain"
+	SMTPAuthNone  = "none"
+)
+
+// Where workload metadata such as owners is read from.
+const (
+	MetadataAnnotations = "annotations"
+	MetadataLabels      = "labels"
+	MetadataBoth        = "both"
+)
+
+// MetadataConfig says where workload metadata is read from: annotations (the
+// default), labels, or both, for teams whose policy tooling requires labels.
+// Labels maps each annotation to the label read in its place; service_owner,
+// owner_dl, team and tier are read from labels of the same name unless
+// mapped. With both, Prefer names the source that wins when both set a key.
+// Label values can't hold an @, so EmailDomain is appended to owner labels
+// without one.
+type MetadataConfig struct {
+	Source      string            `yaml:"source"`
+	Prefer      string            `yaml:"prefer"`
+	Labels      map[string]string `yaml:"labels"`
+	EmailDomain string            `yaml:"email_domain"`
+}
+
+// TLSPolicyConfig restricts every outbound TLS connection (SMTP STARTTLS,
+// webhooks, Slack, Vault and the lookup APIs).
+type TLSPolicyConfig struct {
+	MinVersion   string   `yaml:"min_version"`
+	CipherSuites []string `yaml:"cipher_suites"`
+	// RequireSMTPTLS refuses to send mail to relays without STARTTLS
+	RequireSMTPTLS bool `yaml:"require_smtp_tls"`
+	// ValidateOnStartup connects to the SMTP relay at startup and fails fast
+	// if it can't meet the policy
+	ValidateOnStartup bool `yaml:"validate_on_startup"`
+}
+
+// TLSConfig sets up TLS towards an endpoint. CAFile replaces the system roots
+// with a PEM bundle; CertFile and KeyFile enable mutual TLS.
+type TLSConfig struct {
+	CAFile     string `yaml:"ca_file"`
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	ServerName string `yaml:"server_name"`
+}
+
+// ProxyConfig routes outbound notifications through an HTTP proxy. NoProxy
+// uses the NO_PROXY syntax (comma-separated hosts, domains and CIDRs).
+type ProxyConfig struct {
+	HTTPProxy string `yaml:"http_proxy"`
+	NoProxy   string `yaml:"no_proxy"`
+}
+
+// SecretRef points at a Kubernetes Secret the monitor reads and watches.
+type SecretRef struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+}
+
+// BrandingConfig controls the look of HTML emails. Colors are CSS values.
+type BrandingConfig struct {
+	CompanyName string `yaml:"company_name"`
+	ProductName string `yaml:"product_name"`
+	LogoURL     string `yaml:"logo_url"`
+	FooterText  string `yaml:"footer_text"`
+	// SupportEmail defaults to infra_email
+	SupportEmail        string `yaml:"support_email"`
+	SupportSlackChannel string `yaml:"support_slack_channel"`
+	PrimaryColor        string `yaml:"primary_color"`
+	AlertColor          string `yaml:"alert_color"`
+	SecondaryColor      string `yaml:"secondary_color"`
+	BackgroundColor     string `yaml:"background_color"`
+}
+
+// NotReadyConfig grades containers that run but fail readiness. Those NotReady
+// for less than Grace are assumed to be starting and not reported; those
+// NotReady for less than CriticalAfter are reported as warnings.
+type NotReadyConfig struct {
+	Grace         time.Duration `yaml:"grace"`
+	CriticalAfter time.Duration `yaml:"critical_after"`
+}
+
+// RemediationConfig controls automatic remediation for deployments that opt in
+// via the "remediation" annotation.
+type RemediationConfig struct {
+	NotReadyThreshold time.Duration `yaml:"not_ready_threshold"`
+	// ProbeRestartThreshold is how many restarts of a container killed by its
+	// liveness probe make the pod stuck
+	ProbeRestartThreshold int32         `yaml:"probe_restart_threshold"`
+	MaxActionsPerRun      int           `yaml:"max_actions_per_run"`
+	Cooldown              time.Duration `yaml:"cooldown"`
+	AuditLog              string        `yaml:"audit_log"`
+}
+
+// DebugConfig adds a kubectl debug command to alerts for critical failures
+// and, with a LaunchToken, lets responders start a debug container through
+// POST /api/v1/debug. With api_auth configured, callers authenticate as for
+// the REST API instead of with the shared LaunchToken, and need edit access
+// to the namespace. An empty Image disables both.
+type DebugConfig struct {
+	Image string `yaml:"image"`
+	// Classifications that get a debug command; defaults to crash and
+	// readiness failures
+	Classifications []string `yaml:"classifications"`
+	LaunchToken     string   `yaml:"launch_token"`
+}
+
+// TeamsConfig enables chat commands through a Teams outgoing webhook at
+// /teams/commands. OutgoingWebhookSecret is the base64 security token Teams
+// shows when the webhook is created.
+type TeamsConfig struct {
+	OutgoingWebhookSecret string `yaml:"outgoing_webhook_secret"`
+}
+
+type SlackConfig struct {
+	WebhookURL        string `yaml:"webhook_url"`
+	SigningSecret     string `yaml:"signing_secret"`
+	EscalationMention string `yaml:"escalation_mention"`
+	// CredentialsSecret supplies webhook_url/signing_secret keys
+	CredentialsSecret    *SecretRef   `yaml:"credentials_secret"`
+	CredentialsVaultPath string       `yaml:"credentials_vault_path"`
+	Proxy                *ProxyConfig `yaml:"proxy"`
+	TLS                  *TLSConfig   `yaml:"tls"`
+}
+
+// VaultConfig enables reading notifier credentials from HashiCorp Vault using
+// the Kubernetes auth method.
+type VaultConfig struct {
+	Address   string `yaml:"address"`
+	Role      string `yaml:"role"`
+	AuthPath  string `yaml:"auth_path"`
+	TokenPath string `yaml:"token_path"`
+	// Namespace is the Vault Enterprise namespace, if any
+	Namespace       string        `yaml:"namespace"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+// WebhookConfig posts alerts as versioned JSON (see package payload).
+type WebhookConfig struct {
+	Name    string            `yaml:"name"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	Proxy   *ProxyConfig      `yaml:"proxy"`
+	TLS     *TLSConfig        `yaml:"tls"`
+}
+
+// DaemonConfig applies when running with --daemon.
+type DaemonConfig struct {
+	Interval   time.Duration `yaml:"interval"`
+	ListenAddr string        `yaml:"listen_addr"`
+	// AuditInterval enables periodic best-practice reports; 0 disables them
+	AuditInterval time.Duration `yaml:"audit_interval"`
+	// PriorityInterval rechecks failing deployments, those whose "tier"
+	// annotation is in PriorityTiers, and those rolled out within
+	// RecentRolloutWindow between full scans; 0 disables rechecks
+	PriorityInterval    time.Duration `yaml:"priority_interval"`
+	PriorityTiers       []string      `yaml:"priority_tiers"`
+	RecentRolloutWindow time.Duration `yaml:"recent_rollout_window"`
+	// NamespaceOverrides scan some namespaces more or less often than Interval
+	NamespaceOverrides map[string]NamespaceOverride `yaml:"namespace_overrides"`
+	// Incremental skips healthy deployments whose Deployment and pods are
+	// unchanged since the last scan, checking everything every ResyncInterval
+	Incremental    bool          `yaml:"incremental"`
+	ResyncInterval time.Duration `yaml:"resync_interval"`
+	// ChannelCheckInterval tests the notification channels again this often
+	// after the startup test; 0 only tests them at startup
+	ChannelCheckInterval time.Duration `yaml:"channel_check_interval"`
+	// DrainTimeout bounds how long a SIGTERM waits for the scan in
+	// progress, API requests and notifications to finish before exiting
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+}
+
+type NamespaceOverride struct {
+	Interval time.Duration `yaml:"interval"`
+}
+
+// ShardingConfig splits namespaces between monitor replicas. Each replica
+// renews a Lease in LeaseNamespace; Identity defaults to the pod name.
+type ShardingConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	LeaseNamespace string `yaml:"lease_namespace"`
+	Group          string `yaml:"group"`
+	Identity       string `yaml:"identity"`
+	// LeaseDuration must comfortably exceed the daemon interval
+	LeaseDuration time.Duration `yaml:"lease_duration"`
+}
+
+// RunLockConfig keeps a one-shot run, e.g. from a CronJob, from starting
+// while the previous one is still going and double-sending alerts. The run
+// holds the Lease Name in LeaseNamespace, renewing it as it goes; a run that
+// finds it held exits, or with OnConflict RunLockWait waits up to WaitTimeout.
+// A crashed run's Lease expires after LeaseDuration.
+type RunLockConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	LeaseNamespace string        `yaml:"lease_namespace"`
+	Name           string        `yaml:"name"`
+	LeaseDuration  time.Duration `yaml:"lease_duration"`
+	OnConflict     string        `yaml:"on_conflict"`
+	WaitTimeout    time.Duration `yaml:"wait_timeout"`
+}
+
+// What a run does when another run holds the run lock.
+const (
+	RunLockExit = "exit"
+	RunLockWait = "wait"
+)
+
+type StateConfig struct {
+	// Path of the JSON state file; empty keeps state in memory only
+	Path           string        `yaml:"path"`
+	NotifyCooldown time.Duration `yaml:"notify_cooldown"`
+	// Retention keeps incidents of deleted workloads and expired silences
+	// around for reporting before they are pruned
+	Retention time.Duration `yaml:"retention"`
+	// HistoryRetention keeps resolved incidents for reports
+	HistoryRetention time.Duration `yaml:"history_retention"`
+	// CrashRetention keeps individual container crashes; it defaults to
+	// HistoryRetention
+	CrashRetention time.Duration `yaml:"crash_retention"`
+	// RollupRetention keeps daily per-deployment totals of the incidents
+	// and crashes pruned from history; negative prunes without rollups
+	RollupRetention time.Duration `yaml:"rollup_retention"`
+	// Compression of the state file, StateCompressionNone or
+	// StateCompressionGzip
+	Compression string `yaml:"compression"`
+}
+
+// State file compressions.
+const (
+	StateCompressionNone = "none"
+	StateCompressionGzip = "gzip"
+)
+
+// DependencyConfig declares dependencies between services in addition to
+// their depends_on annotations. Alerts for a service whose dependency is also
+// failing name the dependency as the likely cause.
+type DependencyConfig struct {
+	// Graph maps "namespace/name" to the services it depends on
+	Graph map[string][]string `yaml:"graph"`
+	// SuppressDownstream skips alerts for services whose dependency is
+	// failing, leaving the dependency's owners to alert
+	SuppressDownstream bool `yaml:"suppress_downstream"`
+}
+
+// CircuitBreakerConfig trips when Threshold alerts go out within Window, a
+// sign of a monitor bug or a cluster meltdown. Per-owner alerts are then held
+// back and Email gets one summary, until the breaker is reset through the API
+// or Window has passed. A zero Threshold disables it.
+type CircuitBreakerConfig struct {
+	Threshold int           `yaml:"threshold"`
+	Window    time.Duration `yaml:"window"`
+	// Email defaults to infra_email
+	Email string `yaml:"email"`
+}
+
+// ClusterIncidentConfig collapses widespread failures into one alert to
+// infra_email instead of one alert per owner.
+type ClusterIncidentConfig struct {
+	// FailurePercent of monitored services failing in one scan is a
+	// cluster-level incident; 0 disables the check
+	FailurePercent float64 `yaml:"failure_percent"`
+	// MinServices scanned before FailurePercent applies
+	MinServices int `yaml:"min_services"`
+	// NamespaceMinServices is the smallest namespace whose services all
+	// failing is treated as one incident; 0 disables the check
+	NamespaceMinServices int `yaml:"namespace_min_services"`
+}
+
+// TopologyConfig correlates failing pods by node, node group and zone. When
+// most failures of a scan share one, alerts say so and go to infra_email.
+type TopologyConfig struct {
+	Disabled bool `yaml:"disabled"`
+	// NodeGroupLabels are the node labels naming node groups, tried in
+	// order; empty uses the EKS, GKE, AKS and Karpenter labels
+	NodeGroupLabels []string `yaml:"node_group_labels"`
+	// MinPods failing pods, from two or more deployments, must share the
+	// location, and make up at least Percent of the scan's failing pods
+	MinPods int     `yaml:"min_pods"`
+	Percent float64 `yaml:"percent"`
+}
+
+// ScanConfig controls how results flow through a scan. With Stream set,
+// deployments are checked and notified in batches of about BatchSize as the
+// scan finds them, rather than after it finishes, so large clusters aren't
+// held in memory at once. Batches end at namespace boundaries, so hotspots
+// and cluster-wide incidents are only detected within a batch.
+//
+// Deadline bounds how long a scan checks deployments. A scan that runs out of
+// time handles what it has checked, reports the namespaces it skipped, and
+// leaves their state for the next scan; 0 means no deadline.
+//
+// ReplicaSets and Pods opt in to monitoring ReplicaSets and pods that no
+// controller manages, given owner annotations, until they are migrated to
+// Deployments.
+type ScanConfig struct {
+	Stream      bool          `yaml:"stream"`
+	BatchSize   int           `yaml:"batch_size"`
+	Deadline    time.Duration `yaml:"deadline"`
+	ReplicaSets bool          `yaml:"replica_sets"`
+	Pods        bool          `yaml:"pods"`
+}
+
+// DrillConfig is where the drill command sends its synthetic alert.
+type DrillConfig struct {
+	Recipient    string `yaml:"recipient"`
+	SlackChannel string `yaml:"slack_channel"`
+}
+
+// ReportConfig shapes the failure history in the infra report.
+type ReportConfig struct {
+	// Window is how far back the top offenders and heatmap look
+	Window       time.Duration `yaml:"window"`
+	TopOffenders int           `yaml:"top_offenders"`
+	// IntermittentWindow is how far back audit reports look for crashes
+	// that recovered between scans; match it to daemon.audit_interval
+	IntermittentWindow time.Duration `yaml:"intermittent_window"`
+}
+
+// EmailReplyConfig enables the inbound email webhooks used for "ACK" replies
+// and bounces. Only a deployment's owner and DL addresses may acknowledge by
+// reply, plus senders in AllowedDomains, e.g. for members of a DL.
+type EmailReplyConfig struct {
+	WebhookToken   string   `yaml:"webhook_token"`
+	AllowedDomains []string `yaml:"allowed_domains"`
+}
+
+// BackstageConfig enables ownership lookups for deployments annotated with
+// backstage.io/component.
+type BackstageConfig struct {
+	BaseURL                string `yaml:"base_url"`
+	Token                  string `yaml:"token"`
+	SlackChannelAnnotation string `yaml:"slack_channel_annotation"`
+}
+
+// ImageAdvisoryConfig enables image advisories in audit reports for failing
+// workloads. BaseURL serves GET /images?ref=<image> with the image's push
+// time and scan findings, e.g. from ECR; images older than MaxAge are
+// reported as stale. Advisories are off when BaseURL is empty.
+type ImageAdvisoryConfig struct {
+	BaseURL string        `yaml:"base_url"`
+	Token   string        `yaml:"token"`
+	MaxAge  time.Duration `yaml:"max_age"`
+}
+
+// ProbationConfig puts workloads created less than MaxAge ago on probation
+// until they have been stable for StableFor: their alerts go only to the
+// owner, at warning severity. Probation is off when MaxAge is 0.
+type ProbationConfig struct {
+	MaxAge    time.Duration `yaml:"max_age"`
+	StableFor time.Duration `yaml:"stable_for"`
+}
+
+// IngestConfig enables POST /api/v1/ingest in daemon mode, where other
+// systems (Alertmanager, CI, synthetic checkers) report failing workloads. A
+// report keeps a workload failing until it is resolved or TTL passes without
+// it being repeated, so TTL should exceed Alertmanager's repeat_interval.
+type IngestConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"`
+}
+
+// AlertmanagerConfig syncs silences with Alertmanager in daemon mode: each
+// scan, silences created here are mirrored to Alertmanager, and Alertmanager
+// silences matching exactly a namespace and deployment label are mirrored
+// here, as are early removals both ways. Sync is off when URL is empty.
+type AlertmanagerConfig struct {
+	URL   string `yaml:"url"`
+	Token string `yaml:"token"`
+}
+
+// OwnershipConfig enables periodic emails asking each owner to confirm the
+// deployments attributed to them, or hand them over, through a link to a page
+// under PublicURL signed with Secret. Deployments not confirmed by their
+// current owner within StaleAfter are flagged and the owner DL is copied.
+// Reviews are off when Interval is 0.
+type OwnershipConfig struct {
+	Interval   time.Duration `yaml:"interval"`
+	StaleAfter time.Duration `yaml:"stale_after"`
+	PublicURL  string        `yaml:"public_url"`
+	Secret     string        `yaml:"secret"`
+}
+
+// OnboardingConfig emails the platform team a report on each namespace
+// created while the daemon runs, once it is Delay old so its workloads are
+// deployed: the workloads, which annotations they set and the annotations
+// that would route their alerts. Reports go to Email, or infra_email if it
+// is empty.
+type OnboardingConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Email   string        `yaml:"email"`
+	Delay   time.Duration `yaml:"delay"`
+}
+
+// CMDBConfig enables ServiceNow lookups for deployments annotated with cmdb_ci.
+// FreezeQuery is an encoded query against FreezeTable; "{ci}" is replaced with
+// the CI sys_id. Freeze detection is off when it is empty.
+type CMDBConfig struct {
+	BaseURL     string `yaml:"base_url"`
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+	FreezeTable string `yaml:"freeze_table"`
+	FreezeQuery string `yaml:"freeze_query"`
+}
+
+// APIAuthConfig protects the REST API and dashboard with bearer tokens and/or
+// OIDC sign-in. Each token may only see and act on its namespaces ("*" for
+// all, which is also needed for cluster-wide endpoints such as usage). With
+// neither configured both are open, as are chat commands; otherwise chat
+// users only reach the namespaces ChatUsers grants them.
+type APIAuthConfig struct {
+	Tokens    []APIToken `yaml:"tokens"`
+	OIDC      OIDCConfig `yaml:"oidc"`
+	ChatUsers []ChatUser `yaml:"chat_users"`
+}
+
+// ChatUser grants a Slack or Teams user, by the name the chat platform
+// reports (Slack user_name, Teams display name), access to Namespaces
+// through chat commands.
+type ChatUser struct {
+	User       string   `yaml:"user"`
+	Namespaces []string `yaml:"namespaces"`
+	ReadOnly   bool     `yaml:"read_only"`
+}
+
+// OIDCConfig signs dashboard users in with an OpenID Connect provider. Groups
+// from GroupsClaim in the ID token map to namespaces; users in no mapped
+// group are refused. An empty Issuer disables it.
+type OIDCConfig struct {
+	Issuer       string `yaml:"issuer"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	// RedirectURL is this service's /auth/callback as the browser sees it
+	RedirectURL string   `yaml:"redirect_url"`
+	Scopes      []string `yaml:"scopes"`
+	GroupsClaim string   `yaml:"groups_claim"`
+	// SessionSecret signs session cookies
+	SessionSecret string        `yaml:"session_secret"`
+	SessionTTL    time.Duration `yaml:"session_ttl"`
+	Groups        []OIDCGroup   `yaml:"groups"`
+}
+
+// OIDCGroup grants members of Group access to Namespaces.
+type OIDCGroup struct {
+	Group      string   `yaml:"group"`
+	Namespaces []string `yaml:"namespaces"`
+	ReadOnly   bool     `yaml:"read_only"`
+}
+
+type APIToken struct {
+	Name       string   `yaml:"name"`
+	Token      string   `yaml:"token"`
+	Namespaces []string `yaml:"namespaces"`
+	ReadOnly   bool     `yaml:"read_only"`
+}
+
+// ArchiveConfig uploads each alert's full pod logs, events and rendered email
+// to an S3-compatible bucket and links them from notifications. GCS works with
+// endpoint https://storage.googleapis.com and HMAC keys. Credentials fall back
+// to the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+// environment variables. An empty Bucket disables archiving.
+type ArchiveConfig struct {
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	// Retention is how long archives are kept before being deleted
+	Retention time.Duration `yaml:"retention"`
+	// LinkExpiry is how long links in notifications stay valid, at most 7 days
+	LinkExpiry time.Duration `yaml:"link_expiry"`
+}
+
+type PDBConfig struct {
+	// BlockedThreshold is how long a PDB may block evictions before it is
+	// highlighted in the infra report
+	BlockedThreshold time.Duration `yaml:"blocked_threshold"`
+}
+
+// StructuredLogsConfig overrides the JSON keys used to extract fields from
+// structured container logs. Empty lists keep the built-in defaults.
+type StructuredLogsConfig struct {
+	Disabled      bool     `yaml:"disabled"`
+	TimeFields    []string `yaml:"time_fields"`
+	LevelFields   []string `yaml:"level_fields"`
+	MessageFields []string `yaml:"message_fields"`
+}
+
+// LinkTemplate is a named URL with {placeholder} variables.
+type LinkTemplate struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// RemediationPagesConfig adds a "What to do next" link to alerts, pointing at
+// the wiki page for the failure's remediation category (crashes, resources,
+// images, configuration, scheduling, probes, platform, scaling or external).
+// URL is the page template, with {category} and {classification} as well as
+// the log link placeholders; Pages replaces it for single categories and
+// Categories moves classifications to another category. An empty URL only
+// links the categories in Pages.
+type RemediationPagesConfig struct {
+	URL        string            `yaml:"url"`
+	Pages      map[string]string `yaml:"pages"`
+	Categories map[string]string `yaml:"categories"`
+}
+
+// LogLinksConfig builds links to full logs in Loki, Kibana, etc. Templates can
+// use {namespace}, {deployment}, {pod}, {container}, {from}/{to} (epoch ms)
+// and {from_iso}/{to_iso}.
+type LogLinksConfig struct {
+	TimeWindow time.Duration  `yaml:"time_window"`
+	Links      []LinkTemplate `yaml:"links"`
+}
+
+// ChangeLinksConfig renders a "what changed" section from the annotations CI
+// sets on deployments. Templates can use {commit}, {version}, {revision} and
+// {pipeline} as well as the log link placeholders; a link is left out when a
+// placeholder it uses has no value.
+type ChangeLinksConfig struct {
+	// CommitAnnotations and PipelineAnnotations are tried in order
+	CommitAnnotations   []string       `yaml:"commit_annotations"`
+	PipelineAnnotations []string       `yaml:"pipeline_annotations"`
+	Links               []LinkTemplate `yaml:"links"`
 }
 
+// Load reads the config file with the profile named in $K8S_HEALTH_PROFILE,
+// if any.
 func Load(configPath string) (*Config, error) {
+	return LoadProfile(configPath, os.Getenv(ProfileEnv))
+}
+
+// LoadProfile reads the config file with the named profile applied over it;
+// an empty profile uses the file without its profiles section.
+func LoadProfile(configPath, profile string) (*Config, error) {
 	data, err := ioutil.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
+	return Parse(data, profile)
+}
+
+// Parse parses a config document with the named profile applied, and fills
+// in defaults.
+func Parse(data []byte, profile string) (*Config, error) {
+	data, err := applyProfile(data, profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply config profile: %w", err)
+	}
 
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	if cfg.Warnings, err = decode(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
+	cfg.Profile = profile
 
 	// Set defaults
+	switch cfg.SMTPConfig.Auth {
+	case "":
+		cfg.SMTPConfig.Auth = SMTPAuthPlain
+	case SMTPAuthPlain, SMTPAuthNone:
+	default:
+		return nil, fmt.Errorf("smtp.auth must be %s or %s", SMTPAuthPlain, SMTPAuthNone)
+	}
+	cfg.SMTPConfig.NoAuth = cfg.SMTPConfig.Auth == SMTPAuthNone
+	switch cfg.Metadata.Source {
+	case "":
+		cfg.Metadata.Source = MetadataAnnotations
+	case MetadataAnnotations, MetadataLabels, MetadataBoth:
+	default:
+		return nil, fmt.Errorf("metadata.source must be %s, %s or %s", MetadataAnnotations, MetadataLabels, MetadataBoth)
+	}
+	switch cfg.Metadata.Prefer {
+	case "":
+		cfg.Metadata.Prefer = MetadataAnnotations
+	case MetadataAnnotations, MetadataLabels:
+	default:
+		return nil, fmt.Errorf("metadata.prefer must be %s or %s", MetadataAnnotations, MetadataLabels)
+	}
+	if cfg.Metadata.Labels == nil {
+		cfg.Metadata.Labels = make(map[string]string)
+	}
+	for _, key := range []string{"service_owner", "owner_dl", "team", "tier"} {
+		if cfg.Metadata.Labels[key] == "" {
+			cfg.Metadata.Labels[key] = key
+		}
+	}
 	if cfg.LogTailLines == 0 {
 		cfg.LogTailLines = 50
 	}
+	if cfg.LogLimitBytes == 0 {
+		cfg.LogLimitBytes = 64 * 1024
+	}
+	if cfg.ClusterName == "" {
+		cfg.ClusterName = "EKS Production"
+	}
+	if cfg.DefaultLocale == "" {
+		cfg.DefaultLocale = "en"
+	}
+	if cfg.Branding.ProductName == "" {
+		cfg.Branding.ProductName = "Kubernetes Health Monitor"
+	}
+	if cfg.Branding.SupportEmail == "" {
+		cfg.Branding.SupportEmail = cfg.InfraEmail
+	}
+	if cfg.Branding.PrimaryColor == "" {
+		cfg.Branding.PrimaryColor = "#1565c0"
+	}
+	if cfg.Branding.AlertColor == "" {
+		cfg.Branding.AlertColor = "#c62828"
+	}
+	if cfg.Branding.SecondaryColor == "" {
+		cfg.Branding.SecondaryColor = "#37474f"
+	}
+	if cfg.Branding.BackgroundColor == "" {
+		cfg.Branding.BackgroundColor = "#f4f4f4"
+	}
+	if cfg.LogLinks.TimeWindow == 0 {
+		cfg.LogLinks.TimeWindow = time.Hour
+	}
+	if len(cfg.ChangeLinks.CommitAnnotations) == 0 {
+		cfg.ChangeLinks.CommitAnnotations = []string{"commit_sha", "git_commit"}
+	}
+	if len(cfg.ChangeLinks.PipelineAnnotations) == 0 {
+		cfg.ChangeLinks.PipelineAnnotations = []string{"pipeline_id"}
+	}
+	if cfg.LogFetchWorkers == 0 {
+		cfg.LogFetchWorkers = 5
+	}
+	if cfg.LogBufferBytes == 0 {
+		cfg.LogBufferBytes = cfg.LogLimitBytes
+	}
+	if cfg.LogRunBudgetBytes == 0 {
+		cfg.LogRunBudgetBytes = 32 * 1024 * 1024
+	}
+	if cfg.LogSpillLimitBytes == 0 {
+		cfg.LogSpillLimitBytes = 10 * 1024 * 1024
+	}
+	if cfg.NotReady.Grace == 0 {
+		cfg.NotReady.Grace = 2 * time.Minute
+	}
+	if cfg.NotReady.CriticalAfter == 0 {
+		cfg.NotReady.CriticalAfter = 15 * time.Minute
+	}
+	if cfg.Remediation.NotReadyThreshold == 0 {
+		cfg.Remediation.NotReadyThreshold = 15 * time.Minute
+	}
+	if cfg.Remediation.ProbeRestartThreshold == 0 {
+		cfg.Remediation.ProbeRestartThreshold = 5
+	}
+	if cfg.Remediation.MaxActionsPerRun == 0 {
+		cfg.Remediation.MaxActionsPerRun = 5
+	}
+	if cfg.Remediation.Cooldown == 0 {
+		cfg.Remediation.Cooldown = time.Hour
+	}
+	if cfg.Debug.Image != "" && len(cfg.Debug.Classifications) == 0 {
+		cfg.Debug.Classifications = []string{"crash_loop", "init_crash_loop", "oom_killed", "not_ready", "frequent_restarts", "liveness_probe"}
+	}
+	if cfg.StuckNamespaceThreshold == 0 {
+		cfg.StuckNamespaceThreshold = 30 * time.Minute
+	}
+	if cfg.StuckPodThreshold == 0 {
+		cfg.StuckPodThreshold = 10 * time.Minute
+	}
+	if cfg.PDB.BlockedThreshold == 0 {
+		cfg.PDB.BlockedThreshold = time.Hour
+	}
+	if cfg.Daemon.Interval == 0 {
+		cfg.Daemon.Interval = 5 * time.Minute
+	}
+	if cfg.Daemon.DrainTimeout == 0 {
+		cfg.Daemon.DrainTimeout = 25 * time.Second
+	}
+	for ns, override := range cfg.Daemon.NamespaceOverrides {
+		if override.Interval <= 0 {
+			return nil, fmt.Errorf("daemon.namespace_overrides.%s.interval must be positive", ns)
+		}
+	}
+	if len(cfg.Daemon.PriorityTiers) == 0 {
+		cfg.Daemon.PriorityTiers = []string{"p1"}
+	}
+	if cfg.Daemon.RecentRolloutWindow == 0 {
+		cfg.Daemon.RecentRolloutWindow = 30 * time.Minute
+	}
+	if cfg.Daemon.ResyncInterval == 0 {
+		cfg.Daemon.ResyncInterval = time.Hour
+	}
+	if cfg.Daemon.ListenAddr == "" {
+		cfg.Daemon.ListenAddr = ":8080"
+	}
+	if cfg.TLSPolicy.MinVersion == "" {
+		cfg.TLSPolicy.MinVersion = "1.2"
+	}
+	if cfg.Vault.AuthPath == "" {
+		cfg.Vault.AuthPath = "kubernetes"
+	}
+	if cfg.Vault.TokenPath == "" {
+		cfg.Vault.TokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	}
+	if cfg.Vault.RefreshInterval == 0 {
+		cfg.Vault.RefreshInterval = 5 * time.Minute
+	}
+	if cfg.Sharding.LeaseNamespace == "" {
+		cfg.Sharding.LeaseNamespace = "default"
+	}
+	if cfg.Sharding.Group == "" {
+		cfg.Sharding.Group = "k8s-health-monitor"
+	}
+	if cfg.Sharding.LeaseDuration == 0 {
+		cfg.Sharding.LeaseDuration = 3 * cfg.Daemon.Interval
+	}
+	if cfg.RunLock.LeaseNamespace == "" {
+		cfg.RunLock.LeaseNamespace = "default"
+	}
+	if cfg.RunLock.Name == "" {
+		cfg.RunLock.Name = "k8s-health-monitor-run"
+	}
+	if cfg.RunLock.LeaseDuration == 0 {
+		cfg.RunLock.LeaseDuration = 2 * time.Minute
+	}
+	switch cfg.RunLock.OnConflict {
+	case "":
+		cfg.RunLock.OnConflict = RunLockExit
+	case RunLockExit, RunLockWait:
+	default:
+		return nil, fmt.Errorf("run_lock.on_conflict must be %s or %s", RunLockExit, RunLockWait)
+	}
+	if cfg.RunLock.WaitTimeout == 0 {
+		cfg.RunLock.WaitTimeout = 10 * time.Minute
+	}
+	if cfg.CMDB.FreezeTable == "" {
+		cfg.CMDB.FreezeTable = "change_request"
+	}
+	if cfg.State.NotifyCooldown == 0 {
+		cfg.State.NotifyCooldown = time.Hour
+	}
+	if cfg.State.Retention == 0 {
+		cfg.State.Retention = 7 * 24 * time.Hour
+	}
+	if cfg.State.HistoryRetention == 0 {
+		cfg.State.HistoryRetention = 30 * 24 * time.Hour
+	}
+	if cfg.State.CrashRetention == 0 {
+		cfg.State.CrashRetention = cfg.State.HistoryRetention
+	}
+	if cfg.State.RollupRetention == 0 {
+		cfg.State.RollupRetention = 365 * 24 * time.Hour
+	}
+	switch cfg.State.Compression {
+	case "":
+		cfg.State.Compression = StateCompressionNone
+	case StateCompressionNone, StateCompressionGzip:
+	default:
+		return nil, fmt.Errorf("state.compression must be %s or %s", StateCompressionNone, StateCompressionGzip)
+	}
+	if cfg.CircuitBreaker.Window == 0 {
+		cfg.CircuitBreaker.Window = 15 * time.Minute
+	}
+	if cfg.CircuitBreaker.Email == "" {
+		cfg.CircuitBreaker.Email = cfg.InfraEmail
+	}
+	if cfg.ClusterIncident.MinServices == 0 {
+		cfg.ClusterIncident.MinServices = 10
+	}
+	if cfg.ClusterIncident.FailurePercent < 0 || cfg.ClusterIncident.FailurePercent > 100 {
+		return nil, fmt.Errorf("cluster_incident.failure_percent must be between 0 and 100")
+	}
+	if cfg.Topology.MinPods == 0 {
+		cfg.Topology.MinPods = 3
+	}
+	if cfg.Topology.Percent == 0 {
+		cfg.Topology.Percent = 80
+	}
+	if cfg.Scan.BatchSize == 0 {
+		cfg.Scan.BatchSize = 500
+	}
+	if cfg.Report.Window == 0 {
+		cfg.Report.Window = 14 * 24 * time.Hour
+	}
+	if cfg.Report.TopOffenders == 0 {
+		cfg.Report.TopOffenders = 10
+	}
+	if cfg.Report.IntermittentWindow == 0 {
+		cfg.Report.IntermittentWindow = 24 * time.Hour
+	}
+	if cfg.Probation.StableFor == 0 {
+		cfg.Probation.StableFor = 7 * 24 * time.Hour
+	}
+	if cfg.SMTPConfig.RetryAfter == 0 {
+		cfg.SMTPConfig.RetryAfter = 5 * time.Minute
+	}
+	if cfg.Ingest.TTL == 0 {
+		cfg.Ingest.TTL = 4 * time.Hour
+	}
+	if cfg.Ownership.StaleAfter == 0 {
+		cfg.Ownership.StaleAfter = 90 * 24 * time.Hour
+	}
+	if own := cfg.Ownership; own.Interval > 0 && (own.PublicURL == "" || len(own.Secret) < 32) {
+		return nil, fmt.Errorf("ownership needs a public_url and a secret of at least 32 characters")
+	}
+	if cfg.Onboarding.Delay == 0 {
+		cfg.Onboarding.Delay = time.Hour
+	}
+	if cfg.Onboarding.Email == "" {
+		cfg.Onboarding.Email = cfg.InfraEmail
+	}
+	if cfg.ImageAdvisory.MaxAge == 0 {
+		cfg.ImageAdvisory.MaxAge = 180 * 24 * time.Hour
+	}
+	for i, token := range cfg.APIAuth.Tokens {
+		if token.Name == "" || token.Token == "" || len(token.Namespaces) == 0 {
+			return nil, fmt.Errorf("api_auth.tokens[%d] needs a name, token and namespaces", i)
+		}
+	}
+	for i, user := range cfg.APIAuth.ChatUsers {
+		if user.User == "" || len(user.Namespaces) == 0 {
+			return nil, fmt.Errorf("api_auth.chat_users[%d] needs a user and namespaces", i)
+		}
+	}
+	if oidc := &cfg.APIAuth.OIDC; oidc.Issuer != "" {
+		if oidc.ClientID == "" || oidc.RedirectURL == "" || len(oidc.SessionSecret) < 32 {
+			return nil, fmt.Errorf("api_auth.oidc needs client_id, redirect_url and a session_secret of at least 32 characters")
+		}
+		if len(oidc.Scopes) == 0 {
+			oidc.Scopes = []string{"openid", "email", "profile"}
+		}
+		if oidc.GroupsClaim == "" {
+			oidc.GroupsClaim = "groups"
+		}
+		if oidc.SessionTTL == 0 {
+			oidc.SessionTTL = 8 * time.Hour
+		}
+	}
+	if cfg.Archive.Region == "" {
+		cfg.Archive.Region = "us-east-1"
+	}
+	if cfg.Archive.Endpoint == "" {
+		cfg.Archive.Endpoint = "https://s3." + cfg.Archive.Region + ".amazonaws.com"
+	}
+	if cfg.Archive.Retention == 0 {
+		cfg.Archive.Retention = 30 * 24 * time.Hour
+	}
+	if cfg.Archive.LinkExpiry == 0 {
+		cfg.Archive.LinkExpiry = 7 * 24 * time.Hour
+	}
+	if cfg.Archive.LinkExpiry > 7*24*time.Hour {
+		return nil, fmt.Errorf("archive.link_expiry must be at most 168h")
+	}
 
 	return &cfg, nil
 }
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ProfileEnv selects a profile when none is passed to LoadProfile's callers,
+// e.g. the --profile flag.
+const ProfileEnv = "K8S_HEALTH_PROFILE"
+
+// applyProfile overlays the named profile from the document's profiles
+// section onto the rest of the document. Nested sections are merged key by
+// key; lists and scalars in the profile replace the base value. A profile
+// can name another profile in "inherits" to start from it instead of the
+// base. With an empty name the profiles section is dropped.
+func applyProfile(data []byte, name string) ([]byte, error) {
+	var doc map[interface{}]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	profiles, _ := doc["profiles"].(map[interface{}]interface{})
+	delete(doc, "profiles")
+	if name == "" {
+		return yaml.Marshal(doc)
+	}
+
+	// Walk up the inheritance chain, then apply the profiles root first
+	var chain []map[interface{}]interface{}
+	seen := make(map[string]bool)
+	for current := name; current != ""; {
+		if seen[current] {
+			return nil, fmt.Errorf("profile %q inherits from itself", current)
+		}
+		seen[current] = true
+
+		profile, ok := profiles[current].(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q", current)
+		}
+		chain = append(chain, profile)
+		current, _ = profile["inherits"].(string)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		delete(chain[i], "inherits")
+		merge(doc, chain[i])
+	}
+	return yaml.Marshal(doc)
+}
+
+// merge copies overlay into base, recursing into sections both define.
+func merge(base, overlay map[interface{}]interface{}) {
+	for key, value := range overlay {
+		if overlaySection, ok := value.(map[interface{}]interface{}); ok {
+			if baseSection, ok := base[key].(map[interface{}]interface{}); ok {
+				merge(baseSection, overlaySection)
+				continue
+			}
+		}
+		base[key] = value
+	}
+}
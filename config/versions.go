@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// APIVersion is the current config schema. Configs without an apiVersion are
+// legacy: their old keys are migrated with a warning and unknown keys are
+// ignored. Versioned configs are parsed strictly, so typos and removed keys
+// are errors rather than silently ignored.
+const APIVersion = "k8s-health-monitor/v1"
+
+// migration replaces a legacy key, given as a dotted path.
+type migration struct {
+	from, to string
+	// convert maps the legacy value to the new key's
+	convert func(interface{}) (interface{}, error)
+}
+
+var migrations = []migration{
+	{from: "smtp.no_auth", to: "smtp.auth", convert: func(v interface{}) (interface{}, error) {
+		noAuth, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		if noAuth {
+			return SMTPAuthNone, nil
+		}
+		return SMTPAuthPlain, nil
+	}},
+}
+
+// decode parses a config document per its apiVersion, migrating legacy keys.
+// It returns a warning for each key migrated.
+func decode(data []byte, cfg *Config) ([]string, error) {
+	var doc map[interface{}]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	switch version, _ := doc["apiVersion"].(string); version {
+	case APIVersion:
+		for _, m := range migrations {
+			if _, ok := lookup(doc, m.from); ok {
+				return nil, fmt.Errorf("%s was replaced by %s in %s", m.from, m.to, APIVersion)
+			}
+		}
+		return nil, yaml.UnmarshalStrict(data, cfg)
+
+	case "":
+		warnings := []string{fmt.Sprintf("config has no apiVersion; set apiVersion: %s to have it validated", APIVersion)}
+		for _, m := range migrations {
+			value, ok := lookup(doc, m.from)
+			if !ok {
+				continue
+			}
+			converted, err := m.convert(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", m.from, err)
+			}
+			if _, exists := lookup(doc, m.to); !exists {
+				set(doc, m.to, converted)
+			}
+			remove(doc, m.from)
+			warnings = append(warnings, fmt.Sprintf("%s is deprecated; use %s: %v", m.from, m.to, converted))
+		}
+		migrated, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		return warnings, yaml.Unmarshal(migrated, cfg)
+
+	default:
+		return nil, fmt.Errorf("unsupported apiVersion %q (expected %s)", version, APIVersion)
+	}
+}
+
+func lookup(doc map[interface{}]interface{}, path string) (interface{}, bool) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		section, ok := doc[key].(map[interface{}]interface{})
+		if !ok {
+			return nil, false
+		}
+		doc = section
+	}
+	value, ok := doc[keys[len(keys)-1]]
+	return value, ok
+}
+
+func set(doc map[interface{}]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		section, ok := doc[key].(map[interface{}]interface{})
+		if !ok {
+			section = make(map[interface{}]interface{})
+			doc[key] = section
+		}
+		doc = section
+	}
+	doc[keys[len(keys)-1]] = value
+}
+
+func remove(doc map[interface{}]interface{}, path string) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		section, ok := doc[key].(map[interface{}]interface{})
+		if !ok {
+			return
+		}
+		doc = section
+	}
+	delete(doc, keys[len(keys)-1])
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/kubernetes"
+)
+
+// configMapScheme prefixes ConfigMap config locations:
+// configmap://namespace/name[/key], the key defaulting to config.yaml.
+const configMapScheme = "configmap://"
+
+// configSource reads the config document from a file, a ConfigMap or an
+// HTTP(S) URL. Daemons poll remote sources for changes so a cluster's config
+// can be updated without redeploying; URLs are requested with If-None-Match
+// so unchanged configs cost a 304.
+type configSource struct {
+	location   string
+	httpClient *http.Client
+	client     k8s.Interface
+	// etag and sum describe the last document read
+	etag string
+	sum  [sha256.Size]byte
+}
+
+func newConfigSource(location string) *configSource {
+	return &configSource{location: location, httpClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// remote reports whether the config comes from a ConfigMap or URL.
+func (s *configSource) remote() bool {
+	return strings.HasPrefix(s.location, configMapScheme) ||
+		strings.HasPrefix(s.location, "https://") || strings.HasPrefix(s.location, "http://")
+}
+
+// read returns the current config document.
+func (s *configSource) read(ctx context.Context) ([]byte, error) {
+	data, _, err := s.fetch(ctx)
+	return data, err
+}
+
+// changed re-reads the config and returns the new document if it differs
+// from the last one read.
+func (s *configSource) changed(ctx context.Context) ([]byte, bool, error) {
+	before := s.sum
+	data, modified, err := s.fetch(ctx)
+	if err != nil || !modified {
+		return nil, false, err
+	}
+	return data, s.sum != before, nil
+}
+
+// fetch reads the document, reporting false if a URL answered 304.
+func (s *configSource) fetch(ctx context.Context) ([]byte, bool, error) {
+	var data []byte
+	var err error
+	switch {
+	case strings.HasPrefix(s.location, configMapScheme):
+		data, err = s.fetchConfigMap(ctx)
+	case s.remote():
+		data, err = s.fetchURL(ctx)
+		if err == nil && data == nil {
+			return nil, false, nil
+		}
+	default:
+		data, err = os.ReadFile(s.location)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read config from %s: %w", s.location, err)
+	}
+	s.sum = sha256.Sum256(data)
+	return data, true, nil
+}
+
+func (s *configSource) fetchConfigMap(ctx context.Context) ([]byte, error) {
+	parts := strings.SplitN(strings.TrimPrefix(s.location, configMapScheme), "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("expected %snamespace/name[/key]", configMapScheme)
+	}
+	key := "config.yaml"
+	if len(parts) == 3 && parts[2] != "" {
+		key = parts[2]
+	}
+
+	if s.client == nil {
+		client, err := kubernetes.NewClient(nil)
+		if err != nil {
+			return nil, err
+		}
+		s.client = client
+	}
+	cm, err := s.client.CoreV1().ConfigMaps(parts[0]).Get(ctx, parts[1], metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data, ok := cm.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("ConfigMap has no key %q", key)
+	}
+	return []byte(data), nil
+}
+
+// fetchURL returns nil data if the server says the document is unchanged.
+func (s *configSource) fetchURL(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.location, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, nil
+	case http.StatusOK:
+	default:
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	s.etag = resp.Header.Get("ETag")
+	return data, nil
+}
+
+// checkConfig reports whether a remote config changed to a valid new
+// document, which the daemon applies by restarting. Invalid changes are
+// logged and ignored.
+func (m *monitor) checkConfig(ctx context.Context) bool {
+	if m.configSource == nil || !m.configSource.remote() {
+		return false
+	}
+	data, changed, err := m.configSource.changed(ctx)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return false
+	}
+	if !changed {
+		return false
+	}
+	if _, err := config.Parse(data, m.cfg.Profile); err != nil {
+		log.Printf("Warning: ignoring changed config from %s: %v", m.configSource.location, err)
+		return false
+	}
+	log.Printf("Config at %s changed; restarting to apply it", m.configSource.location)
+	return true
+}
+
+// restart replaces the process with a fresh copy of itself, e.g. to apply a
+// changed config.
+func restart() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(exe, os.Args, os.Environ())
+}
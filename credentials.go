@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	k8s "k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/secrets"
+)
+
+// Keys read from credential Secrets.
+const (
+	secretKeyUsername      = "username"
+	secretKeyPassword      = "password"
+	secretKeyWebhookURL    = "webhook_url"
+	secretKeySigningSecret = "signing_secret"
+)
+
+// credentialSources are the external stores notifier credentials come from;
+// nil means the config file values are used as is.
+type credentialSources struct {
+	smtp  secrets.Source
+	slack secrets.Source
+}
+
+func newCredentialSources(client k8s.Interface, cfg *config.Config) (credentialSources, error) {
+	var vault *secrets.Vault
+	if cfg.Vault.Address != "" {
+		vault = secrets.NewVault(cfg.Vault)
+	}
+
+	source := func(name string, ref *config.SecretRef, vaultPath string) (secrets.Source, error) {
+		switch {
+		case ref != nil && vaultPath != "":
+			return nil, fmt.Errorf("%s: credentials_secret and credentials_vault_path are mutually exclusive", name)
+		case ref != nil:
+			return secrets.NewKubernetesSource(client, *ref), nil
+		case vaultPath != "" && vault == nil:
+			return nil, fmt.Errorf("%s: credentials_vault_path requires vault.address", name)
+		case vaultPath != "":
+			return vault.Source(vaultPath), nil
+		}
+		return nil, nil
+	}
+
+	var sources credentialSources
+	var err error
+	if sources.smtp, err = source("smtp", cfg.SMTPConfig.CredentialsSecret, cfg.SMTPConfig.CredentialsVaultPath); err != nil {
+		return sources, err
+	}
+	if sources.slack, err = source("slack", cfg.Slack.CredentialsSecret, cfg.Slack.CredentialsVaultPath); err != nil {
+		return sources, err
+	}
+	return sources, nil
+}
+
+// load fills notifier credentials before the notifiers are created. Values
+// from the store win over the config file.
+func (s credentialSources) load(ctx context.Context, cfg *config.Config) error {
+	if s.smtp != nil {
+		data, err := s.smtp.Get(ctx)
+		if err != nil {
+			return fmt.Errorf("smtp credentials: %w", err)
+		}
+		applySMTPCredentials(&cfg.SMTPConfig, data)
+	}
+
+	if s.slack != nil {
+		data, err := s.slack.Get(ctx)
+		if err != nil {
+			return fmt.Errorf("slack credentials: %w", err)
+		}
+		applySlackCredentials(&cfg.Slack, data)
+	}
+	return nil
+}
+
+// watchCredentials keeps the notifiers' credentials in sync with their
+// stores so rotation doesn't need a restart.
+func (m *monitor) watchCredentials(ctx context.Context, sources credentialSources) {
+	if sources.smtp != nil {
+		go sources.smtp.Watch(ctx, func(data map[string][]byte) {
+			smtp := m.cfg.SMTPConfig
+			applySMTPCredentials(&smtp, data)
+			m.emailSender.SetCredentials(smtp.Username, smtp.Password)
+		})
+	}
+
+	if sources.slack != nil && m.slack != nil {
+		go sources.slack.Watch(ctx, func(data map[string][]byte) {
+			slack := m.cfg.Slack
+			applySlackCredentials(&slack, data)
+			m.slack.SetCredentials(slack.WebhookURL, slack.SigningSecret)
+		})
+	}
+}
+
+func applySMTPCredentials(cfg *config.SMTPConfig, data map[string][]byte) {
+	if v, ok := data[secretKeyUsername]; ok {
+		cfg.Username = string(v)
+	}
+	if v, ok := data[secretKeyPassword]; ok {
+		cfg.Password = string(v)
+	}
+}
+
+func applySlackCredentials(cfg *config.SlackConfig, data map[string][]byte) {
+	if v, ok := data[secretKeyWebhookURL]; ok {
+		cfg.WebhookURL = string(v)
+	}
+	if v, ok := data[secretKeySigningSecret]; ok {
+		cfg.SigningSecret = string(v)
+	}
+}
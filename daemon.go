@@ -0,0 +1,298 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+	"github.com/Bharath-H-R/k8s-health/email"
+	"github.com/Bharath-H-R/k8s-health/slack"
+	"github.com/Bharath-H-R/k8s-health/teams"
+)
+
+// runDaemon scans on a fixed interval and serves the callback endpoints used
+// by interactive notifications. SIGTERM or an interrupt stops it after the
+// scan in progress, draining API requests and notifications being sent for
+// up to drain_timeout, so a pod restart mid-incident doesn't drop alerts.
+func runDaemon(ctx context.Context, m *monitor) {
+	stopping, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	// Work still going on when the drain timeout runs out is cancelled
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	drainBy := make(chan time.Time, 1)
+	defer context.AfterFunc(stopping, func() {
+		log.Printf("Shutting down, draining for up to %v", m.cfg.Daemon.DrainTimeout)
+		drainBy <- time.Now().Add(m.cfg.Daemon.DrainTimeout)
+		time.AfterFunc(m.cfg.Daemon.DrainTimeout, cancel)
+	})()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux.HandleFunc("/metrics", m.serveMetrics)
+	mux.Handle("/api/v1/usage", m.api(m.serveUsage))
+	mux.Handle("/api/v1/silences", m.api(m.serveSilences))
+	mux.Handle("/api/v1/silences/", m.api(m.serveSilences))
+	mux.Handle("/api/v1/incidents", m.api(m.serveIncidents))
+	mux.Handle("/api/v1/incidents/", m.api(m.serveIncidents))
+	mux.Handle("/api/v1/recheck/", m.api(m.serveRecheck))
+	mux.Handle("/api/v1/history", m.api(m.serveHistory))
+	mux.Handle("/api/v1/history/rollups", m.api(m.serveRollups))
+	mux.Handle("/api/v1/breaker", m.api(m.serveBreaker))
+	mux.Handle("/api/v1/breaker/reset", m.api(m.serveBreaker))
+	mux.Handle("/api/v1/state", m.api(m.serveState))
+	// Not gzipped: the responses are tiny and gates want them fast
+	mux.Handle("/api/v1/health/", m.authenticated(m.serveHealth))
+	mux.HandleFunc("/dashboard", m.serveDashboard)
+
+	if m.oidc != nil {
+		mux.HandleFunc("/auth/login", m.oidc.Login)
+		mux.HandleFunc("/auth/callback", m.oidc.Callback)
+		mux.HandleFunc("/auth/logout", serveLogout)
+	}
+
+	if m.debugger != nil {
+		mux.HandleFunc("/api/v1/debug", m.serveDebug)
+	}
+
+	if m.cfg.Ingest.Enabled {
+		m.external = newExternalReports(m.cfg.Ingest.TTL)
+		mux.Handle("/api/v1/ingest", m.api(m.serveIngest))
+	}
+
+	if m.cfg.Ownership.Interval > 0 {
+		mux.HandleFunc("/ownership", m.serveOwnershipPage)
+		mux.Handle("/api/v1/ownership", m.api(m.serveOwnership))
+		mux.Handle("/api/v1/ownership/", m.api(m.serveOwnership))
+	}
+
+	if m.slack != nil && m.cfg.Slack.SigningSecret != "" {
+		mux.Handle("/slack/actions", slack.NewActionHandler(m.slack, m.store))
+		mux.Handle("/slack/commands", slack.NewCommandHandler(m.slack, m.runChatCommand))
+	}
+
+	if m.cfg.Teams.OutgoingWebhookSecret != "" {
+		handler, err := teams.NewHandler(m.cfg.Teams.OutgoingWebhookSecret, m.runChatCommand)
+		if err != nil {
+			log.Fatalf("Failed to create teams handler: %v", err)
+		}
+		mux.Handle("/teams/commands", handler)
+	}
+
+	if m.cfg.EmailReplies.WebhookToken != "" {
+		replies := email.NewReplyHandler(m.cfg.EmailReplies.WebhookToken, m.store)
+		replies.SetResponders(m.ownerAddresses, m.cfg.EmailReplies.AllowedDomains)
+		mux.Handle("/email/replies", replies)
+		mux.Handle("/email/bounces", email.NewBounceHandler(m.cfg.EmailReplies.WebhookToken, m.store))
+	}
+
+	server := &http.Server{
+		Addr:              m.cfg.Daemon.ListenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Printf("Listening on %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("HTTP server failed: %v", err)
+		}
+	}()
+
+	if m.cfg.Daemon.Incremental {
+		m.incremental = newChangeTracker(m.k8sClient, m.cfg.Daemon.ResyncInterval)
+	}
+
+	schedule := newScanSchedule(m.cfg.Daemon)
+	log.Printf("Running in daemon mode, scanning every %v", m.cfg.Daemon.Interval)
+	for ns, interval := range schedule.overrides {
+		log.Printf("Scanning namespace %s every %v", ns, interval)
+	}
+	ticker := time.NewTicker(schedule.period())
+	defer ticker.Stop()
+
+	var queue recheckQueue
+	priorityInterval := m.cfg.Daemon.PriorityInterval
+
+	// Don't audit on startup, so daemon restarts don't resend reports
+	lastAudit := time.Now()
+	// Channels were tested on startup
+	lastChannelCheck := time.Now()
+	// Like audits, reviews aren't resent on restart
+	lastOwnershipReview := time.Now()
+	for {
+		// Pick up silences made in Alertmanager before alerting
+		if m.silences != nil {
+			if err := m.silences.sync(ctx, m.store, time.Now()); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+
+		checked := m.runScan(ctx, schedule.due(time.Now()))
+		if stopping.Err() != nil {
+			break
+		}
+		if priorityInterval > 0 {
+			queue.schedule(m.prioritize(checked, time.Now()), time.Now(), priorityInterval)
+		}
+
+		if m.cfg.Daemon.AuditInterval > 0 && time.Since(lastAudit) >= m.cfg.Daemon.AuditInterval {
+			m.runAudit(ctx)
+			lastAudit = time.Now()
+		}
+
+		if m.cfg.Daemon.ChannelCheckInterval > 0 && time.Since(lastChannelCheck) >= m.cfg.Daemon.ChannelCheckInterval {
+			m.testChannels()
+			lastChannelCheck = time.Now()
+		}
+
+		if m.cfg.Ownership.Interval > 0 && time.Since(lastOwnershipReview) >= m.cfg.Ownership.Interval {
+			m.runOwnershipReview(ctx)
+			lastOwnershipReview = time.Now()
+		}
+
+		if m.cfg.Onboarding.Enabled {
+			m.checkNewNamespaces(ctx)
+		}
+
+		if m.checkConfig(ctx) {
+			m.configChanged = true
+			break
+		}
+
+		if !m.waitForScan(ctx, stopping, ticker, &queue) {
+			break
+		}
+	}
+
+	deadline := time.Now().Add(m.cfg.Daemon.DrainTimeout)
+	select {
+	case deadline = <-drainBy:
+	default:
+	}
+	m.drain(server, deadline)
+
+	// Hand this replica's namespaces to the others straight away
+	if m.shards != nil {
+		if err := m.shards.Release(context.Background()); err != nil {
+			log.Printf("Warning: failed to release shard lease: %v", err)
+		}
+	}
+}
+
+// drain waits until deadline for API requests and background notifications
+// to finish.
+func (m *monitor) drain(server *http.Server, deadline time.Time) {
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Warning: API requests still running at shutdown: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		m.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Println("Drained, exiting")
+	case <-ctx.Done():
+		log.Println("Warning: notifications still being sent at shutdown")
+	}
+}
+
+// waitForScan rechecks queued priority deployments as they come due until
+// the next full scan. It returns false once stopping is done.
+func (m *monitor) waitForScan(ctx, stopping context.Context, ticker *time.Ticker, queue *recheckQueue) bool {
+	for {
+		var due <-chan time.Time
+		var timer *time.Timer
+		if next, ok := queue.next(); ok {
+			timer = time.NewTimer(time.Until(next))
+			due = timer.C
+		}
+
+		select {
+		case <-stopping.Done():
+			stopTimer(timer)
+			return false
+		case <-ticker.C:
+			stopTimer(timer)
+			return true
+		case <-due:
+			deps := queue.popDue(time.Now())
+			log.Printf("Rechecking %d priority deployment(s)", len(deps))
+			checked := m.recheck(ctx, deps)
+			queue.add(m.prioritize(checked, time.Now()), time.Now(), m.cfg.Daemon.PriorityInterval)
+		}
+	}
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// api requires REST API callers to authenticate, if configured, and
+// compresses responses for callers that accept gzip.
+func (m *monitor) api(handler http.HandlerFunc) http.Handler {
+	return gzipped(m.authenticated(handler))
+}
+
+// authenticated wraps a REST handler with authentication only.
+func (m *monitor) authenticated(handler http.HandlerFunc) http.Handler {
+	if m.authn == nil {
+		return handler
+	}
+	return auth.Middleware(m.authn, handler)
+}
+
+// gzipped compresses a handler's responses when the caller accepts gzip.
+func gzipped(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		handler.ServeHTTP(gzipResponseWriter{ResponseWriter: w, Writer: gz}, r)
+	})
+}
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	io.Writer
+}
+
+func (w gzipResponseWriter) Write(b []byte) (int, error) {
+	return w.Writer.Write(b)
+}
+
+func (w gzipResponseWriter) WriteHeader(status int) {
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 6px 10px; border-bottom: 1px solid #ddd; }
+th { background: {{.Color}}; color: #fff; }
+.unhealthy { color: #c62828; font-weight: bold; }
+.healthy { color: #2e7d32; }
+.stopped { color: #777777; }
+</style></head>
+<body>
+<h2>{{.Title}}: {{.Cluster}}</h2>
+{{if .User}}<p>Signed in as {{.User}}{{if .Logout}} · <a href="/auth/logout">Sign out</a>{{end}}</p>{{end}}
+<p>{{.Unhealthy}} of {{len .Rows}} service(s) unhealthy{{if .Stopped}}, {{.Stopped}} intentionally stopped{{end}}</p>
+<table>
+<tr><th>Service</th><th>Status</th><th>Classification</th><th>Reason</th><th>Incident</th><th>Checked</th></tr>
+{{range .Rows}}<tr>
+<td>{{.Service}}</td>
+{{if .Result.Stopped}}<td class="stopped">stopped</td><td></td><td>{{.Result.Reason}}</td>{{else if .Result.Healthy}}<td class="healthy">healthy</td><td></td><td></td>{{else}}<td class="unhealthy">unhealthy</td><td>{{.Result.Classification}}</td><td>{{.Result.Reason}}</td>{{end}}
+<td>{{if .Incident}}since {{.Incident.StartedAt.Format "2006-01-02 15:04"}}{{if .Incident.AcknowledgedBy}}, acknowledged by {{.Incident.AcknowledgedBy}}{{end}}{{end}}{{if .Silence}} (silenced until {{.Silence.Until.Format "2006-01-02 15:04"}}){{end}}</td>
+<td>{{.Result.CheckedAt.Format "15:04:05"}}</td>
+</tr>{{end}}
+</table>
+</body></html>
+`))
+
+type dashboardRow struct {
+	Service  string
+	Result   state.ScanResult
+	Incident *state.Incident
+	Silence  *state.Silence
+}
+
+// serveDashboard renders the latest scan for the namespaces the user may
+// view. With OIDC configured, anonymous users are sent to sign in.
+func (m *monitor) serveDashboard(w http.ResponseWriter, r *http.Request) {
+	var principal *auth.Principal
+	if m.authn != nil {
+		var err error
+		if principal, err = m.authn.Authenticate(r); err != nil {
+			if m.oidc != nil {
+				http.Redirect(w, r, "/auth/login?next=/dashboard", http.StatusFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	now := time.Now()
+	var rows []dashboardRow
+	unhealthy, stopped := 0, 0
+	for key, result := range m.store.LastScan() {
+		if !principal.CanView(result.Namespace) {
+			continue
+		}
+		row := dashboardRow{Service: key, Result: result}
+		if incident, ok := m.store.Incident(result.Namespace, result.Deployment); ok {
+			row.Incident = &incident
+		}
+		if silence, ok := m.store.Silenced(result.Namespace, result.Deployment, now); ok {
+			row.Silence = &silence
+		}
+		if !result.Healthy {
+			unhealthy++
+		} else if result.Stopped {
+			stopped++
+		}
+		rows = append(rows, row)
+	}
+	// Failures first, then by name
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Result.Healthy != rows[j].Result.Healthy {
+			return !rows[i].Result.Healthy
+		}
+		return rows[i].Service < rows[j].Service
+	})
+
+	var user string
+	if principal != nil {
+		user = principal.Name
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(w, struct {
+		Title     string
+		Color     string
+		Cluster   string
+		User      string
+		Logout    bool
+		Unhealthy int
+		Stopped   int
+		Rows      []dashboardRow
+	}{
+		Title:     m.cfg.Branding.ProductName,
+		Color:     m.cfg.Branding.PrimaryColor,
+		Cluster:   m.cfg.ClusterName,
+		User:      user,
+		Logout:    m.oidc != nil,
+		Unhealthy: unhealthy,
+		Stopped:   stopped,
+		Rows:      rows,
+	}); err != nil {
+		log.Printf("Failed to render dashboard: %v", err)
+	}
+}
+
+// serveLogout clears the dashboard session.
+func serveLogout(w http.ResponseWriter, r *http.Request) {
+	auth.ClearSession(w)
+	http.Redirect(w, r, "/dashboard", http.StatusFound)
+}
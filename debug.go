@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+)
+
+// debugRequest is the body of POST /api/v1/debug.
+type debugRequest struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	// By names the responder, for the audit log; with API authentication
+	// it is the caller
+	By string `json:"by"`
+}
+
+// serveDebug launches an ephemeral debug container for a responder holding
+// the debug launch token or, with API authentication configured, allowed to
+// edit the pod's namespace.
+func (m *monitor) serveDebug(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// The launch token is shared and can't be scoped to namespaces, so it
+	// only stands in for API authentication while that is off
+	var principal *auth.Principal
+	if m.authn != nil {
+		var err error
+		if principal, err = m.authn.Authenticate(r); err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="k8s-health-monitor"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	} else {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(m.cfg.Debug.LaunchToken)) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var req debugRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if principal != nil {
+		req.By = principal.Name
+	}
+	if req.Namespace == "" || req.Pod == "" || req.By == "" {
+		http.Error(w, "namespace, pod and by are required", http.StatusBadRequest)
+		return
+	}
+	if !principal.CanEdit(req.Namespace) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	name, err := m.debugger.Launch(r.Context(), req.Namespace, req.Pod, req.Container)
+	if err != nil {
+		log.Printf("Failed to launch debug container in %s/%s for %s: %v", req.Namespace, req.Pod, req.By, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	log.Printf("Debug container %s launched in %s/%s by %s", name, req.Namespace, req.Pod, req.By)
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"container": name,
+		"attach":    fmt.Sprintf("kubectl attach -it -n %s %s -c %s", req.Namespace, req.Pod, name),
+	})
+}
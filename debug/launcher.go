@@ -0,0 +1,76 @@
+// Package debug builds kubectl debug commands for alerts and launches
+// ephemeral debug containers on behalf of responders.
+package debug
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+)
+
+// Command returns the kubectl debug command that attaches a debug container,
+// sharing the target container's process namespace, to a pod.
+func Command(image, namespace, pod, container string) string {
+	parts := []string{"kubectl", "debug", "-it", "-n", namespace, pod, "--image=" + image}
+	if container != "" {
+		parts = append(parts, "--target="+container)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Critical reports whether a failure classification warrants a debug command
+// in the alert.
+func Critical(cfg config.DebugConfig, classification string) bool {
+	if cfg.Image == "" {
+		return false
+	}
+	for _, class := range cfg.Classifications {
+		if class == classification {
+			return true
+		}
+	}
+	return false
+}
+
+// Launcher adds ephemeral debug containers to running pods.
+type Launcher struct {
+	client kubernetes.Interface
+	image  string
+}
+
+func NewLauncher(client kubernetes.Interface, cfg config.DebugConfig) *Launcher {
+	return &Launcher{client: client, image: cfg.Image}
+}
+
+// Launch adds a debug container targeting container to the pod and returns
+// its name. Responders attach with kubectl attach -it -c <name>.
+func (l *Launcher) Launch(ctx context.Context, namespace, podName, container string) (string, error) {
+	pod, err := l.client.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get pod %s/%s: %w", namespace, podName, err)
+	}
+
+	name := "debugger-" + rand.String(5)
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:                     name,
+			Image:                    l.image,
+			Stdin:                    true,
+			TTY:                      true,
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		},
+		TargetContainerName: container,
+	})
+
+	if _, err := l.client.CoreV1().Pods(namespace).UpdateEphemeralContainers(ctx, podName, pod, metav1.UpdateOptions{}); err != nil {
+		return "", fmt.Errorf("failed to add debug container to %s/%s: %w", namespace, podName, err)
+	}
+	return name, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// runDiff checks the cluster and prints how it differs from the last stored
+// scan. It doesn't update the store or send notifications.
+func (m *monitor) runDiff(ctx context.Context, out io.Writer) error {
+	checked, err := m.check(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to scan deployments: %w", err)
+	}
+
+	changes := state.Diff(m.store.LastScan(), scanResults(checked, time.Now()))
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "No changes since the last stored scan.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHANGE\tSERVICE\tDETAILS")
+	for _, change := range changes {
+		curr := change.Current
+		service := state.Key(curr.Namespace, curr.Deployment)
+
+		switch change.Kind {
+		case state.ChangeNewlyFailed:
+			fmt.Fprintf(w, "NEWLY FAILED\t%s\t%s\n", service, curr.Reason)
+		case state.ChangeRecovered:
+			fmt.Fprintf(w, "RECOVERED\t%s\twas: %s\n", service, change.Previous.Reason)
+		case state.ChangeReasonChanged:
+			fmt.Fprintf(w, "REASON CHANGED\t%s\t%s -> %s: %s\n", service,
+				change.Previous.Classification, curr.Classification, curr.Reason)
+		}
+	}
+	return w.Flush()
+}
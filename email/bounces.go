@@ -0,0 +1,129 @@
+package email
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// BounceHandler processes bounces forwarded by an inbound email webhook
+// (SendGrid Inbound Parse with raw MIME, or a Mailgun route storing
+// body-mime) and records owner addresses that can't receive mail, so alerts
+// fall back to the DL.
+type BounceHandler struct {
+	token string
+	store *state.Store
+}
+
+func NewBounceHandler(token string, store *state.Store) *BounceHandler {
+	return &BounceHandler{token: token, store: store}
+}
+
+func (h *BounceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(h.token)) != 1 {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil && err != http.ErrNotMultipart {
+		http.Error(w, "invalid form body", http.StatusBadRequest)
+		return
+	}
+
+	// Always answer 200 for unprocessable mail so the provider doesn't retry
+	bounces, err := ParseBounce(strings.NewReader(firstValue(r, "email", "body-mime")))
+	if err != nil {
+		log.Printf("Ignoring inbound email: %v", err)
+	}
+	for _, bounce := range bounces {
+		bounce.BouncedAt = time.Now()
+		if err := h.store.RecordBounce(bounce); err != nil {
+			log.Printf("Failed to record bounce for %s: %v", bounce.Address, err)
+			continue
+		}
+		log.Printf("Warning: mail to %s bounced (%s %s)", bounce.Address, bounce.Status, bounce.Diagnosis)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// ParseBounce extracts the permanently failed recipients from a delivery
+// status notification (RFC 3464) in raw MIME form.
+func ParseBounce(raw io.Reader) ([]state.Bounce, error) {
+	msg, err := mail.ReadMessage(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/report" || params["report-type"] != "delivery-status" {
+		return nil, fmt.Errorf("not a delivery status notification")
+	}
+
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("delivery status notification has no status")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid delivery status notification: %w", err)
+		}
+		if mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type")); mediaType == "message/delivery-status" {
+			return parseDeliveryStatus(part)
+		}
+	}
+}
+
+// parseDeliveryStatus reads the per-message fields followed by a block of
+// fields per recipient, and returns the recipients whose delivery failed.
+func parseDeliveryStatus(r io.Reader) ([]state.Bounce, error) {
+	reader := textproto.NewReader(bufio.NewReader(r))
+	if _, err := reader.ReadMIMEHeader(); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid delivery status: %w", err)
+	}
+
+	var bounces []state.Bounce
+	for {
+		fields, err := reader.ReadMIMEHeader()
+		if len(fields) > 0 && strings.EqualFold(fields.Get("Action"), "failed") {
+			if address := strings.Trim(typedValue(fields.Get("Final-Recipient")), "<>"); address != "" {
+				bounces = append(bounces, state.Bounce{
+					Address:   address,
+					Status:    fields.Get("Status"),
+					Diagnosis: typedValue(fields.Get("Diagnostic-Code")),
+				})
+			}
+		}
+		if err == io.EOF {
+			return bounces, nil
+		}
+		if err != nil {
+			return bounces, fmt.Errorf("invalid delivery status: %w", err)
+		}
+	}
+}
+
+// typedValue strips the type from a typed field such as
+// "rfc822; someone@example.com".
+func typedValue(field string) string {
+	if i := strings.Index(field, ";"); i >= 0 {
+		field = field[i+1:]
+	}
+	return strings.TrimSpace(field)
+}
@@ -0,0 +1,116 @@
+package email
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+)
+
+// relays returns the config for each SMTP relay in the order to try them:
+// the primary and then the fallbacks, with relays that failed within
+// retry_after moved to the end.
+func (s *Sender) relays(now time.Time) []config.SMTPConfig {
+	s.mu.RLock()
+	primary := s.config
+	s.mu.RUnlock()
+
+	all := []config.SMTPConfig{primary}
+	for _, fallback := range primary.Fallbacks {
+		relay := primary
+		relay.Host = fallback.Host
+		if fallback.Port != 0 {
+			relay.Port = fallback.Port
+		}
+		if fallback.Username != "" {
+			relay.Username, relay.Password = fallback.Username, fallback.Password
+		}
+		all = append(all, relay)
+	}
+
+	s.relayMu.Lock()
+	defer s.relayMu.Unlock()
+	var up, down []config.SMTPConfig
+	for _, relay := range all {
+		if failed, ok := s.relayFailed[relayAddr(relay)]; ok && now.Sub(failed) < primary.RetryAfter {
+			down = append(down, relay)
+		} else {
+			up = append(up, relay)
+		}
+	}
+	return append(up, down...)
+}
+
+// markRelay records whether a relay is working.
+func (s *Sender) markRelay(relay config.SMTPConfig, err error, now time.Time) {
+	s.relayMu.Lock()
+	defer s.relayMu.Unlock()
+	if s.relayFailed == nil {
+		s.relayFailed = make(map[string]time.Time)
+	}
+	if err != nil {
+		s.relayFailed[relayAddr(relay)] = now
+	} else {
+		delete(s.relayFailed, relayAddr(relay))
+	}
+}
+
+// send delivers a message through the first relay that accepts it.
+func (s *Sender) send(rcpts []string, msg []byte) error {
+	relays := s.relays(time.Now())
+	var failures []string
+	for i, relay := range relays {
+		var auth smtp.Auth
+		if !relay.NoAuth && relay.Username != "" {
+			auth = smtp.PlainAuth("", relay.Username, relay.Password, relay.Host)
+		}
+		err := deliver(relay, s.proxy, s.tlsConfig, auth, rcpts, msg)
+		s.markRelay(relay, err, time.Now())
+		if err == nil {
+			return nil
+		}
+		if len(relays) == 1 {
+			return err
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", relayAddr(relay), err))
+		if i < len(relays)-1 {
+			log.Printf("Warning: smtp relay %s failed, trying the next one: %v", relayAddr(relay), err)
+		}
+	}
+	return fmt.Errorf("all %d smtp relays failed: %s", len(relays), strings.Join(failures, "; "))
+}
+
+// CheckRelay verifies the SMTP relays are reachable and meet the TLS policy.
+// It fails only if none of them does; relays that fail are logged and tried
+// last until they recover.
+func (s *Sender) CheckRelay() error {
+	if s.outbox != nil {
+		return nil
+	}
+	relays := s.relays(time.Now())
+	var failures []string
+	for _, relay := range relays {
+		err := checkRelay(relay, s.proxy, s.tlsConfig)
+		s.markRelay(relay, err, time.Now())
+		if err == nil {
+			continue
+		}
+		if len(relays) == 1 {
+			return err
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", relayAddr(relay), err))
+		log.Printf("Warning: smtp relay %s failed its check: %v", relayAddr(relay), err)
+	}
+	if len(failures) == len(relays) {
+		return fmt.Errorf("all %d smtp relays failed: %s", len(relays), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func relayAddr(relay config.SMTPConfig) string {
+	return net.JoinHostPort(relay.Host, fmt.Sprint(relay.Port))
+}
@@ -0,0 +1,96 @@
+package email
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Maintenance is a scheduled maintenance window during which a deployment's
+// alerts are silenced.
+type Maintenance struct {
+	Namespace  string
+	Deployment string
+	Start      time.Time
+	End        time.Time
+	Reason     string
+	CreatedBy  string
+}
+
+// SendMaintenanceInvite emails a calendar event for a maintenance window, so
+// the people who would otherwise have been alerted see the planned silence in
+// their calendars. The event doesn't block time.
+func (s *Sender) SendMaintenanceInvite(to, cc []string, window Maintenance) error {
+	service := window.Namespace + "/" + window.Deployment
+	subject := fmt.Sprintf("[INFO] Maintenance: alerts for %s silenced %s - %s", service,
+		window.Start.UTC().Format("2006-01-02 15:04"), window.End.UTC().Format("2006-01-02 15:04 MST"))
+
+	description := fmt.Sprintf("Health alerts for %s in cluster %s are silenced during this maintenance window.", service, s.clusterName)
+	if window.Reason != "" {
+		description += "\nReason: " + window.Reason
+	}
+	if window.CreatedBy != "" {
+		description += "\nScheduled by: " + window.CreatedBy
+	}
+
+	organizer := s.config.From
+	if address, err := mail.ParseAddress(s.config.From); err == nil {
+		organizer = address.Address
+	}
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//k8s-health//maintenance//EN",
+		"METHOD:REQUEST",
+		"BEGIN:VEVENT",
+		fmt.Sprintf("UID:%s.%s.%d@k8s-health", window.Namespace, window.Deployment, window.Start.Unix()),
+		"DTSTAMP:" + icsTime(time.Now()),
+		"DTSTART:" + icsTime(window.Start),
+		"DTEND:" + icsTime(window.End),
+		"SUMMARY:" + icsText("Maintenance: "+service+" alerts silenced"),
+		"DESCRIPTION:" + icsText(description),
+		"ORGANIZER:mailto:" + organizer,
+	}
+	for _, attendee := range append(append([]string{}, to...), cc...) {
+		lines = append(lines, "ATTENDEE;ROLE=OPT-PARTICIPANT;RSVP=FALSE:mailto:"+attendee)
+	}
+	lines = append(lines, "TRANSP:TRANSPARENT", "END:VEVENT", "END:VCALENDAR")
+
+	var body strings.Builder
+	for _, line := range lines {
+		body.WriteString(icsFold(line))
+		body.WriteString("\r\n")
+	}
+
+	return s.sendEmail(to, cc, subject, body.String(), false, map[string]string{
+		"Content-Type": "text/calendar; method=REQUEST; charset=UTF-8",
+	})
+}
+
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// icsText escapes a TEXT property value (RFC 5545 section 3.3.11).
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icsFold folds a content line longer than 75 octets (RFC 5545 section 3.1),
+// without splitting UTF-8 sequences.
+func icsFold(line string) string {
+	var folded strings.Builder
+	n := 0
+	for _, r := range line {
+		size := len(string(r))
+		if n+size > 75 {
+			folded.WriteString("\r\n ")
+			n = 1
+		}
+		folded.WriteRune(r)
+		n += size
+	}
+	return folded.String()
+}
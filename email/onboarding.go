@@ -0,0 +1,46 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/health"
+)
+
+// SendOnboardingReport tells the platform team about a new namespace, so its
+// workloads can be set up for monitoring before their first failure.
+func (s *Sender) SendOnboardingReport(to string, report health.NamespaceReport) error {
+	unmonitored := 0
+	for _, workload := range report.Workloads {
+		if !workload.Monitored {
+			unmonitored++
+		}
+	}
+	subject := fmt.Sprintf("[INFO] New namespace %s in %s: %d workload(s)", report.Namespace, s.clusterName, len(report.Workloads))
+	if unmonitored > 0 {
+		subject = fmt.Sprintf("[ACTION REQUIRED] New namespace %s in %s: %d workload(s) not monitored", report.Namespace, s.clusterName, unmonitored)
+	}
+
+	templateData := struct {
+		Report      health.NamespaceReport
+		Unmonitored int
+		GeneratedAt time.Time
+		ClusterName string
+		Branding    config.BrandingConfig
+	}{
+		Report:      report,
+		Unmonitored: unmonitored,
+		GeneratedAt: time.Now(),
+		ClusterName: s.clusterName,
+		Branding:    s.branding,
+	}
+
+	var buf bytes.Buffer
+	if err := s.onboardingTemplate.Execute(&buf, templateData); err != nil {
+		return fmt.Errorf("failed to execute onboarding template: %w", err)
+	}
+
+	return s.sendEmail([]string{to}, nil, subject, buf.String(), false, nil)
+}
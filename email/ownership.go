@@ -0,0 +1,61 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+)
+
+// OwnershipReview is one deployment in an owner's ownership review email.
+type OwnershipReview struct {
+	Namespace  string
+	Deployment string
+	// ConfirmedAt is when the owner last confirmed it; zero if never
+	ConfirmedAt time.Time
+	// Stale is set when the owner hasn't confirmed it recently enough
+	Stale bool
+	// TransferTo is a pending handover the owner asked for
+	TransferTo string
+	// ReviewURL opens the page to confirm or transfer the deployment
+	ReviewURL string
+}
+
+// SendOwnershipReview asks an owner to confirm the deployments attributed to
+// them, or hand them over, so alerts keep reaching someone responsible.
+func (s *Sender) SendOwnershipReview(owner string, cc []string, reviews []OwnershipReview) error {
+	stale := 0
+	for _, review := range reviews {
+		if review.Stale {
+			stale++
+		}
+	}
+	subject := fmt.Sprintf("[INFO] Please confirm you still own %d deployment(s) in %s", len(reviews), s.clusterName)
+	if stale > 0 {
+		subject = fmt.Sprintf("[ACTION REQUIRED] Ownership of %d deployment(s) in %s is unconfirmed", stale, s.clusterName)
+	}
+
+	templateData := struct {
+		Owner       string
+		Reviews     []OwnershipReview
+		Stale       int
+		GeneratedAt time.Time
+		ClusterName string
+		Branding    config.BrandingConfig
+	}{
+		Owner:       owner,
+		Reviews:     reviews,
+		Stale:       stale,
+		GeneratedAt: time.Now(),
+		ClusterName: s.clusterName,
+		Branding:    s.branding,
+	}
+
+	var buf bytes.Buffer
+	if err := s.ownershipTemplate.Execute(&buf, templateData); err != nil {
+		return fmt.Errorf("failed to execute ownership template: %w", err)
+	}
+
+	return s.sendEmail([]string{owner}, cc, subject, buf.String(), false, nil)
+}
@@ -0,0 +1,143 @@
+package email
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// Matches the namespace/deployment in alert subjects in any locale, including
+// "Re:" prefixed replies.
+var alertSubjectPattern = regexp.MustCompile(`: ([a-z0-9][-a-z0-9]*)/([a-z0-9][-a-z0-9.]*)`)
+
+// ReplyHandler processes inbound email webhooks (SendGrid Inbound Parse or
+// Mailgun routes) and acknowledges incidents when an owner replies "ACK".
+type ReplyHandler struct {
+	token string
+	store *state.Store
+	// owners returns a deployment's owner and DL addresses; without it
+	// only senders in domains may acknowledge
+	owners func(namespace, deployment string) ([]string, error)
+	// domains, if set, lets anyone in them acknowledge, e.g. the DL's members
+	domains *health.EmailValidator
+}
+
+func NewReplyHandler(token string, store *state.Store) *ReplyHandler {
+	return &ReplyHandler{token: token, store: store}
+}
+
+// SetResponders limits who may acknowledge by reply to a deployment's owners,
+// as returned by owners, and to senders in allowedDomains or their subdomains.
+func (h *ReplyHandler) SetResponders(owners func(namespace, deployment string) ([]string, error), allowedDomains []string) {
+	h.owners = owners
+	if len(allowedDomains) > 0 {
+		h.domains = health.NewEmailValidator(allowedDomains)
+	}
+}
+
+func (h *ReplyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(h.token)) != 1 {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil && err != http.ErrNotMultipart {
+		http.Error(w, "invalid form body", http.StatusBadRequest)
+		return
+	}
+
+	from := firstValue(r, "from", "sender")
+	subject := firstValue(r, "subject")
+	body := firstValue(r, "stripped-text", "text", "body-plain")
+
+	// Always answer 200 for unprocessable mail so the provider doesn't retry
+	if err := h.process(from, subject, body); err != nil {
+		log.Printf("Ignoring email reply from %q: %v", from, err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *ReplyHandler) process(from, subject, body string) error {
+	if !isAck(body) {
+		return fmt.Errorf("reply is not an acknowledgment")
+	}
+
+	match := alertSubjectPattern.FindStringSubmatch(subject)
+	if match == nil {
+		return fmt.Errorf("subject %q does not reference an alert", subject)
+	}
+
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+
+	if err := h.checkResponder(match[1], match[2], addr.Address); err != nil {
+		return err
+	}
+
+	if _, err := h.store.Acknowledge(match[1], match[2], addr.Address, time.Now()); err != nil {
+		return err
+	}
+
+	log.Printf("Incident for %s/%s acknowledged by %s via email reply", match[1], match[2], addr.Address)
+	return nil
+}
+
+// checkResponder returns an error unless address may acknowledge incidents of
+// the deployment. The sender of a reply is easily forged, so this keeps
+// outsiders from pausing escalation, not a determined insider.
+func (h *ReplyHandler) checkResponder(namespace, deployment, address string) error {
+	if h.domains != nil {
+		if _, err := h.domains.Normalize(address); err == nil {
+			return nil
+		}
+	}
+	if h.owners != nil {
+		owners, err := h.owners(namespace, deployment)
+		if err != nil {
+			return fmt.Errorf("failed to look up the owners of %s/%s: %w", namespace, deployment, err)
+		}
+		for _, owner := range owners {
+			if strings.EqualFold(owner, address) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%s is not an owner of %s/%s", address, namespace, deployment)
+}
+
+// isAck reports whether the first non-empty, non-quoted line of a reply
+// starts with "ACK".
+func isAck(body string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ">") {
+			continue
+		}
+		return strings.HasPrefix(strings.ToUpper(line), "ACK")
+	}
+	return false
+}
+
+func firstValue(r *http.Request, keys ...string) string {
+	for _, key := range keys {
+		if v := r.FormValue(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
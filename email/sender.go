@@ -2,41 +2,124 @@ package email
 
 import (
     "bytes"
+    "crypto/tls"
     "fmt"
     "html/template"
-    "net/smtp"
     "os"
-    "path/filepath"
+    "strings"
+    "sync"
     "time"
     
-    "k8s-health-monitor/config"
-    "k8s-health-monitor/health"
+    "github.com/Bharath-H-R/k8s-health/config"
+    "github.com/Bharath-H-R/k8s-health/health"
+    "github.com/Bharath-H-R/k8s-health/i18n"
+    "github.com/Bharath-H-R/k8s-health/outbox"
+    "github.com/Bharath-H-R/k8s-health/transport"
 )
 
 type Sender struct {
+    mu         sync.RWMutex
     config     config.SMTPConfig
+    proxy      config.ProxyConfig
+    tlsConfig  *tls.Config
+    branding   config.BrandingConfig
+    clusterName  string
+    logTailLines int
+    // infraEmail is copied on every alert
+    infraEmail   string
+    // bounced reports addresses known to be undeliverable
+    bounced      func(address string) bool
+    // outbox, if set, receives messages instead of the SMTP relay
+    outbox       *outbox.Dir
+    // relayMu guards relayFailed, when each relay that is down last failed,
+    // by address
+    relayMu      sync.Mutex
+    relayFailed  map[string]time.Time
     emailTemplate *template.Template
+    auditTemplate *template.Template
+    infraTemplate *template.Template
+    recoveryTemplate *template.Template
+    ownershipTemplate *template.Template
+    onboardingTemplate *template.Template
 }
 
-func NewSender(cfg config.SMTPConfig) (*Sender, error) {
-    sender := &Sender{config: cfg}
+func NewSender(cfg *config.Config) (*Sender, error) {
+    sender := &Sender{
+        config:       cfg.SMTPConfig,
+        proxy:        transport.Proxy(cfg.Proxy, cfg.SMTPConfig.Proxy),
+        branding:     cfg.Branding,
+        clusterName:  cfg.ClusterName,
+        logTailLines: cfg.LogTailLines,
+        infraEmail:   cfg.InfraEmail,
+    }
+    
+    var err error
+    sender.tlsConfig, err = transport.TLSConfig(cfg.SMTPConfig.TLS)
+    if err != nil {
+        return nil, fmt.Errorf("smtp tls: %w", err)
+    }
     
-    // Load email template
-    err := sender.loadEmailTemplate()
+    // Load email templates
+    sender.emailTemplate, err = loadTemplate("template.html")
     if err != nil {
         return nil, fmt.Errorf("failed to load email template: %w", err)
     }
     
+    sender.auditTemplate, err = loadTemplate("audit.html")
+    if err != nil {
+        return nil, fmt.Errorf("failed to load audit template: %w", err)
+    }
+    
+    sender.infraTemplate, err = loadTemplate("infra.html")
+    if err != nil {
+        return nil, fmt.Errorf("failed to load infra template: %w", err)
+    }
+    
+    sender.recoveryTemplate, err = loadTemplate("recovery.html")
+    if err != nil {
+        return nil, fmt.Errorf("failed to load recovery template: %w", err)
+    }
+    
+    sender.ownershipTemplate, err = loadTemplate("ownership.html")
+    if err != nil {
+        return nil, fmt.Errorf("failed to load ownership template: %w", err)
+    }
+    
+    sender.onboardingTemplate, err = loadTemplate("onboarding.html")
+    if err != nil {
+        return nil, fmt.Errorf("failed to load onboarding template: %w", err)
+    }
+    
     return sender, nil
 }
 
-func (s *Sender) loadEmailTemplate() error {
+// SetBounceChecker makes alerts skip owner addresses that bounced, falling
+// back to the DL, or to the infra address if the DL bounced too.
+func (s *Sender) SetBounceChecker(bounced func(address string) bool) {
+    s.bounced = bounced
+}
+
+// SetOutbox writes messages to the outbox instead of sending them.
+func (s *Sender) SetOutbox(dir *outbox.Dir) {
+    s.outbox = dir
+}
+
+// SetCredentials replaces the SMTP username and password, e.g. after a Secret
+// rotation.
+func (s *Sender) SetCredentials(username, password string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.config.Username = username
+    s.config.Password = password
+}
+
+func loadTemplate(name string) (*template.Template, error) {
     // Try multiple locations for template file
     templatePaths := []string{
-        "./email/template.html",
-        "./template.html",
-        "/app/email/template.html",
-        "/app/template.html",
+        "./email/" + name,
+        "./" + name,
+        "/app/email/" + name,
+        "/app/" + name,
     }
     
     var templateContent string
@@ -52,17 +135,24 @@ func (s *Sender) loadEmailTemplate() error {
     
     if !found {
         // Fallback to embedded template
-        return fmt.Errorf("email template not found in any location")
+        return nil, fmt.Errorf("%s not found in any location", name)
     }
     
     // Create template with custom functions
-    tmpl, err := template.New("email").Funcs(template.FuncMap{
+    tmpl, err := template.New(name).Funcs(template.FuncMap{
         "formatTime": func(t time.Time) string {
             return t.Format("Mon, 02 Jan 2006 15:04:05 MST")
         },
+        // Replaced per alert with the deployment's locale
+        "t": func(key string, args ...interface{}) string {
+            return i18n.T(i18n.DefaultLocale, key, args...)
+        },
         "currentYear": func() int {
             return time.Now().Year()
         },
+        "inc": func(i int) int {
+            return i + 1
+        },
         "truncateLogs": func(logs string, maxLines int) string {
             lines := bytes.Split([]byte(logs), []byte("\n"))
             if len(lines) > maxLines {
@@ -73,18 +163,19 @@ func (s *Sender) loadEmailTemplate() error {
     }).Parse(templateContent)
     
     if err != nil {
-        return fmt.Errorf("failed to parse email template: %w", err)
+        return nil, fmt.Errorf("failed to parse %s: %w", name, err)
     }
     
-    s.emailTemplate = tmpl
-    return nil
+    return tmpl, nil
 }
 
 func (s *Sender) SendHealthAlert(failedService health.FailedService) error {
     // Prepare email content
-    subject := fmt.Sprintf("[URGENT] Service Health Alert: %s/%s is DOWN", 
-        failedService.Deployment.Namespace, 
-        failedService.Deployment.Name)
+    warning := failedService.Severity == health.SeverityWarning
+    subject := alertSubject(failedService.Locale, failedService.Deployment, failedService.IncidentID, warning)
+    if failedService.Drill {
+        subject = "[DRILL] " + subject
+    }
     
     // Generate HTML body
     htmlBody, err := s.generateHTMLBody(failedService)
@@ -93,14 +184,141 @@ func (s *Sender) SendHealthAlert(failedService health.FailedService) error {
     }
     
     // Prepare recipients
-    to := []string{failedService.Deployment.OwnerEmail}
-    cc := []string{
-        failedService.Deployment.OwnerDlEmail,
-        "tech.infraengineers@godigit.com",
+    to, cc := s.ownerRecipients(failedService.Deployment)
+    switch {
+    case failedService.Drill:
+        // Drills only go to the test recipient
+    case failedService.Probation > 0:
+        // New services still being stood up only alert their owner
+        to, cc = to[:1], nil
+    case s.infraEmail != "" && (health.IsPlatform(failedService.Classification) || failedService.Hotspot != nil):
+        // Platform failures, and failures concentrated on one node, node
+        // group or zone, are the infra team's to fix; owners stay informed
+        to, cc = []string{s.infraEmail}, append(to, cc...)
+    case s.infraEmail != "" && to[0] != s.infraEmail:
+        cc = append(cc, s.infraEmail)
+    }
+    
+    // Follow-ups reply to the first alert so the incident stays in one thread
+    var headers map[string]string
+    if failedService.IncidentID != "" {
+        headers = s.threadHeaders(failedService.IncidentID, failedService.FollowUp)
+    }
+    
+    // Send email; warnings aren't flagged high priority
+    return s.sendEmail(to, cc, subject, htmlBody, !warning, headers)
+}
+
+// SendRecovery tells the owners of a notified incident that the service is
+// healthy again, as a reply in the incident's thread.
+func (s *Sender) SendRecovery(dep health.DeploymentInfo, locale, incidentID string, startedAt, now time.Time) error {
+    // Keep the alert subject so clients that thread by subject group it too
+    subject := "Re: " + alertSubject(locale, dep, incidentID, false)
+    
+    templateData := struct {
+        Deployment  health.DeploymentInfo
+        IncidentID  string
+        StartedAt   time.Time
+        ResolvedAt  time.Time
+        Duration    time.Duration
+        ClusterName string
+        Branding    config.BrandingConfig
+        Locale      string
+    }{
+        Deployment:  dep,
+        IncidentID:  incidentID,
+        StartedAt:   startedAt,
+        ResolvedAt:  now,
+        Duration:    now.Sub(startedAt).Round(time.Second),
+        ClusterName: s.clusterName,
+        Branding:    s.branding,
+        Locale:      i18n.Resolve(nil, locale),
+    }
+    
+    tmpl, err := localized(s.recoveryTemplate, templateData.Locale)
+    if err != nil {
+        return err
+    }
+    var buf bytes.Buffer
+    if err := tmpl.Execute(&buf, templateData); err != nil {
+        return fmt.Errorf("failed to execute recovery template: %w", err)
+    }
+    
+    to, cc := s.ownerRecipients(dep)
+    if s.infraEmail != "" && to[0] != s.infraEmail {
+        cc = append(cc, s.infraEmail)
+    }
+    return s.sendEmail(to, cc, subject, buf.String(), false, s.threadHeaders(incidentID, true))
+}
+
+// ownerRecipients returns the owner as To and the DL as Cc, leaving out
+// addresses that bounced. If both bounced it falls back to the infra address,
+// or to trying them anyway without one.
+func (s *Sender) ownerRecipients(dep health.DeploymentInfo) ([]string, []string) {
+    var live []string
+    for _, address := range []string{dep.OwnerEmail, dep.OwnerDlEmail} {
+        if s.bounced == nil || !s.bounced(address) {
+            live = append(live, address)
+        }
+    }
+    if len(live) == 0 {
+        if s.infraEmail != "" {
+            return []string{s.infraEmail}, nil
+        }
+        live = []string{dep.OwnerEmail, dep.OwnerDlEmail}
+    }
+    return live[:1], live[1:]
+}
+
+func alertSubject(locale string, dep health.DeploymentInfo, incidentID string, warning bool) string {
+    service := dep.Namespace + "/" + dep.Name
+    key := "alert.subject"
+    if warning {
+        key = "alert.subject_warning"
     }
+    if incidentID == "" {
+        return i18n.T(locale, key, service)
+    }
+    return i18n.T(locale, key+"_incident", service, incidentID)
+}
+
+// threadHeaders returns the headers that group all emails for an incident.
+// The first alert's Message-ID is derived from the incident ID, so follow-ups
+// can reference it without remembering what was sent.
+func (s *Sender) threadHeaders(incidentID string, followUp bool) map[string]string {
+    domain := "k8s-health-monitor"
+    if i := strings.LastIndex(s.config.From, "@"); i >= 0 {
+        domain = strings.TrimSuffix(s.config.From[i+1:], ">")
+    }
+    root := fmt.Sprintf("<%s@%s>", incidentID, domain)
     
-    // Send email
-    return s.sendEmail(to, cc, subject, htmlBody)
+    if !followUp {
+        return map[string]string{"Message-ID": root}
+    }
+    return map[string]string{
+        "Message-ID":  fmt.Sprintf("<%s.%d@%s>", incidentID, time.Now().UnixNano(), domain),
+        "In-Reply-To": root,
+        "References":  root,
+    }
+}
+
+// localized returns a copy of tmpl whose "t" function uses the given locale.
+func localized(tmpl *template.Template, locale string) (*template.Template, error) {
+    clone, err := tmpl.Clone()
+    if err != nil {
+        return nil, fmt.Errorf("failed to clone template: %w", err)
+    }
+    return clone.Funcs(template.FuncMap{
+        "t": func(key string, args ...interface{}) string {
+            return i18n.T(locale, key, args...)
+        },
+    }), nil
+}
+
+// RenderHealthAlert returns the HTML body of the alert email for a failed
+// service, e.g. to archive it.
+func (s *Sender) RenderHealthAlert(failedService health.FailedService) (string, error) {
+    return s.generateHTMLBody(failedService)
 }
 
 func (s *Sender) generateHTMLBody(failedService health.FailedService) (string, error) {
@@ -113,42 +331,180 @@ func (s *Sender) generateHTMLBody(failedService health.FailedService) (string, e
         Deployment      health.DeploymentInfo
         FailureReason   string
         PodLogs         string
+        StructuredLogs  []health.LogEntry
+        LogLinks        []health.Link
+        DashboardURL    string
+        RunbookURL      string
+        RunbookSnippet  string
+        RemediationSteps []string
+        NextSteps       *health.NextSteps
         CheckTime       time.Time
         LogTailLines    int
         ClusterName     string
-        SupportEmail    string
-        SlackChannel    string
+        Branding        config.BrandingConfig
+        Classification  string
+        Remediations    []health.RemediationAction
+        Suggestions     []string
+        ChangeFreeze    *health.ChangeFreeze
+        Change          *health.Change
+        Locale          string
+        IncidentID      string
+        AcknowledgedBy  string
+        AcknowledgedAt  time.Time
+        Pods            []health.PodFailure
+        TotalPods       int
+        DebugCommand    string
+        PlatformIssue   bool
+        ArchiveURL      string
+        OwnerBounced    bool
+        UpstreamCause   string
+        Hotspot         *health.Hotspot
+        Drill           bool
+        Restarts        []health.Restart
+        Severity        string
+        Probation       time.Duration
+        RolloutAge      time.Duration
     }{
         Deployment:    failedService.Deployment,
         FailureReason: failedService.FailureReason,
         PodLogs:       failedService.PodLogs,
+        StructuredLogs: failedService.StructuredLogs,
+        LogLinks:      failedService.LogLinks,
+        DashboardURL:  failedService.DashboardURL,
+        RunbookURL:    failedService.RunbookURL,
+        RunbookSnippet: failedService.RunbookSnippet,
+        RemediationSteps: failedService.RemediationSteps,
+        NextSteps:     failedService.NextSteps,
         CheckTime:     failedService.CheckTime,
-        LogTailLines:  50,
-        ClusterName:   "EKS Production",
-        SupportEmail:  "tech.infraengineers@godigit.com",
-        SlackChannel:  "#tech-infra",
+        LogTailLines:  s.logTailLines,
+        ClusterName:   s.clusterName,
+        Branding:      s.branding,
+        Classification: failedService.Classification,
+        Remediations:  failedService.Remediations,
+        Suggestions:   failedService.Suggestions,
+        ChangeFreeze:  failedService.ChangeFreeze,
+        Change:        failedService.Change,
+        Locale:        i18n.Resolve(nil, failedService.Locale),
+        IncidentID:    failedService.IncidentID,
+        AcknowledgedBy: failedService.AcknowledgedBy,
+        AcknowledgedAt: failedService.AcknowledgedAt,
+        Pods:          failedService.Pods,
+        TotalPods:     failedService.TotalPods,
+        DebugCommand:  failedService.DebugCommand,
+        PlatformIssue: health.IsPlatform(failedService.Classification),
+        ArchiveURL:    failedService.ArchiveURL,
+        OwnerBounced:  failedService.OwnerBounced,
+        UpstreamCause: failedService.UpstreamCause,
+        Hotspot:       failedService.Hotspot,
+        Drill:         failedService.Drill,
+        Restarts:      failedService.Restarts,
+        Severity:      failedService.Severity,
+        Probation:     failedService.Probation,
+    }
+    if rollout := failedService.Deployment.LastRollout; !rollout.IsZero() {
+        templateData.RolloutAge = failedService.CheckTime.Sub(rollout).Round(time.Minute)
+    }
+    
+    tmpl, err := localized(s.emailTemplate, templateData.Locale)
+    if err != nil {
+        return "", err
     }
     
     var buf bytes.Buffer
-    if err := s.emailTemplate.Execute(&buf, templateData); err != nil {
+    if err := tmpl.Execute(&buf, templateData); err != nil {
         return "", fmt.Errorf("failed to execute email template: %w", err)
     }
     
     return buf.String(), nil
 }
 
-func (s *Sender) sendEmail(to, cc []string, subject, body string) error {
+// SendAuditReport sends an owner the low-severity best-practice report for
+// their deployments, listing those intentionally stopped separately.
+func (s *Sender) SendAuditReport(owner string, cc []string, reports []health.AuditReport, stopped []health.DeploymentInfo) error {
+    subject := fmt.Sprintf("[INFO] Kubernetes best-practice audit: %d deployment(s) need attention", len(reports))
+    if len(reports) == 0 {
+        subject = fmt.Sprintf("[INFO] Kubernetes best-practice audit: %d deployment(s) intentionally stopped", len(stopped))
+    }
+    
+    templateData := struct {
+        Owner        string
+        Reports      []health.AuditReport
+        Stopped      []health.DeploymentInfo
+        GeneratedAt  time.Time
+        ClusterName  string
+        Branding     config.BrandingConfig
+    }{
+        Owner:        owner,
+        Reports:      reports,
+        Stopped:      stopped,
+        GeneratedAt:  time.Now(),
+        ClusterName:  s.clusterName,
+        Branding:     s.branding,
+    }
+    
+    var buf bytes.Buffer
+    if err := s.auditTemplate.Execute(&buf, templateData); err != nil {
+        return fmt.Errorf("failed to execute audit template: %w", err)
+    }
+    
+    return s.sendEmail([]string{owner}, cc, subject, buf.String(), false, nil)
+}
+
+// SendInfraReport sends cluster-level findings to the infra team.
+func (s *Sender) SendInfraReport(to string, report health.InfraReport, pdbThreshold time.Duration) error {
+    subject := "[INFO] Kubernetes infra report"
+    if report.CircuitBreaker != nil {
+        subject = fmt.Sprintf("[CRITICAL] Notification circuit breaker tripped in %s: %d alert(s) held back", s.clusterName, len(report.CircuitBreaker.Held))
+    } else if len(report.ClusterIncidents) > 0 {
+        subject = fmt.Sprintf("[CRITICAL] Cluster-level incident in %s: %d scope(s) failing", s.clusterName, len(report.ClusterIncidents))
+    } else if len(report.StuckNamespaces) > 0 {
+        subject = fmt.Sprintf("[ACTION REQUIRED] %d namespace(s) stuck Terminating", len(report.StuckNamespaces))
+    } else if len(report.BlockedPDBs) > 0 {
+        subject = fmt.Sprintf("[INFO] Kubernetes infra report: %d PDB(s) blocking node drains", len(report.BlockedPDBs))
+    }
+    
+    templateData := struct {
+        Report       health.InfraReport
+        PDBThreshold time.Duration
+        ClusterName  string
+        Branding     config.BrandingConfig
+    }{
+        Report:       report,
+        PDBThreshold: pdbThreshold,
+        ClusterName:  s.clusterName,
+        Branding:     s.branding,
+    }
+    
+    var buf bytes.Buffer
+    if err := s.infraTemplate.Execute(&buf, templateData); err != nil {
+        return fmt.Errorf("failed to execute infra template: %w", err)
+    }
+    
+    return s.sendEmail([]string{to}, nil, subject, buf.String(), len(report.ClusterIncidents) > 0 || report.CircuitBreaker != nil, nil)
+}
+
+func (s *Sender) sendEmail(to, cc []string, subject, body string, urgent bool, extra map[string]string) error {
     // Prepare email headers
     headers := make(map[string]string)
     headers["From"] = s.config.From
     headers["To"] = to[0]
-    headers["Cc"] = joinEmails(cc)
+    if len(cc) > 0 {
+        headers["Cc"] = joinEmails(cc)
+    }
     headers["Subject"] = subject
     headers["MIME-Version"] = "1.0"
     headers["Content-Type"] = "text/html; charset=UTF-8"
-    headers["X-Priority"] = "1" // High priority
-    headers["X-MSMail-Priority"] = "High"
-    headers["Importance"] = "high"
+    if urgent {
+        headers["X-Priority"] = "1" // High priority
+        headers["X-MSMail-Priority"] = "High"
+        headers["Importance"] = "high"
+    }
+    if s.config.ReplyTo != "" {
+        headers["Reply-To"] = s.config.ReplyTo
+    }
+    for k, v := range extra {
+        headers[k] = v
+    }
     
     // Build message
     var message bytes.Buffer
@@ -158,18 +514,12 @@ func (s *Sender) sendEmail(to, cc []string, subject, body string) error {
     message.WriteString("\r\n")
     message.WriteString(body)
     
-    // Send email via SMTP
-    addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
-    
-    if s.config.NoAuth {
-        // For whitelisted server without auth
-        return smtp.SendMail(addr, nil, s.config.From, append(to, cc...), message.Bytes())
-    } else {
-        // For servers requiring auth (if needed in future)
-        // auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
-        // return smtp.SendMail(addr, auth, s.config.From, append(to, cc...), message.Bytes())
-        return smtp.SendMail(addr, nil, s.config.From, append(to, cc...), message.Bytes())
+    if s.outbox != nil {
+        return s.outbox.Write("email", "eml", message.Bytes())
     }
+    
+    // Send email via SMTP, failing over between relays
+    return s.send(append(to, cc...), message.Bytes())
 }
 
 func joinEmails(emails []string) string {
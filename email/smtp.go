@@ -0,0 +1,147 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/transport"
+)
+
+// How long a single SMTP delivery, including any proxy tunnel, may take.
+const smtpTimeout = 30 * time.Second
+
+// deliver does what smtp.SendMail does, but over a connection from
+// transport.Dial so the relay can sit behind an HTTP proxy, and with custom
+// TLS settings for STARTTLS.
+func deliver(cfg config.SMTPConfig, proxy config.ProxyConfig, tlsConfig *tls.Config, auth smtp.Auth, to []string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), smtpTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(cfg.Host, fmt.Sprint(cfg.Port))
+	conn, err := transport.Dial(ctx, proxy, addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if err := startTLS(c, cfg, tlsConfig); err != nil {
+		return err
+	}
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	// Ask for failure reports, with headers only, if the relay supports DSN
+	dsn := false
+	if cfg.RequestDSN {
+		dsn, _ = c.Extension("DSN")
+	}
+	if dsn {
+		if err := command(c, 250, "MAIL FROM:<%s> RET=HDRS", cfg.From); err != nil {
+			return err
+		}
+	} else if err := c.Mail(cfg.From); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if dsn {
+			if err := command(c, 25, "RCPT TO:<%s> NOTIFY=FAILURE,DELAY", rcpt); err != nil {
+				return err
+			}
+		} else if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// command sends an SMTP command net/smtp has no parameters for and checks
+// that the reply code starts with expect.
+func command(c *smtp.Client, expect int, format string, args ...interface{}) error {
+	id, err := c.Text.Cmd(format, args...)
+	if err != nil {
+		return err
+	}
+	c.Text.StartResponse(id)
+	defer c.Text.EndResponse(id)
+	_, _, err = c.Text.ReadResponse(expect)
+	return err
+}
+
+// startTLS upgrades the connection when the relay offers STARTTLS, and fails
+// if the TLS policy requires it but the relay doesn't.
+func startTLS(c *smtp.Client, cfg config.SMTPConfig, tlsConfig *tls.Config) error {
+	relay := fmt.Sprintf("smtp relay %s", cfg.Host)
+	if ok, _ := c.Extension("STARTTLS"); !ok {
+		if transport.RequireSMTPTLS() {
+			return transport.PolicyError(relay, fmt.Errorf("STARTTLS is not offered"))
+		}
+		return nil
+	}
+
+	conf := &tls.Config{}
+	if tlsConfig != nil {
+		conf = tlsConfig.Clone()
+	}
+	if conf.ServerName == "" {
+		conf.ServerName = cfg.Host
+	}
+	if err := c.StartTLS(conf); err != nil {
+		return transport.PolicyError(relay, err)
+	}
+	return nil
+}
+
+// checkRelay connects to the relay and negotiates TLS without sending mail.
+func checkRelay(cfg config.SMTPConfig, proxy config.ProxyConfig, tlsConfig *tls.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), smtpTimeout)
+	defer cancel()
+
+	conn, err := transport.Dial(ctx, proxy, net.JoinHostPort(cfg.Host, fmt.Sprint(cfg.Port)))
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if err := startTLS(c, cfg, tlsConfig); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,7 @@
+//go:build boringcrypto
+
+package main
+
+// FIPS builds (GOEXPERIMENT=boringcrypto) restrict TLS to FIPS-approved
+// versions, cipher suites and curves, on top of tls_policy.
+import _ "crypto/tls/fipsonly"
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+)
+
+// serveHealth implements
+//
+//	GET /api/v1/health/{ns}/{deployment}
+//
+// for deployment gates and admission webhooks that block changes to failing
+// services. It answers from the last scan kept in memory, never from the
+// cluster, so it is cheap enough to call on every admission; a deployment
+// missing from the last scan is 404.
+func (m *monitor) serveHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	namespace, deployment, err := splitService(strings.TrimPrefix(r.URL.Path, "/api/v1/health/"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !auth.FromContext(r.Context()).CanView(namespace) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	result, ok := m.store.ScanResult(namespace, deployment)
+	if !ok {
+		http.Error(w, "not in the last scan", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// admissionMarkers identify FailedCreate events caused by an admission
+// webhook rather than by the workload itself.
+var admissionMarkers = []string{
+	"admission webhook",
+	"failed calling webhook",
+	"denied the request",
+}
+
+// AdmissionFailure returns the latest message of a FailedCreate event on one
+// of the deployment's ReplicaSets caused by an admission webhook, or "" if
+// there is none.
+func AdmissionFailure(ctx context.Context, client kubernetes.Interface, dep DeploymentInfo) (string, error) {
+	events, err := client.CoreV1().Events(dep.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "reason=FailedCreate,involvedObject.kind=ReplicaSet",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list events: %w", err)
+	}
+
+	var message string
+	var latest metav1.Time
+	for _, event := range events.Items {
+		// ReplicaSets are named <deployment>-<pod-template-hash>
+		if !strings.HasPrefix(event.InvolvedObject.Name, dep.Name+"-") || !isAdmissionFailure(event.Message) {
+			continue
+		}
+		if seen := eventTime(event.LastTimestamp, event.EventTime); message == "" || latest.Before(&seen) {
+			message, latest = event.Message, seen
+		}
+	}
+	return message, nil
+}
+
+func isAdmissionFailure(message string) bool {
+	lower := strings.ToLower(message)
+	for _, marker := range admissionMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func eventTime(last metav1.Time, eventTime metav1.MicroTime) metav1.Time {
+	if !last.IsZero() {
+		return last
+	}
+	return metav1.NewTime(eventTime.Time)
+}
@@ -0,0 +1,228 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// Best-practice checks reported by audit mode.
+const (
+	AuditNoReadinessProbe = "no_readiness_probe"
+	AuditNoResourceLimits = "no_resource_limits"
+	AuditSingleReplica    = "single_replica"
+	AuditLatestTag        = "latest_tag"
+	AuditMissingPDB       = "missing_pdb"
+	AuditBrokenReference  = "broken_reference"
+	AuditServiceAccount   = "service_account"
+	AuditCPUThrottling    = "cpu_throttling"
+	AuditOrphanPods       = "orphan_pods"
+	AuditDigestDrift      = "digest_drift"
+)
+
+// Each finding costs this many points off a perfect score of 100.
+const auditPenalty = 20
+
+type AuditFinding struct {
+	Check   string
+	Message string
+}
+
+// AuditReport lists non-fatal hygiene issues for a single deployment.
+type AuditReport struct {
+	Deployment DeploymentInfo
+	Findings   []AuditFinding
+	Score      int
+	// Intermittent are container crashes that recovered before a scan
+	// could alert on them
+	Intermittent []state.CrashSummary
+	// ImageAdvisories note stale or vulnerable images of a failing
+	// deployment, e.g. "app: image is 14 months old"
+	ImageAdvisories []string
+}
+
+// Auditor scores deployments against best practices. PDBs are cached per
+// namespace, so use a fresh Auditor for every run.
+type Auditor struct {
+	client kubernetes.Interface
+	pdbs   map[string][]policyv1.PodDisruptionBudget
+	refs   *ReferenceChecker
+	// noMetrics is set once metrics-server turns out to be unreachable, so
+	// the run stops asking it
+	noMetrics bool
+}
+
+func NewAuditor(client kubernetes.Interface) *Auditor {
+	return &Auditor{
+		client: client,
+		pdbs:   make(map[string][]policyv1.PodDisruptionBudget),
+		refs:   NewReferenceChecker(client),
+	}
+}
+
+func (a *Auditor) Audit(ctx context.Context, dep DeploymentInfo) (*AuditReport, error) {
+	deployment, err := a.client.AppsV1().Deployments(dep.Namespace).Get(ctx, dep.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment: %w", err)
+	}
+
+	report := &AuditReport{Deployment: dep}
+	add := func(check, format string, args ...interface{}) {
+		report.Findings = append(report.Findings, AuditFinding{
+			Check:   check,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	var noProbe, noLimits, latest []string
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.ReadinessProbe == nil {
+			noProbe = append(noProbe, container.Name)
+		}
+		if container.Resources.Limits.Cpu().IsZero() || container.Resources.Limits.Memory().IsZero() {
+			noLimits = append(noLimits, container.Name)
+		}
+		if usesLatestTag(container.Image) {
+			latest = append(latest, container.Image)
+		}
+	}
+
+	if len(noProbe) > 0 {
+		add(AuditNoReadinessProbe, "No readiness probe on container(s): %s", strings.Join(noProbe, ", "))
+	}
+	if len(noLimits) > 0 {
+		add(AuditNoResourceLimits, "Missing CPU or memory limits on container(s): %s", strings.Join(noLimits, ", "))
+	}
+	if replicas := replicaCount(deployment); replicas == 1 {
+		add(AuditSingleReplica, "Runs a single replica; any pod restart causes downtime")
+	}
+	if len(latest) > 0 {
+		add(AuditLatestTag, "Uses mutable latest tag: %s", strings.Join(latest, ", "))
+	}
+
+	// Caught here, a broken reference is fixed before the next rollout fails
+	// with CreateContainerConfigError
+	problems, err := a.refs.Check(ctx, deployment.Namespace, deployment.Spec.Template.Spec)
+	if err != nil {
+		log.Printf("Warning: %s/%s: %v", dep.Namespace, dep.Name, err)
+	} else if len(problems) > 0 {
+		add(AuditBrokenReference, "Unresolvable ConfigMap/Secret references: %s", strings.Join(problems, "; "))
+	}
+
+	saProblems, err := CheckServiceAccount(ctx, a.client, dep, deployment.Spec.Template.Spec)
+	if err != nil {
+		log.Printf("Warning: %s/%s: %v", dep.Namespace, dep.Name, err)
+	} else if len(saProblems) > 0 {
+		add(AuditServiceAccount, "Service account misconfigured: %s", strings.Join(saProblems, "; "))
+	}
+
+	if !a.noMetrics {
+		throttled, err := CPUThrottling(ctx, a.client, dep, deployment.Spec.Template.Spec)
+		if err != nil {
+			log.Printf("Warning: skipping CPU throttling checks: %v", err)
+			a.noMetrics = true
+		} else if len(throttled) > 0 {
+			add(AuditCPUThrottling, "Heavy CPU throttling, which often shows up as readiness probe failures: %s", strings.Join(throttled, "; "))
+		}
+	}
+
+	owned, orphans, err := a.pods(ctx, dep)
+	if err != nil {
+		log.Printf("Warning: %s/%s: %v", dep.Namespace, dep.Name, err)
+	} else {
+		if len(orphans) > 0 {
+			add(AuditOrphanPods, "Pods matching the selector that the deployment didn't create, which may serve stale code: %s", strings.Join(orphans, ", "))
+		}
+		// A repushed tag or nodes with stale image caches make a bug show
+		// up on some pods only
+		if drift := DigestDrift(deployment.Spec.Template.Spec, owned); len(drift) > 0 {
+			add(AuditDigestDrift, "Pods run different image digests than the deployment declares: %s", strings.Join(drift, "; "))
+		}
+	}
+
+	covered, err := a.hasPDB(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+	if !covered {
+		add(AuditMissingPDB, "No PodDisruptionBudget selects this deployment's pods")
+	}
+
+	report.Score = 100 - auditPenalty*len(report.Findings)
+	if report.Score < 0 {
+		report.Score = 0
+	}
+	return report, nil
+}
+
+func (a *Auditor) hasPDB(ctx context.Context, deployment *appsv1.Deployment) (bool, error) {
+	pdbs, ok := a.pdbs[deployment.Namespace]
+	if !ok {
+		list, err := a.client.PolicyV1().PodDisruptionBudgets(deployment.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to list pod disruption budgets: %w", err)
+		}
+		pdbs = list.Items
+		a.pdbs[deployment.Namespace] = pdbs
+	}
+
+	podLabels := labels.Set(deployment.Spec.Template.Labels)
+	for _, pdb := range pdbs {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		if selector.Matches(podLabels) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// pods returns the pods of a deployment's ReplicaSets, and describes the
+// pods matching its selector that none of them created.
+func (a *Auditor) pods(ctx context.Context, dep DeploymentInfo) ([]corev1.Pod, []string, error) {
+	pods, err := a.client.CoreV1().Pods(dep.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: PodSelector(dep),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	owned, stray, err := SplitOrphans(ctx, a.client, dep, pods.Items)
+	if err != nil {
+		return nil, nil, err
+	}
+	var orphans []string
+	for _, pod := range stray {
+		orphans = append(orphans, describeOrphan(pod))
+	}
+	return owned, orphans, nil
+}
+
+func replicaCount(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
+
+// usesLatestTag reports whether an image is untagged or tagged latest and not
+// pinned by digest.
+func usesLatestTag(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	i := strings.LastIndex(name, ":")
+	return i < 0 || name[i+1:] == "latest"
+}
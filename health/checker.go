@@ -3,9 +3,12 @@ package health
 import (
 	"context"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -15,118 +18,720 @@ type DeploymentInfo struct {
 	Namespace    string
 	OwnerEmail   string
 	OwnerDlEmail string
+	Team         string
+	SlackChannel string
 	Annotations  map[string]string
+	// Labels are the workload object's labels
+	Labels map[string]string
+	// LastRollout is when the deployment last made rollout progress
+	LastRollout time.Time
+	// Revision is the deployment's rollout revision
+	Revision string
+	// Images are the images of the pod template's containers
+	Images []ContainerImage
+	// Stopped says why the deployment was deliberately stopped, e.g. scaled
+	// to zero, or is empty
+	Stopped string
+	// Kind is KindReplicaSet or KindPod for legacy workloads monitored
+	// without a Deployment, and empty for Deployments
+	Kind string
+	// Selector selects the pods of a legacy workload
+	Selector string
+	// Created is when the workload was created
+	Created time.Time
+	// ResourceVersion is the workload object's resourceVersion when scanned
+	ResourceVersion string
+}
+
+// Change describes what a deployment's current rollout changed, from the
+// annotations CI sets on it.
+type Change struct {
+	Version  string
+	Commit   string
+	Pipeline string
+	// Links point at the commit, pipeline, etc.
+	Links []Link
+}
+
+// ContainerImage is the image a container runs.
+type ContainerImage struct {
+	Container string
+	Image     string
 }
 
 type FailedService struct {
-	Deployment    DeploymentInfo
-	FailureReason string
-	PodLogs       string
-	CheckTime     time.Time
+	Deployment     DeploymentInfo
+	FailureReason  string
+	Classification string
+	PodLogs        string
+	StructuredLogs []LogEntry
+	LogLinks       []Link
+	DashboardURL   string
+	RunbookURL     string
+	RunbookSnippet string
+	// RemediationSteps are knowledge base steps for the classification
+	RemediationSteps []string
+	// NextSteps links the wiki page for the classification's remediation
+	// category
+	NextSteps    *NextSteps
+	CheckTime    time.Time
+	Remediations []RemediationAction
+	Suggestions  []string
+	ChangeFreeze *ChangeFreeze
+	// DebugCommand attaches a debug container to the failing pod
+	DebugCommand string
+	// Locale selects the message catalog used to render notifications
+	Locale string
+	// IncidentID correlates this alert with other channels and the recovery
+	IncidentID string
+	// Pods details each failing pod, of TotalPods in the deployment
+	Pods      []PodFailure
+	TotalPods int
+	// FollowUp is set when an earlier alert went out for the same incident
+	FollowUp bool
+	// AcknowledgedBy is whoever already took the incident, so teammates
+	// don't start debugging it independently
+	AcknowledgedBy string
+	AcknowledgedAt time.Time
+	// OwnerBounced is set when mail to the owner address bounced, so the
+	// alert went to the DL instead
+	OwnerBounced bool
+	// Change is what the failing rollout changed, if CI annotated it
+	Change *Change
+	// UpstreamCause is a failing dependency ("namespace/name") that likely
+	// caused this failure
+	UpstreamCause string
+	// Hotspot is set when this service's failing pods share a node, node
+	// group or zone with most of the scan's other failures
+	Hotspot *Hotspot
+	// Drill marks a synthetic failure sent to test notifications
+	Drill bool
+	// Probation is how long a new service must stay stable before its
+	// alerts reach more than the owner; zero once it has been
+	Probation time.Duration
+	// Severity is SeverityWarning for short readiness failures; empty means
+	// critical
+	Severity string
+	// NotReadyFor is how long the container has been NotReady, for
+	// readiness failures
+	NotReadyFor time.Duration
+	// Restarts are the most recent container restarts seen across scans,
+	// newest first
+	Restarts []Restart
+	// ArchiveURL links to the full logs, events and alert in object storage
+	ArchiveURL string
+}
+
+// MaxRestarts caps the restarts listed in an alert.
+const MaxRestarts = 5
+
+// Restart is one or more restarts of a container seen between two scans,
+// described by the last of them.
+type Restart struct {
+	Pod       string
+	Container string
+	Count     int32
+	Reason    string
+	ExitCode  int32
+	At        time.Time
+}
+
+// ExitMeaning explains common exit codes, whose pattern across restarts
+// often points at the cause.
+func (r Restart) ExitMeaning() string {
+	switch r.ExitCode {
+	case 0:
+		return "exited normally"
+	case 1:
+		return "application error"
+	case 137:
+		return "SIGKILL: out of memory or killed by a liveness probe"
+	case 139:
+		return "SIGSEGV: segmentation fault"
+	case 143:
+		return "SIGTERM: stopped by Kubernetes"
+	}
+	return ""
 }
 
+// NextSteps is the wiki page for a failure's remediation category.
+type NextSteps struct {
+	Category string
+	URL      string
+}
+
+// ChangeFreeze is an active change freeze window covering a failure.
+type ChangeFreeze struct {
+	ID          string
+	Description string
+	Start       time.Time
+	End         time.Time
+}
+
+// Link is a named URL rendered into notifications.
+type Link struct {
+	Name string
+	URL  string
+}
+
+// RemediationAction records an automatic action taken against a pod of a
+// failing deployment.
+type RemediationAction struct {
+	Action string
+	Pod    string
+	Reason string
+	Time   time.Time
+	DryRun bool
+	Error  string
+}
+
+// Checker evaluates deployment health from pod status. Logs for failing pods
+// are fetched separately by a LogFetcher.
 type Checker struct {
-	logTailLines int
+	policy Policy
 }
 
 func NewChecker() *Checker {
-	return &Checker{
-		logTailLines: 50,
+	return &Checker{policy: DefaultPolicy()}
+}
+
+// SetPolicy replaces the default thresholds.
+func (c *Checker) SetPolicy(policy Policy) {
+	c.policy = policy
+}
+
+// PodSelectorAnnotation overrides the label selector used to find a
+// deployment's pods, e.g. "release=foo" for pods whose labels an operator
+// manages.
+const PodSelectorAnnotation = "healthcheck/pod-selector"
+
+// CriticalContainersAnnotation names the containers whose failures are
+// critical, e.g. "app,worker". Failures of the others, such as sidecars, are
+// reported as warnings. Without it every container is critical.
+const CriticalContainersAnnotation = "healthcheck/critical-containers"
+
+// criticalContainers returns the containers named by a deployment's
+// CriticalContainersAnnotation, or nil if every container is critical.
+func criticalContainers(dep DeploymentInfo) map[string]bool {
+	var critical map[string]bool
+	for _, name := range strings.Split(dep.Annotations[CriticalContainersAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			if critical == nil {
+				critical = make(map[string]bool)
+			}
+			critical[name] = true
+		}
+	}
+	return critical
+}
+
+// PodSelector returns the label selector used to find a deployment's pods.
+func PodSelector(dep DeploymentInfo) string {
+	if selector := strings.TrimSpace(dep.Annotations[PodSelectorAnnotation]); selector != "" {
+		return selector
+	}
+	if dep.Selector != "" {
+		return dep.Selector
 	}
+	return fmt.Sprintf("app=%s", dep.Name)
 }
 
-func (c *Checker) CheckDeploymentHealth(ctx context.Context, client *kubernetes.Clientset,
-	dep DeploymentInfo) (bool, string, string, error) {
+// ListPods returns a deployment's pods: those PodSelector selects or, for a
+// standalone pod, the pod itself.
+func ListPods(ctx context.Context, client kubernetes.Interface, dep DeploymentInfo) ([]corev1.Pod, error) {
+	if dep.Kind == KindPod {
+		pod, err := client.CoreV1().Pods(dep.Namespace).Get(ctx, dep.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []corev1.Pod{*pod}, nil
+	}
 
-	// Get deployment pods
 	pods, err := client.CoreV1().Pods(dep.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("app=%s", dep.Name),
+		LabelSelector: PodSelector(dep),
 	})
 	if err != nil {
-		return false, "Failed to list pods", "", err
+		return nil, err
 	}
+	return pods.Items, nil
+}
 
-	if len(pods.Items) == 0 {
-		return false, "No pods found for deployment", "", nil
+// MaxPodFailures is the default cap on pods detailed per deployment, and so
+// the log fetches for a large deployment failing as a whole.
+const MaxPodFailures = 10
+
+// CheckResult is the outcome of a single deployment health check. The
+// top-level fields describe the first failing pod; Pods lists each one.
+type CheckResult struct {
+	Healthy        bool
+	FailureReason  string
+	Classification string
+	Namespace      string
+	Pod            string
+	Container      string
+	PodLogs        string
+	// LogFile holds the full logs when they are spilled to disk
+	LogFile string
+	Pods    []PodFailure
+	// TotalPods is how many pods the deployment has, failing or not
+	TotalPods int
+	// Restarts has the restart counter of every container, failing or not,
+	// so restarts between scans can be tracked
+	Restarts []ContainerRestarts
+	// NotReadyFor is how long the failing container has been NotReady, for
+	// readiness failures
+	NotReadyFor time.Duration
+	// Severity is SeverityCritical, or SeverityWarning for a readiness
+	// failure shorter than the policy's NotReadyCritical
+	Severity string
+	// Stopped is set for healthy results of deployments that were
+	// deliberately stopped and so weren't checked
+	Stopped bool
+	// OrphanPods are pods matching the deployment's selector that none of
+	// its ReplicaSets created; they are left out of the check
+	OrphanPods []string
+	// RecheckAt is when a healthy result may stop holding without the pods
+	// changing: the earliest end of a readiness grace period or Terminating
+	// threshold that kept a pod from failing. Zero if there is none.
+	RecheckAt time.Time
+}
+
+// Failure severities.
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+)
+
+// ContainerRestarts is a container's restart counter and how it last
+// terminated.
+type ContainerRestarts struct {
+	Pod       string
+	Container string
+	Count     int32
+	// LastReason, LastExitCode and LastFinishedAt describe the most recent
+	// termination, if any
+	LastReason     string
+	LastExitCode   int32
+	LastFinishedAt time.Time
+}
+
+// PodFailure is the problem found with one pod of a deployment.
+type PodFailure struct {
+	Pod            string
+	Container      string
+	Reason         string
+	Classification string
+	Logs           string
+	LogFile        string
+	// Node is where the pod is scheduled; Zone and NodeGroup are filled in
+	// by a Topology
+	Node      string
+	Zone      string
+	NodeGroup string
+	// NotReadyFor is how long the container has been NotReady, for
+	// readiness failures
+	NotReadyFor time.Duration
+}
+
+func (c *Checker) CheckDeploymentHealth(ctx context.Context, client kubernetes.Interface,
+	dep DeploymentInfo) (*CheckResult, error) {
+
+	// KEDA scales event-driven services to zero by design; what can break
+	// is the ScaledObject that would scale them back up
+	if dep.Stopped == StoppedScaledToZero && dep.Kind == "" {
+		so, err := FindScaledObject(ctx, client, dep)
+		if err != nil {
+			log.Printf("Warning: %s/%s: %v", dep.Namespace, dep.Name, err)
+		}
+		if so != nil {
+			return checkScaledObject(ctx, client, dep, so), nil
+		}
 	}
 
-	// Check each pod
-	for _, pod := range pods.Items {
-		// Check pod status
-		if pod.Status.Phase != corev1.PodRunning {
-			return false,
-				fmt.Sprintf("Pod %s is not running (status: %s)", pod.Name, pod.Status.Phase),
-				c.getPodLogs(ctx, client, pod),
-				nil
+	// Scaled to zero or paused on purpose; "No pods found" would be noise
+	if dep.Stopped != "" && !c.policy.CheckStopped {
+		return &CheckResult{
+			Healthy:        true,
+			Stopped:        true,
+			FailureReason:  "Intentionally stopped: " + dep.Stopped,
+			Classification: ClassStopped,
+		}, nil
+	}
+
+	// Get deployment pods
+	pods, err := ListPods(ctx, client, dep)
+	if err != nil {
+		return &CheckResult{FailureReason: "Failed to list pods"}, err
+	}
+
+	// Pods the deployment's ReplicaSets didn't create would skew the result
+	var orphans []string
+	if len(pods) > 0 && dep.Kind == "" {
+		owned, stray, err := SplitOrphans(ctx, client, dep, pods)
+		if err != nil {
+			log.Printf("Warning: %s/%s: %v", dep.Namespace, dep.Name, err)
+		}
+		pods = owned
+		for _, pod := range stray {
+			orphans = append(orphans, describeOrphan(pod))
 		}
+	}
 
-		// Check container statuses
-		for _, container := range pod.Status.ContainerStatuses {
-			if container.State.Waiting != nil {
-				return false,
-					fmt.Sprintf("Container %s is waiting: %s",
-						container.Name, container.State.Waiting.Reason),
-					c.getPodLogs(ctx, client, pod),
-					nil
-			}
+	if len(pods) == 0 {
+		// A failing admission webhook blocks pod creation for every team, so
+		// it's a platform issue rather than the owner's
+		message, err := AdmissionFailure(ctx, client, dep)
+		if err != nil {
+			log.Printf("Warning: %s/%s: %v", dep.Namespace, dep.Name, err)
+		}
+		if message != "" {
+			return &CheckResult{
+				FailureReason:  "Pods can't be created: " + message,
+				Classification: ClassAdmissionWebhook,
+			}, nil
+		}
+		reason := "No pods found for deployment"
+		if len(orphans) > 0 {
+			reason = fmt.Sprintf("No pods found for deployment; orphaned pod(s) match its selector: %s", strings.Join(orphans, ", "))
+		}
+		return &CheckResult{
+			FailureReason:  reason,
+			Classification: ClassNoPods,
+			OrphanPods:     orphans,
+		}, nil
+	}
 
-			if container.State.Terminated != nil {
-				return false,
-					fmt.Sprintf("Container %s terminated: %s (exit code: %d)",
-						container.Name, container.State.Terminated.Reason,
-						container.State.Terminated.ExitCode),
-					c.getPodLogs(ctx, client, pod),
-					nil
-			}
+	// Check every pod so partial failures report each affected pod
+	var result *CheckResult
+	var failures []PodFailure
+	var pending *corev1.Pod
+	critical := criticalContainers(dep)
+	for i, pod := range pods {
+		failed := c.checkPod(pod, critical)
+		if failed == nil {
+			continue
+		}
+		if failed.Classification == ClassUnschedulable && pending == nil {
+			pending = &pods[i]
+		}
+		// A critical failure outranks a sidecar's in the summary
+		if result == nil || (result.Severity == SeverityWarning && failed.Severity != SeverityWarning) {
+			result = failed
+		}
+		if len(failures) < c.policy.MaxPodFailures {
+			failures = append(failures, PodFailure{
+				Pod:            failed.Pod,
+				Container:      failed.Container,
+				Reason:         failed.FailureReason,
+				Classification: failed.Classification,
+				Node:           pod.Spec.NodeName,
+				NotReadyFor:    failed.NotReadyFor,
+			})
+		}
+	}
+
+	if result == nil {
+		result = &CheckResult{Healthy: true}
+	}
+	result.Pods = failures
+	result.TotalPods = len(pods)
+	result.Restarts = restarts(pods)
+	result.OrphanPods = orphans
+	if result.Healthy {
+		result.RecheckAt = c.recheckAt(pods, time.Now())
+		return result, nil
+	}
+
+	// A readiness failure of unknown duration is treated as critical, and
+	// one of a non-critical container is already a warning
+	if result.Severity == "" {
+		result.Severity = SeverityCritical
+	}
+	if result.Classification == ClassNotReady && result.NotReadyFor > 0 && result.NotReadyFor < c.policy.NotReadyCritical {
+		result.Severity = SeverityWarning
+	}
+
+	switch result.Classification {
+	case ClassConfigError, ClassInitConfigError:
+		c.explainConfigError(ctx, client, result, pods)
+	case ClassCrashLoop, ClassFrequentRestarts, ClassTerminated, ClassNotReady:
+		c.explainLivenessKills(ctx, client, result, pods)
+	}
 
-			if !container.Ready {
-				// Check if there's a readiness probe failure
-				if container.LastTerminationState.Terminated != nil {
-					return false,
-						fmt.Sprintf("Container %s not ready (last termination: %s)",
-							container.Name, container.LastTerminationState.Terminated.Reason),
-						c.getPodLogs(ctx, client, pod),
-						nil
+	// The scheduler's message only counts nodes; say which constraint fails.
+	// Pods of a deployment share a spec, so one explanation covers them all.
+	if pending != nil {
+		explanation, err := ExplainScheduling(ctx, client, *pending)
+		if err != nil {
+			log.Printf("Warning: %s/%s: %v", dep.Namespace, dep.Name, err)
+		} else if explanation != "" {
+			for i := range result.Pods {
+				if result.Pods[i].Classification == ClassUnschedulable {
+					result.Pods[i].Reason = fmt.Sprintf("Pod %s is unschedulable: %s", result.Pods[i].Pod, explanation)
 				}
-				return false,
-					fmt.Sprintf("Container %s not ready", container.Name),
-					c.getPodLogs(ctx, client, pod),
-					nil
 			}
+			if result.Classification == ClassUnschedulable {
+				result.FailureReason = fmt.Sprintf("Pod %s is unschedulable: %s", result.Pod, explanation)
+			}
+		}
+	}
+	return result, nil
+}
+
+// explainConfigError appends the ConfigMap and Secret references that don't
+// resolve to a CreateContainerConfigError, naming the exact object and key.
+func (c *Checker) explainConfigError(ctx context.Context, client kubernetes.Interface, result *CheckResult, pods []corev1.Pod) {
+	for _, pod := range pods {
+		if pod.Name != result.Pod {
+			continue
+		}
+		problems, err := NewReferenceChecker(client).Check(ctx, pod.Namespace, pod.Spec)
+		if err != nil {
+			log.Printf("Warning: %s/%s: %v", pod.Namespace, pod.Name, err)
+			return
+		}
+		if len(problems) == 0 {
+			return
 		}
 
-		// Check for recent restarts
+		detail := " (" + strings.Join(problems, "; ") + ")"
+		result.FailureReason += detail
+		for i := range result.Pods {
+			if result.Pods[i].Classification == result.Classification {
+				result.Pods[i].Reason += detail
+			}
+		}
+		return
+	}
+}
+
+// restarts returns the restart counters of the pods' containers.
+func restarts(pods []corev1.Pod) []ContainerRestarts {
+	var counts []ContainerRestarts
+	for _, pod := range pods {
 		for _, container := range pod.Status.ContainerStatuses {
-			if container.RestartCount > 3 {
-				return false,
-					fmt.Sprintf("Container %s restarted %d times (possible crash loop)",
-						container.Name, container.RestartCount),
-					c.getPodLogs(ctx, client, pod),
-					nil
+			count := ContainerRestarts{
+				Pod:       pod.Name,
+				Container: container.Name,
+				Count:     container.RestartCount,
 			}
+			if last := container.LastTerminationState.Terminated; last != nil {
+				count.LastReason = last.Reason
+				count.LastExitCode = last.ExitCode
+				count.LastFinishedAt = last.FinishedAt.Time
+			}
+			counts = append(counts, count)
 		}
 	}
+	return counts
+}
+
+// checkPod returns the first problem found with a pod, or nil if it's healthy.
+// Problems with containers not in critical, unless it is nil, are only
+// returned, as warnings, when no critical container has one; init containers
+// and the pod itself are always critical.
+func (c *Checker) checkPod(pod corev1.Pod, critical map[string]bool) *CheckResult {
+	if pod.DeletionTimestamp != nil {
+		return c.checkTerminating(pod)
+	}
 
-	return true, "", "", nil
+	// Check pod status
+	if cond := unschedulable(pod); pod.Status.Phase == corev1.PodPending && cond != nil {
+		return c.failure(pod, "", ClassUnschedulable,
+			fmt.Sprintf("Pod %s is unschedulable: %s", pod.Name, cond.Message))
+	}
+	if failed := c.checkInitContainers(pod); failed != nil {
+		return failed
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return c.failure(pod, "", ClassPodNotRunning,
+			fmt.Sprintf("Pod %s is not running (status: %s)", pod.Name, pod.Status.Phase))
+	}
+
+	var sidecar *CheckResult
+	check := func(container string, failed *CheckResult) *CheckResult {
+		if failed == nil || critical == nil || critical[container] {
+			return failed
+		}
+		if sidecar == nil {
+			sidecar = failed
+			sidecar.Severity = SeverityWarning
+		}
+		return nil
+	}
+
+	// Check container statuses
+	for _, container := range pod.Status.ContainerStatuses {
+		if failed := check(container.Name, c.checkContainer(pod, container)); failed != nil {
+			return failed
+		}
+	}
+
+	// Check for recent restarts
+	for _, container := range pod.Status.ContainerStatuses {
+		if container.RestartCount > c.policy.RestartThreshold {
+			class := ClassFrequentRestarts
+			if last := container.LastTerminationState.Terminated; last != nil && last.Reason == "OOMKilled" {
+				class = ClassOOMKilled
+			}
+			failed := c.failure(pod, container.Name, class,
+				fmt.Sprintf("Container %s restarted %d times (possible crash loop)",
+					container.Name, container.RestartCount))
+			if failed = check(container.Name, failed); failed != nil {
+				return failed
+			}
+		}
+	}
+
+	return sidecar
 }
 
-func (c *Checker) getPodLogs(ctx context.Context, client *kubernetes.Clientset,
-	pod corev1.Pod) string {
+// checkContainer returns the problem with a container's current state, or
+// nil if it's running and ready or still within its readiness grace period.
+func (c *Checker) checkContainer(pod corev1.Pod, container corev1.ContainerStatus) *CheckResult {
+	if container.State.Waiting != nil {
+		return c.failure(pod, container.Name, classifyWaiting(container),
+			fmt.Sprintf("Container %s is waiting: %s",
+				container.Name, container.State.Waiting.Reason))
+	}
 
-	if len(pod.Spec.Containers) == 0 {
-		return "No containers in pod"
+	if container.State.Terminated != nil {
+		return c.failure(pod, container.Name, classifyTerminated(container.State.Terminated),
+			fmt.Sprintf("Container %s terminated: %s (exit code: %d)",
+				container.Name, container.State.Terminated.Reason,
+				container.State.Terminated.ExitCode))
 	}
 
-	containerName := pod.Spec.Containers[0].Name
-	logOptions := &corev1.PodLogOptions{
-		Container: containerName,
-		TailLines: func(i int) *int64 { v := int64(i); return &v }(c.logTailLines),
+	if !container.Ready {
+		// Check if there's a readiness probe failure
+		if container.LastTerminationState.Terminated != nil {
+			return c.failure(pod, container.Name, classifyTerminated(container.LastTerminationState.Terminated),
+				fmt.Sprintf("Container %s not ready (last termination: %s)",
+					container.Name, container.LastTerminationState.Terminated.Reason))
+		}
+		notReadyFor, known := notReadyDuration(pod, container, time.Now())
+		if !known {
+			return c.failure(pod, container.Name, ClassNotReady,
+				fmt.Sprintf("Container %s not ready", container.Name))
+		}
+		// A container that only just started may still be warming up
+		if notReadyFor < c.policy.NotReadyGrace {
+			return nil
+		}
+		failed := c.failure(pod, container.Name, ClassNotReady,
+			fmt.Sprintf("Container %s not ready for %s", container.Name, notReadyFor.Round(time.Second)))
+		failed.NotReadyFor = notReadyFor
+		return failed
 	}
+	return nil
+}
 
-	logs, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, logOptions).Do(ctx).Raw()
-	if err != nil {
-		return fmt.Sprintf("Failed to get logs: %v", err)
+// checkTerminating reports a pod still Terminating StuckTerminating after its
+// grace period ended, usually because a finalizer or a volume detach can't
+// complete. Until then it's skipped: a terminating pod's containers going
+// NotReady is expected, and its replacement is checked on its own.
+func (c *Checker) checkTerminating(pod corev1.Pod) *CheckResult {
+	// The deletion timestamp is when the grace period ends
+	overdue := time.Since(pod.DeletionTimestamp.Time)
+	if overdue < c.policy.StuckTerminating {
+		return nil
+	}
+
+	reason := fmt.Sprintf("Pod %s stuck Terminating for %s past its grace period", pod.Name, overdue.Round(time.Second))
+	if len(pod.Finalizers) > 0 {
+		reason += fmt.Sprintf(" (finalizers: %s)", strings.Join(pod.Finalizers, ", "))
+	}
+	if pod.Spec.NodeName != "" {
+		reason += " on node " + pod.Spec.NodeName
+	}
+	return c.failure(pod, "", ClassStuckTerminating, reason)
+}
+
+// recheckAt returns when the earliest grace period that kept one of pods from
+// failing ends, or zero if none did.
+func (c *Checker) recheckAt(pods []corev1.Pod, now time.Time) time.Time {
+	var earliest time.Time
+	until := func(at time.Time) {
+		if earliest.IsZero() || at.Before(earliest) {
+			earliest = at
+		}
+	}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			if overdue := now.Sub(pod.DeletionTimestamp.Time); overdue < c.policy.StuckTerminating {
+				until(pod.DeletionTimestamp.Add(c.policy.StuckTerminating))
+			}
+			continue
+		}
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, container := range pod.Status.ContainerStatuses {
+			if container.Ready || container.State.Waiting != nil || container.State.Terminated != nil ||
+				container.LastTerminationState.Terminated != nil {
+				continue
+			}
+			if notReadyFor, known := notReadyDuration(pod, container, now); known && notReadyFor < c.policy.NotReadyGrace {
+				until(now.Add(c.policy.NotReadyGrace - notReadyFor))
+			}
+		}
+	}
+	return earliest
+}
+
+// notReadyDuration returns how long a container has been NotReady: since the
+// pod's Ready condition last turned false, or since the container started if
+// that is later. It returns false if the pod's status doesn't say.
+func notReadyDuration(pod corev1.Pod, container corev1.ContainerStatus, now time.Time) (time.Duration, bool) {
+	var since time.Time
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady && cond.Status != corev1.ConditionTrue {
+			since = cond.LastTransitionTime.Time
+		}
+	}
+	if running := container.State.Running; running != nil && running.StartedAt.After(since) {
+		since = running.StartedAt.Time
+	}
+	if since.IsZero() {
+		return 0, false
+	}
+	return now.Sub(since), true
+}
+
+// checkInitContainers reports an init container that is crash looping,
+// failed, or can't start, which would otherwise only show as a Pending pod.
+// Init containers still running or waiting their turn are not failures.
+func (c *Checker) checkInitContainers(pod corev1.Pod) *CheckResult {
+	for _, container := range pod.Status.InitContainerStatuses {
+		if waiting := container.State.Waiting; waiting != nil && waiting.Reason != "PodInitializing" {
+			return c.failure(pod, container.Name, classifyInitWaiting(container),
+				fmt.Sprintf("Init container %s is waiting: %s", container.Name, waiting.Reason))
+		}
+
+		if terminated := container.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			return c.failure(pod, container.Name, ClassInitCrashLoop,
+				fmt.Sprintf("Init container %s failed: %s (exit code: %d)",
+					container.Name, terminated.Reason, terminated.ExitCode))
+		}
+	}
+	return nil
+}
+
+// failure builds a failed result. Pod-level failures use the pod's first
+// container for logs.
+func (c *Checker) failure(pod corev1.Pod, container, class, reason string) *CheckResult {
+	if container == "" && len(pod.Spec.Containers) > 0 {
+		container = pod.Spec.Containers[0].Name
 	}
 
-	return string(logs)
+	return &CheckResult{
+		FailureReason:  reason,
+		Classification: class,
+		Namespace:      pod.Namespace,
+		Pod:            pod.Name,
+		Container:      container,
+	}
 }
@@ -0,0 +1,121 @@
+package health
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Failure classifications attached to check results.
+const (
+	ClassNoPods           = "no_pods"
+	ClassPodNotRunning    = "pod_not_running"
+	ClassUnschedulable    = "unschedulable"
+	ClassCrashLoop        = "crash_loop"
+	ClassImagePull        = "image_pull"
+	ClassConfigError      = "config_error"
+	ClassContainerWaiting = "container_waiting"
+	ClassInitCrashLoop    = "init_crash_loop"
+	ClassInitConfigError  = "init_config_error"
+	ClassInitWaiting      = "init_waiting"
+	ClassOOMKilled        = "oom_killed"
+	ClassTerminated       = "container_terminated"
+	ClassNotReady         = "not_ready"
+	ClassFrequentRestarts = "frequent_restarts"
+	ClassLivenessProbe    = "liveness_probe"
+	ClassCPUThrottling    = "cpu_throttling"
+	ClassHPAAtMax         = "hpa_at_max"
+	ClassAdmissionWebhook = "admission_webhook"
+	ClassStuckTerminating = "stuck_terminating"
+	ClassStopped          = "intentionally_stopped"
+	ClassScaledObject     = "scaled_object"
+	ClassExternal         = "external"
+)
+
+// Remediation categories group classifications by what fixes them, for
+// linking alerts to a wiki page per category.
+const (
+	CategoryCrashes       = "crashes"
+	CategoryResources     = "resources"
+	CategoryImages        = "images"
+	CategoryConfiguration = "configuration"
+	CategoryScheduling    = "scheduling"
+	CategoryProbes        = "probes"
+	CategoryPlatform      = "platform"
+	CategoryScaling       = "scaling"
+	CategoryExternal      = "external"
+)
+
+// Category returns the remediation category of a classification, or "" for
+// classifications that aren't failures.
+func Category(class string) string {
+	switch class {
+	case ClassCrashLoop, ClassInitCrashLoop, ClassTerminated, ClassFrequentRestarts:
+		return CategoryCrashes
+	case ClassOOMKilled, ClassCPUThrottling:
+		return CategoryResources
+	case ClassImagePull:
+		return CategoryImages
+	case ClassConfigError, ClassInitConfigError:
+		return CategoryConfiguration
+	case ClassUnschedulable, ClassPodNotRunning, ClassContainerWaiting, ClassInitWaiting:
+		return CategoryScheduling
+	case ClassNotReady, ClassLivenessProbe:
+		return CategoryProbes
+	case ClassAdmissionWebhook, ClassStuckTerminating:
+		return CategoryPlatform
+	case ClassNoPods, ClassHPAAtMax, ClassScaledObject:
+		return CategoryScaling
+	case ClassExternal:
+		return CategoryExternal
+	}
+	return ""
+}
+
+// IsPlatform reports whether a classification points at the cluster platform
+// rather than the workload, so the infra team owns the fix.
+func IsPlatform(class string) bool {
+	return class == ClassAdmissionWebhook || class == ClassStuckTerminating
+}
+
+// IsSaturation reports whether a classification points at resource pressure
+// rather than an application fault.
+func IsSaturation(class string) bool {
+	switch class {
+	case ClassOOMKilled, ClassCPUThrottling, ClassHPAAtMax:
+		return true
+	}
+	return false
+}
+
+func classifyWaiting(container corev1.ContainerStatus) string {
+	switch container.State.Waiting.Reason {
+	case "CrashLoopBackOff":
+		if last := container.LastTerminationState.Terminated; last != nil && last.Reason == "OOMKilled" {
+			return ClassOOMKilled
+		}
+		return ClassCrashLoop
+	case "ImagePullBackOff", "ErrImagePull", "InvalidImageName":
+		return ClassImagePull
+	case "CreateContainerConfigError", "CreateContainerError":
+		return ClassConfigError
+	}
+	return ClassContainerWaiting
+}
+
+func classifyInitWaiting(container corev1.ContainerStatus) string {
+	switch container.State.Waiting.Reason {
+	case "CrashLoopBackOff":
+		return ClassInitCrashLoop
+	case "ImagePullBackOff", "ErrImagePull", "InvalidImageName":
+		return ClassImagePull
+	case "CreateContainerConfigError", "CreateContainerError":
+		return ClassInitConfigError
+	}
+	return ClassInitWaiting
+}
+
+func classifyTerminated(state *corev1.ContainerStateTerminated) string {
+	if state.Reason == "OOMKilled" {
+		return ClassOOMKilled
+	}
+	return ClassTerminated
+}
@@ -0,0 +1,38 @@
+package health
+
+import (
+	"strings"
+)
+
+// DependsOnAnnotation lists the services a deployment depends on, comma
+// separated, e.g. "payments/db-proxy, cache". Names without a namespace are
+// in the deployment's own namespace.
+const DependsOnAnnotation = "depends_on"
+
+// Dependencies returns the "namespace/name" keys of the services dep depends
+// on, from its annotation and the configured graph (keyed the same way).
+func Dependencies(dep DeploymentInfo, graph map[string][]string) []string {
+	var deps []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return
+		}
+		if !strings.Contains(name, "/") {
+			name = dep.Namespace + "/" + name
+		}
+		if !seen[name] && name != dep.Namespace+"/"+dep.Name {
+			seen[name] = true
+			deps = append(deps, name)
+		}
+	}
+
+	for _, name := range strings.Split(dep.Annotations[DependsOnAnnotation], ",") {
+		add(name)
+	}
+	for _, name := range graph[dep.Namespace+"/"+dep.Name] {
+		add(name)
+	}
+	return deps
+}
@@ -0,0 +1,127 @@
+package health
+
+import (
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// RevisionAnnotation is set by the deployment controller on every rollout.
+const RevisionAnnotation = "deployment.kubernetes.io/revision"
+
+// Reasons a workload is deliberately stopped.
+const (
+	StoppedScaledToZero = "scaled to 0 replicas"
+	StoppedPaused       = "rollout paused"
+)
+
+// Kinds of legacy workload monitored without a Deployment.
+const (
+	KindReplicaSet = "ReplicaSet"
+	KindPod        = "Pod"
+)
+
+// NewDeploymentInfo describes a deployment from its own annotations. Owners
+// not annotated are left empty for an OwnerResolver to fill in.
+func NewDeploymentInfo(dep appsv1.Deployment) DeploymentInfo {
+	annotations := dep.GetAnnotations()
+	return DeploymentInfo{
+		Name:            dep.Name,
+		Namespace:       dep.Namespace,
+		OwnerEmail:      annotations[OwnerAnnotation],
+		OwnerDlEmail:    annotations[OwnerDLAnnotation],
+		Team:            annotations[TeamAnnotation],
+		Annotations:     annotations,
+		Labels:          dep.GetLabels(),
+		LastRollout:     lastRollout(dep),
+		Revision:        annotations[RevisionAnnotation],
+		Images:          images(dep.Spec.Template.Spec),
+		Stopped:         stopped(dep),
+		Created:         dep.CreationTimestamp.Time,
+		ResourceVersion: dep.ResourceVersion,
+	}
+}
+
+// NewReplicaSetInfo describes a ReplicaSet no Deployment manages, from its
+// own annotations.
+func NewReplicaSetInfo(rs appsv1.ReplicaSet) DeploymentInfo {
+	annotations := rs.GetAnnotations()
+	info := DeploymentInfo{
+		Name:            rs.Name,
+		Namespace:       rs.Namespace,
+		OwnerEmail:      annotations[OwnerAnnotation],
+		OwnerDlEmail:    annotations[OwnerDLAnnotation],
+		Team:            annotations[TeamAnnotation],
+		Annotations:     annotations,
+		Labels:          rs.GetLabels(),
+		Images:          images(rs.Spec.Template.Spec),
+		Kind:            KindReplicaSet,
+		Created:         rs.CreationTimestamp.Time,
+		ResourceVersion: rs.ResourceVersion,
+	}
+	if selector, err := metav1.LabelSelectorAsSelector(rs.Spec.Selector); err == nil && !selector.Empty() {
+		info.Selector = selector.String()
+	}
+	if rs.Spec.Replicas != nil && *rs.Spec.Replicas == 0 {
+		info.Stopped = StoppedScaledToZero
+	}
+	return info
+}
+
+// NewPodInfo describes a pod no controller manages, from its own
+// annotations.
+func NewPodInfo(pod corev1.Pod) DeploymentInfo {
+	annotations := pod.GetAnnotations()
+	info := DeploymentInfo{
+		Name:            pod.Name,
+		Namespace:       pod.Namespace,
+		OwnerEmail:      annotations[OwnerAnnotation],
+		OwnerDlEmail:    annotations[OwnerDLAnnotation],
+		Team:            annotations[TeamAnnotation],
+		Annotations:     annotations,
+		Labels:          pod.GetLabels(),
+		Images:          images(pod.Spec),
+		Kind:            KindPod,
+		Created:         pod.CreationTimestamp.Time,
+		ResourceVersion: pod.ResourceVersion,
+	}
+	if len(pod.Labels) > 0 {
+		info.Selector = labels.SelectorFromSet(pod.Labels).String()
+	}
+	return info
+}
+
+// stopped says why a deployment was deliberately stopped: scaled to zero
+// replicas or its rollout paused. It returns "" for running deployments.
+func stopped(dep appsv1.Deployment) string {
+	switch {
+	case dep.Spec.Replicas != nil && *dep.Spec.Replicas == 0:
+		return StoppedScaledToZero
+	case dep.Spec.Paused:
+		return StoppedPaused
+	}
+	return ""
+}
+
+// images returns the images of a pod spec, init containers first.
+func images(spec corev1.PodSpec) []ContainerImage {
+	var images []ContainerImage
+	for _, c := range append(append([]corev1.Container(nil), spec.InitContainers...), spec.Containers...) {
+		images = append(images, ContainerImage{Container: c.Name, Image: c.Image})
+	}
+	return images
+}
+
+// lastRollout returns when the Progressing condition last changed, which is
+// when the latest rollout progressed or completed.
+func lastRollout(dep appsv1.Deployment) time.Time {
+	for _, cond := range dep.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing {
+			return cond.LastUpdateTime.Time
+		}
+	}
+	return time.Time{}
+}
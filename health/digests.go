@@ -0,0 +1,106 @@
+package health
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DigestDrift describes the containers whose pods don't all run the image
+// digest the pod template declares: a digest other than the one it pins, or,
+// for a tag, more than one digest, e.g. after the tag was repushed and only
+// some nodes pulled it again. Pods still on an older template during a
+// rollout are left out.
+func DigestDrift(spec corev1.PodSpec, pods []corev1.Pod) []string {
+	var drift []string
+	for _, container := range spec.Containers {
+		// digest -> nodes running it
+		nodes := make(map[string][]string)
+		for _, pod := range pods {
+			if !runsImage(pod, container.Name, container.Image) {
+				continue
+			}
+			for _, status := range pod.Status.ContainerStatuses {
+				if digest := imageDigest(status.ImageID); status.Name == container.Name && digest != "" {
+					nodes[digest] = append(nodes[digest], pod.Spec.NodeName)
+				}
+			}
+		}
+
+		pinned := imageDigest(container.Image)
+		if len(nodes) == 0 || (len(nodes) == 1 && (pinned == "" || len(nodes[pinned]) > 0)) {
+			continue
+		}
+
+		digests := make([]string, 0, len(nodes))
+		for digest := range nodes {
+			digests = append(digests, digest)
+		}
+		// Most common first
+		sort.Slice(digests, func(i, j int) bool {
+			if len(nodes[digests[i]]) != len(nodes[digests[j]]) {
+				return len(nodes[digests[i]]) > len(nodes[digests[j]])
+			}
+			return digests[i] < digests[j]
+		})
+		var runs []string
+		for _, digest := range digests {
+			runs = append(runs, fmt.Sprintf("%s on %d pod(s) (%s)", shortDigest(digest), len(nodes[digest]), strings.Join(uniqueNodes(nodes[digest]), ", ")))
+		}
+		if pinned != "" {
+			drift = append(drift, fmt.Sprintf("%s pins %s but runs %s", container.Name, shortDigest(pinned), strings.Join(runs, ", ")))
+		} else {
+			drift = append(drift, fmt.Sprintf("%s runs %s as %d digests: %s", container.Name, container.Image, len(digests), strings.Join(runs, ", ")))
+		}
+	}
+	return drift
+}
+
+// runsImage reports whether a pod's spec runs image in the named container.
+func runsImage(pod corev1.Pod, container, image string) bool {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == container {
+			return c.Image == image
+		}
+	}
+	return false
+}
+
+// imageDigest returns the digest of an image reference or a container
+// status imageID such as "docker-pullable://nginx@sha256:...", or "" if it
+// isn't pinned by one. Bare image IDs identify the local image, not the
+// pushed manifest, so they are ignored.
+func imageDigest(ref string) string {
+	i := strings.LastIndex(ref, "@")
+	if i < 0 {
+		return ""
+	}
+	return ref[i+1:]
+}
+
+// shortDigest abbreviates a digest the way registries' UIs do.
+func shortDigest(digest string) string {
+	algorithm, hex, ok := strings.Cut(digest, ":")
+	if !ok || len(hex) <= 12 {
+		return digest
+	}
+	return algorithm + ":" + hex[:12]
+}
+
+func uniqueNodes(nodes []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, node := range nodes {
+		if node == "" {
+			node = "unscheduled"
+		}
+		if !seen[node] {
+			seen[node] = true
+			unique = append(unique, node)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,53 @@
+package health
+
+import (
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// StuckNamespace is a namespace that has been Terminating, usually because
+// finalizers can't complete.
+type StuckNamespace struct {
+	Name            string
+	DeletionStarted time.Time
+	TerminatingFor  time.Duration
+	Finalizers      []string
+	Conditions      []string
+}
+
+// InfraReport collects cluster-level findings for the infra team.
+type InfraReport struct {
+	GeneratedAt     time.Time
+	BlockedPDBs     []PDBFinding
+	StuckNamespaces []StuckNamespace
+	// ClusterIncidents replace the per-owner alerts of the services in them
+	ClusterIncidents []ClusterIncident
+	// CircuitBreaker is set when too many alerts went out at once
+	CircuitBreaker *BreakerTrip
+	// TopOffenders and Heatmap summarize failures over Window
+	TopOffenders []state.Offender
+	Heatmap      state.Heatmap
+	Window       time.Duration
+}
+
+// BreakerTrip describes a tripped notification circuit breaker.
+type BreakerTrip struct {
+	TrippedAt time.Time
+	Threshold int
+	Window    time.Duration
+	// Held are the services ("namespace/name") whose alerts were held back
+	Held []string
+}
+
+// ClusterIncident is a failure too widespread to be any one owner's problem,
+// e.g. most of the cluster or a whole namespace failing in one scan.
+type ClusterIncident struct {
+	// Namespace is empty when the incident spans the cluster
+	Namespace string
+	Failed    int
+	Total     int
+	// Classifications counts the failures by classification
+	Classifications map[string]int
+	Services        []string
+}
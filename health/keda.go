@@ -0,0 +1,138 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const kedaAPI = "/apis/keda.sh/v1alpha1"
+
+// ScaledObject is the part of a KEDA ScaledObject the checker reads.
+type ScaledObject struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		ScaleTargetRef struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"scaleTargetRef"`
+		Triggers []struct {
+			Type              string `json:"type"`
+			AuthenticationRef *struct {
+				Name string `json:"name"`
+				Kind string `json:"kind"`
+			} `json:"authenticationRef"`
+		} `json:"triggers"`
+	} `json:"spec"`
+	Status struct {
+		Conditions []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// FindScaledObject returns the KEDA ScaledObject that scales a deployment, or
+// nil if there is none or KEDA isn't installed.
+func FindScaledObject(ctx context.Context, clientset kubernetes.Interface, dep DeploymentInfo) (*ScaledObject, error) {
+	client, ok := kedaClient(clientset)
+	if !ok {
+		return nil, nil
+	}
+	raw, err := client.Get().AbsPath(kedaAPI, "namespaces", dep.Namespace, "scaledobjects").DoRaw(ctx)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scaled objects: %w", err)
+	}
+
+	var list struct {
+		Items []ScaledObject `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode scaled objects: %w", err)
+	}
+	for i, so := range list.Items {
+		target := so.Spec.ScaleTargetRef
+		if target.Name == dep.Name && (target.Kind == "" || target.Kind == "Deployment") {
+			return &list.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// ScaledObjectProblems returns why a ScaledObject can't scale its target back
+// up: a Ready condition that isn't True, or trigger authentication that
+// doesn't exist.
+func ScaledObjectProblems(ctx context.Context, clientset kubernetes.Interface, so *ScaledObject) ([]string, error) {
+	var problems []string
+	for _, cond := range so.Status.Conditions {
+		if cond.Type == "Ready" && cond.Status != "True" {
+			problems = append(problems, fmt.Sprintf("not ready: %s", strings.TrimSpace(cond.Reason+" "+cond.Message)))
+		}
+	}
+
+	client, ok := kedaClient(clientset)
+	if !ok {
+		return problems, nil
+	}
+	checked := make(map[string]bool)
+	for _, trigger := range so.Spec.Triggers {
+		ref := trigger.AuthenticationRef
+		if ref == nil || checked[ref.Kind+"/"+ref.Name] {
+			continue
+		}
+		checked[ref.Kind+"/"+ref.Name] = true
+
+		path := []string{kedaAPI, "namespaces", so.Metadata.Namespace, "triggerauthentications", ref.Name}
+		kind := "TriggerAuthentication"
+		if ref.Kind == "ClusterTriggerAuthentication" {
+			path = []string{kedaAPI, "clustertriggerauthentications", ref.Name}
+			kind = ref.Kind
+		}
+		_, err := client.Get().AbsPath(path...).DoRaw(ctx)
+		if apierrors.IsNotFound(err) {
+			problems = append(problems, fmt.Sprintf("%s trigger references missing %s %s", trigger.Type, kind, ref.Name))
+		} else if err != nil {
+			return problems, fmt.Errorf("failed to get %s %s: %w", kind, ref.Name, err)
+		}
+	}
+	return problems, nil
+}
+
+// checkScaledObject checks a deployment KEDA has scaled to zero through its
+// ScaledObject.
+func checkScaledObject(ctx context.Context, client kubernetes.Interface, dep DeploymentInfo, so *ScaledObject) *CheckResult {
+	problems, err := ScaledObjectProblems(ctx, client, so)
+	if err != nil {
+		log.Printf("Warning: %s/%s: %v", dep.Namespace, dep.Name, err)
+	}
+	if len(problems) == 0 {
+		return &CheckResult{Healthy: true}
+	}
+	return &CheckResult{
+		FailureReason: fmt.Sprintf("Scaled to zero by KEDA ScaledObject %s, which can't scale it back up: %s",
+			so.Metadata.Name, strings.Join(problems, "; ")),
+		Classification: ClassScaledObject,
+		Severity:       SeverityCritical,
+	}
+}
+
+// kedaClient returns the REST client used for KEDA's API. Fake clientsets
+// used in simulations have none.
+func kedaClient(clientset kubernetes.Interface) (*rest.RESTClient, bool) {
+	client, ok := clientset.CoreV1().RESTClient().(*rest.RESTClient)
+	return client, ok && client != nil
+}
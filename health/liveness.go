@@ -0,0 +1,131 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// LivenessKilled reports whether the kubelet restarted a pod's container for
+// failing its liveness probe, per the pod's Killing and Unhealthy events.
+func LivenessKilled(ctx context.Context, client kubernetes.Interface, namespace, pod, container string) (bool, error) {
+	events, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.kind=Pod,involvedObject.name=" + pod,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	for _, event := range events.Items {
+		if event.InvolvedObject.Name != pod {
+			continue
+		}
+		// Events name the container as spec.containers{name}
+		if event.InvolvedObject.FieldPath != "" && !strings.Contains(event.InvolvedObject.FieldPath, "{"+container+"}") {
+			continue
+		}
+		switch {
+		case event.Reason == "Killing" && strings.Contains(event.Message, "failed liveness probe"):
+			return true, nil
+		case event.Reason == "Unhealthy" && strings.HasPrefix(event.Message, "Liveness probe failed"):
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DescribeProbe summarizes what a probe checks and how quickly it gives up.
+func DescribeProbe(probe *corev1.Probe) string {
+	var check string
+	switch {
+	case probe.HTTPGet != nil:
+		check = fmt.Sprintf("HTTP GET %s on port %s", probe.HTTPGet.Path, probe.HTTPGet.Port.String())
+	case probe.TCPSocket != nil:
+		check = fmt.Sprintf("TCP port %s", probe.TCPSocket.Port.String())
+	case probe.GRPC != nil:
+		check = fmt.Sprintf("gRPC port %d", probe.GRPC.Port)
+	case probe.Exec != nil:
+		check = fmt.Sprintf("exec %q", strings.Join(probe.Exec.Command, " "))
+	default:
+		check = "unknown check"
+	}
+
+	// Unset fields fall back to the API server's defaults
+	timeout, period, failures := probe.TimeoutSeconds, probe.PeriodSeconds, probe.FailureThreshold
+	if timeout == 0 {
+		timeout = 1
+	}
+	if period == 0 {
+		period = 10
+	}
+	if failures == 0 {
+		failures = 3
+	}
+	return fmt.Sprintf("%s, initial delay %ds, timeout %ds, every %ds, %d failures",
+		check, probe.InitialDelaySeconds, timeout, period, failures)
+}
+
+// explainLivenessKills reclassifies a restart loop as ClassLivenessProbe
+// when the kubelet is killing the container for failing its liveness probe,
+// since the fix is tuning the probe rather than the code.
+func (c *Checker) explainLivenessKills(ctx context.Context, client kubernetes.Interface, result *CheckResult, pods []corev1.Pod) {
+	for _, pod := range pods {
+		if pod.Name != result.Pod {
+			continue
+		}
+
+		probe, exitCode, ok := livenessCandidate(pod, result.Container)
+		if !ok {
+			return
+		}
+		killed, err := LivenessKilled(ctx, client, pod.Namespace, pod.Name, result.Container)
+		if err != nil {
+			log.Printf("Warning: %s/%s: %v", pod.Namespace, pod.Name, err)
+			return
+		}
+		if !killed {
+			return
+		}
+
+		reason := fmt.Sprintf("Container %s is restarted by its failing liveness probe (%s); last exit code %d",
+			result.Container, DescribeProbe(probe), exitCode)
+		result.Classification, result.FailureReason = ClassLivenessProbe, reason
+		for i := range result.Pods {
+			if result.Pods[i].Pod == pod.Name {
+				result.Pods[i].Classification, result.Pods[i].Reason = ClassLivenessProbe, reason
+			}
+		}
+		return
+	}
+}
+
+// livenessCandidate returns the container's liveness probe if it has one and
+// last terminated by signal, as a liveness kill does (SIGTERM, then SIGKILL
+// after the grace period).
+func livenessCandidate(pod corev1.Pod, container string) (*corev1.Probe, int32, bool) {
+	var probe *corev1.Probe
+	for _, c := range pod.Spec.Containers {
+		if c.Name == container {
+			probe = c.LivenessProbe
+		}
+	}
+	if probe == nil {
+		return nil, 0, false
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		last := status.LastTerminationState.Terminated
+		if status.Name != container || last == nil || last.Reason == "OOMKilled" {
+			continue
+		}
+		if last.ExitCode == 137 || last.ExitCode == 143 {
+			return probe, last.ExitCode, true
+		}
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,115 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LogEntry is one line of a container's logs, with fields extracted when the
+// line is a JSON object.
+type LogEntry struct {
+	Time       string
+	Level      string
+	Message    string
+	Structured bool
+}
+
+// LogFields lists the JSON keys tried, in order, for each extracted field.
+type LogFields struct {
+	Time    []string
+	Level   []string
+	Message []string
+}
+
+// DefaultLogFields covers the common zap, logrus, bunyan and ECS key names.
+var DefaultLogFields = LogFields{
+	Time:    []string{"ts", "time", "timestamp", "@timestamp", "t"},
+	Level:   []string{"level", "lvl", "severity", "log.level", "levelname"},
+	Message: []string{"msg", "message", "log", "error"},
+}
+
+// ParseStructuredLogs extracts fields from JSON log lines. It returns nil when
+// fewer than half the lines are JSON, so callers fall back to the raw logs.
+// Non-JSON lines in mostly structured logs are kept as plain messages.
+func ParseStructuredLogs(raw string, fields LogFields) []LogEntry {
+	var entries []LogEntry
+	structured := 0
+
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var obj map[string]interface{}
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &obj) != nil {
+			entries = append(entries, LogEntry{Message: line})
+			continue
+		}
+
+		structured++
+		entry := LogEntry{
+			Time:       formatLogTime(lookupField(obj, fields.Time)),
+			Level:      strings.ToUpper(fmt.Sprint(valueOr(lookupField(obj, fields.Level), ""))),
+			Message:    fmt.Sprint(valueOr(lookupField(obj, fields.Message), "")),
+			Structured: true,
+		}
+		if entry.Message == "" {
+			entry.Message = line
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 || structured*2 < len(entries) {
+		return nil
+	}
+	return entries
+}
+
+// lookupField returns the first key present, also resolving dotted keys
+// through nested objects (e.g. "log.level").
+func lookupField(obj map[string]interface{}, keys []string) interface{} {
+	for _, key := range keys {
+		if v, ok := obj[key]; ok {
+			return v
+		}
+
+		var current interface{} = obj
+		for _, part := range strings.Split(key, ".") {
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				current = nil
+				break
+			}
+			current = m[part]
+		}
+		if current != nil {
+			return current
+		}
+	}
+	return nil
+}
+
+func valueOr(v interface{}, fallback interface{}) interface{} {
+	if v == nil {
+		return fallback
+	}
+	return v
+}
+
+// formatLogTime renders RFC3339 strings as-is and epoch seconds (as emitted by
+// zap) as UTC timestamps.
+func formatLogTime(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		sec := int64(t)
+		nsec := int64((t - float64(sec)) * 1e9)
+		return time.Unix(sec, nsec).UTC().Format("2006-01-02 15:04:05.000")
+	default:
+		return fmt.Sprint(t)
+	}
+}
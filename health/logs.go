@@ -0,0 +1,312 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// LogFetcher fetches tail logs for failing pods with bounded concurrency and a
+// byte cap, caching each pod/container so a run never fetches it twice. Logs
+// are streamed into a fixed-size buffer that keeps only the tail, and a
+// per-run budget bounds the bytes read overall. Use a fresh LogFetcher per
+// run.
+type LogFetcher struct {
+	client      kubernetes.Interface
+	tailLines   int64
+	limitBytes  int64
+	concurrency int
+
+	// bufferBytes bounds the tail kept in memory per container
+	bufferBytes int64
+	// budget is what remains of the run's log byte budget; nil is unlimited
+	budget *atomic.Int64
+
+	// spillDir receives full logs, up to spillLimit bytes each, if set
+	spillDir   string
+	spillLimit int64
+
+	// observe receives the latency of each log request, if set
+	observe func(time.Duration)
+
+	mu    sync.Mutex
+	cache map[string]*fetchedLogs
+}
+
+type fetchedLogs struct {
+	tail string
+	file string
+	// done is closed once tail and file are set, so concurrent fetches of
+	// the same container wait for the first instead of fetching again
+	done chan struct{}
+}
+
+func NewLogFetcher(client kubernetes.Interface, tailLines int, limitBytes int64, concurrency int) *LogFetcher {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &LogFetcher{
+		client:      client,
+		tailLines:   int64(tailLines),
+		limitBytes:  limitBytes,
+		concurrency: concurrency,
+		bufferBytes: limitBytes,
+		cache:       make(map[string]*fetchedLogs),
+	}
+}
+
+// SetLatencyObserver registers a function called with the duration of every
+// log request made, e.g. to record a histogram.
+func (f *LogFetcher) SetLatencyObserver(observe func(time.Duration)) {
+	f.observe = observe
+}
+
+// SetMemoryLimits bounds the log tail kept in memory per container and the
+// total log bytes read by this fetcher. Zero leaves a limit unchanged.
+func (f *LogFetcher) SetMemoryLimits(bufferBytes, runBudget int64) {
+	if bufferBytes > 0 {
+		f.bufferBytes = bufferBytes
+	}
+	if runBudget > 0 {
+		f.budget = &atomic.Int64{}
+		f.budget.Store(runBudget)
+	}
+}
+
+// SetSpillDir makes the fetcher stream each container's full logs, up to
+// limitBytes, to a file in dir while keeping only the tail in memory.
+func (f *LogFetcher) SetSpillDir(dir string, limitBytes int64) {
+	f.spillDir = dir
+	f.spillLimit = limitBytes
+}
+
+// FetchAll fills in PodLogs, and the logs of each failing pod, for every
+// failed result concurrently.
+func (f *LogFetcher) FetchAll(ctx context.Context, results []*CheckResult) {
+	sem := make(chan struct{}, f.concurrency)
+	var wg sync.WaitGroup
+
+	fetch := func(namespace, pod, container string, logs, file *string) {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			*logs, *file = f.fetch(ctx, namespace, pod, container)
+		}()
+	}
+
+	for _, result := range results {
+		if result.Healthy || result.Pod == "" {
+			continue
+		}
+
+		fetch(result.Namespace, result.Pod, result.Container, &result.PodLogs, &result.LogFile)
+		for i := range result.Pods {
+			fetch(result.Namespace, result.Pods[i].Pod, result.Pods[i].Container, &result.Pods[i].Logs, &result.Pods[i].LogFile)
+		}
+	}
+
+	wg.Wait()
+}
+
+// Fetch returns the tail of a container's logs, or a message describing why
+// they couldn't be fetched.
+func (f *LogFetcher) Fetch(ctx context.Context, namespace, pod, container string) string {
+	logs, _ := f.fetch(ctx, namespace, pod, container)
+	return logs
+}
+
+// fetch returns the tail of a container's logs and, when spilling, the file
+// holding the full logs.
+func (f *LogFetcher) fetch(ctx context.Context, namespace, pod, container string) (string, string) {
+	if container == "" {
+		return "No containers in pod", ""
+	}
+
+	key := namespace + "/" + pod + "/" + container
+	f.mu.Lock()
+	cached, ok := f.cache[key]
+	if !ok {
+		cached = &fetchedLogs{done: make(chan struct{})}
+		f.cache[key] = cached
+	}
+	f.mu.Unlock()
+	if ok {
+		<-cached.done
+		return cached.tail, cached.file
+	}
+
+	fetched := f.stream(ctx, namespace, pod, container)
+	cached.tail, cached.file = fetched.tail, fetched.file
+	close(cached.done)
+
+	return fetched.tail, fetched.file
+}
+
+func (f *LogFetcher) stream(ctx context.Context, namespace, pod, container string) fetchedLogs {
+	var remaining int64
+	if f.budget != nil {
+		if remaining = f.budget.Load(); remaining <= 0 {
+			return fetchedLogs{tail: "Log budget for this run exhausted; logs not fetched"}
+		}
+	}
+	capped := func(limit int64) int64 {
+		if remaining > 0 && (limit <= 0 || remaining < limit) {
+			return remaining
+		}
+		return limit
+	}
+
+	tail := newTailBuffer(f.bufferBytes)
+	tailLimit := capped(f.limitBytes)
+	tailOptions := &corev1.PodLogOptions{Container: container, TailLines: &f.tailLines}
+	if tailLimit > 0 {
+		tailOptions.LimitBytes = &tailLimit
+	}
+
+	if f.spillDir == "" {
+		if _, err := f.read(ctx, namespace, pod, tailOptions, tailLimit, tail); err != nil {
+			return fetchedLogs{tail: err.Error()}
+		}
+		return fetchedLogs{tail: lastLines(tail.String(), int(f.tailLines))}
+	}
+
+	spill, err := os.CreateTemp(f.spillDir, spillName(namespace, pod, container))
+	if err != nil {
+		return fetchedLogs{tail: fmt.Sprintf("Failed to spill logs: %v", err)}
+	}
+	defer spill.Close()
+
+	limit := capped(f.spillLimit)
+	n, err := f.read(ctx, namespace, pod, &corev1.PodLogOptions{Container: container}, limit, io.MultiWriter(tail, spill))
+	if err != nil {
+		return fetchedLogs{tail: err.Error(), file: spill.Name()}
+	}
+	// A log longer than the limit was cut short before its end, so fetch the
+	// tail on its own
+	if limit > 0 && n >= limit {
+		tail = newTailBuffer(f.bufferBytes)
+		if _, err := f.read(ctx, namespace, pod, tailOptions, tailLimit, tail); err != nil {
+			return fetchedLogs{tail: err.Error(), file: spill.Name()}
+		}
+	}
+	return fetchedLogs{tail: lastLines(tail.String(), int(f.tailLines)), file: spill.Name()}
+}
+
+// read streams a container's logs into w, reading at most limit bytes if
+// positive, and charges the bytes read to the run budget.
+func (f *LogFetcher) read(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions, limit int64, w io.Writer) (int64, error) {
+	start := time.Now()
+	stream, err := f.client.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(ctx)
+	if f.observe != nil {
+		f.observe(time.Since(start))
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Failed to get logs: %v", err)
+	}
+	defer stream.Close()
+
+	var reader io.Reader = stream
+	if limit > 0 {
+		reader = io.LimitReader(stream, limit)
+	}
+
+	n, err := io.Copy(w, reader)
+	if f.budget != nil {
+		f.budget.Add(-n)
+	}
+	if err != nil {
+		return n, fmt.Errorf("Failed to read logs: %v", err)
+	}
+	return n, nil
+}
+
+func spillName(namespace, pod, container string) string {
+	return strings.Join([]string{namespace, pod, container, "*.log"}, "_")
+}
+
+// PruneSpillDir removes spilled log files older than maxAge.
+func PruneSpillDir(dir string, maxAge time.Duration, now time.Time) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err == nil && now.Sub(info.ModTime()) >= maxAge {
+			if err := os.Remove(file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tailBuffer keeps the last size bytes written to it.
+type tailBuffer struct {
+	buf     []byte
+	size    int
+	wrapped bool
+}
+
+func newTailBuffer(size int64) *tailBuffer {
+	if size <= 0 {
+		size = 64 * 1024
+	}
+	return &tailBuffer{size: int(size)}
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(p) >= t.size {
+		t.buf = append(t.buf[:0], p[len(p)-t.size:]...)
+		t.wrapped = true
+		return n, nil
+	}
+	if overflow := len(t.buf) + len(p) - t.size; overflow > 0 {
+		t.buf = append(t.buf[:0], t.buf[overflow:]...)
+		t.wrapped = true
+	}
+	t.buf = append(t.buf, p...)
+	return n, nil
+}
+
+// String returns the buffered bytes, without the partial first line if
+// earlier output was dropped.
+func (t *tailBuffer) String() string {
+	if t.wrapped {
+		if i := bytes.IndexByte(t.buf, '\n'); i >= 0 {
+			return string(t.buf[i+1:])
+		}
+	}
+	return string(t.buf)
+}
+
+// lastLines returns at most n trailing lines of s.
+func lastLines(s string, n int) string {
+	if n <= 0 {
+		return s
+	}
+	trimmed := strings.TrimSuffix(s, "\n")
+	lines := 0
+	for i := len(trimmed) - 1; i >= 0; i-- {
+		if trimmed[i] == '\n' {
+			lines++
+			if lines == n {
+				return s[i+1:]
+			}
+		}
+	}
+	return s
+}
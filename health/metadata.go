@@ -0,0 +1,51 @@
+package health
+
+import (
+	"strings"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+)
+
+// MetadataReader reads workload metadata from labels as well as, or instead
+// of, annotations, for teams whose policy tooling only allows labels.
+type MetadataReader struct {
+	cfg config.MetadataConfig
+}
+
+func NewMetadataReader(cfg config.MetadataConfig) *MetadataReader {
+	return &MetadataReader{cfg: cfg}
+}
+
+// Apply merges the configured labels into a workload's annotations and
+// refreshes the owners and team read from them. The annotations are copied,
+// since they are shared with the scanned object.
+func (r *MetadataReader) Apply(dep *DeploymentInfo) {
+	merged := make(map[string]string, len(dep.Annotations)+len(r.cfg.Labels))
+	for k, v := range dep.Annotations {
+		merged[k] = v
+	}
+
+	for annotation, label := range r.cfg.Labels {
+		value := dep.Labels[label]
+		if value != "" && (annotation == OwnerAnnotation || annotation == OwnerDLAnnotation) &&
+			r.cfg.EmailDomain != "" && !strings.Contains(value, "@") {
+			value += "@" + r.cfg.EmailDomain
+		}
+
+		switch {
+		case r.cfg.Source == config.MetadataLabels && value == "":
+			delete(merged, annotation)
+		case r.cfg.Source == config.MetadataLabels:
+			merged[annotation] = value
+		case value == "":
+			// Both: the annotation, if any, stands
+		case merged[annotation] == "" || r.cfg.Prefer == config.MetadataLabels:
+			merged[annotation] = value
+		}
+	}
+
+	dep.Annotations = merged
+	dep.OwnerEmail = merged[OwnerAnnotation]
+	dep.OwnerDlEmail = merged[OwnerDLAnnotation]
+	dep.Team = merged[TeamAnnotation]
+}
@@ -0,0 +1,35 @@
+package health
+
+import "time"
+
+// NamespaceReport introduces a new namespace to the platform team: its
+// workloads, which of the monitor's annotations they set, and the changes
+// that would get their alerts routed.
+type NamespaceReport struct {
+	Namespace string
+	CreatedAt time.Time
+	Workloads []WorkloadAnnotations
+	Coverage  []AnnotationCoverage
+	// Suggestions are kubectl commands that would set up alert routing
+	Suggestions []string
+}
+
+// WorkloadAnnotations is a workload in a NamespaceReport.
+type WorkloadAnnotations struct {
+	Name string
+	// Monitored is set when the workload has owners, from its annotations
+	// or an owner resolver, so its failures are alerted on
+	Monitored bool
+	// Missing are the checked annotations it doesn't set
+	Missing []string
+}
+
+// AnnotationCoverage counts the workloads in a namespace setting an
+// annotation.
+type AnnotationCoverage struct {
+	Annotation string
+	// Required annotations must be set for a workload to be monitored
+	Required bool
+	Set      int
+	Total    int
+}
@@ -0,0 +1,51 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SplitOrphans separates pods matching a deployment's pod selector into those
+// created by one of its ReplicaSets and orphans: pods left behind by an old
+// selector, created by hand or owned by another workload. Orphans skew the
+// health check and may serve stale code. If none of the deployment's
+// ReplicaSets are found, e.g. in a fixture, every pod counts as owned.
+func SplitOrphans(ctx context.Context, client kubernetes.Interface, dep DeploymentInfo, pods []corev1.Pod) (owned, orphans []corev1.Pod, err error) {
+	replicaSets, err := client.AppsV1().ReplicaSets(dep.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: PodSelector(dep),
+	})
+	if err != nil {
+		return pods, nil, fmt.Errorf("failed to list replica sets: %w", err)
+	}
+
+	current := make(map[string]bool)
+	for _, rs := range replicaSets.Items {
+		if owner := metav1.GetControllerOf(&rs); owner != nil && owner.Kind == "Deployment" && owner.Name == dep.Name {
+			current[rs.Name] = true
+		}
+	}
+	if len(current) == 0 {
+		return pods, nil, nil
+	}
+
+	for _, pod := range pods {
+		if owner := metav1.GetControllerOf(&pod); owner != nil && owner.Kind == "ReplicaSet" && current[owner.Name] {
+			owned = append(owned, pod)
+		} else {
+			orphans = append(orphans, pod)
+		}
+	}
+	return owned, orphans, nil
+}
+
+// describeOrphan says where an orphaned pod came from.
+func describeOrphan(pod corev1.Pod) string {
+	if owner := metav1.GetControllerOf(&pod); owner != nil {
+		return fmt.Sprintf("%s (owned by %s %s)", pod.Name, owner.Kind, owner.Name)
+	}
+	return pod.Name + " (no controller)"
+}
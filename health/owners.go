@@ -0,0 +1,130 @@
+package health
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// Owner annotations holding email addresses.
+const (
+	OwnerAnnotation   = "service_owner"
+	OwnerDLAnnotation = "owner_dl"
+)
+
+// TeamAnnotation names the team owning a workload, when no service catalog
+// does.
+const TeamAnnotation = "team"
+
+// OwnerFilter selects the workloads attributed to an owner, by their owner
+// or DL address, and to a team. Empty fields match every workload.
+type OwnerFilter struct {
+	Owner string
+	Team  string
+}
+
+// Matches reports whether a workload is attributed to the filter's owner and
+// team. Addresses and team names are compared case-insensitively.
+func (f OwnerFilter) Matches(dep DeploymentInfo) bool {
+	if f.Owner != "" && !strings.EqualFold(dep.OwnerEmail, f.Owner) && !strings.EqualFold(dep.OwnerDlEmail, f.Owner) {
+		return false
+	}
+	return f.Team == "" || strings.EqualFold(dep.Team, f.Team)
+}
+
+var domainPattern = regexp.MustCompile(`^([a-z0-9]([-a-z0-9]*[a-z0-9])?\.)+[a-z]{2,}$`)
+
+// InvalidOwner is an owner address that failed validation at scan time.
+type InvalidOwner struct {
+	Namespace  string `json:"namespace"`
+	Deployment string `json:"deployment"`
+	Annotation string `json:"annotation"`
+	Value      string `json:"value"`
+	Reason     string `json:"reason"`
+}
+
+// EmailValidator normalizes owner addresses and rejects malformed ones and,
+// if allowed domains are configured, those outside them. Subdomains of an
+// allowed domain are allowed too.
+type EmailValidator struct {
+	allowedDomains []string
+}
+
+func NewEmailValidator(allowedDomains []string) *EmailValidator {
+	v := &EmailValidator{}
+	for _, domain := range allowedDomains {
+		v.allowedDomains = append(v.allowedDomains, strings.ToLower(strings.TrimSpace(domain)))
+	}
+	return v
+}
+
+// Normalize returns the bare, lower-cased address in value, which may
+// include a display name, or an error describing why it is invalid.
+func (v *EmailValidator) Normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("empty address")
+	}
+
+	addr, err := mail.ParseAddress(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid address syntax")
+	}
+	address := strings.ToLower(addr.Address)
+
+	domain := address[strings.LastIndex(address, "@")+1:]
+	if !domainPattern.MatchString(domain) {
+		return "", fmt.Errorf("invalid domain %q", domain)
+	}
+	if len(v.allowedDomains) > 0 && !v.allowed(domain) {
+		return "", fmt.Errorf("domain %q is not allowed", domain)
+	}
+	return address, nil
+}
+
+func (v *EmailValidator) allowed(domain string) bool {
+	for _, allowed := range v.allowedDomains {
+		if domain == allowed || strings.HasSuffix(domain, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateOwners normalizes a deployment's owner and DL addresses in place
+// and returns the ones that are invalid. An invalid address is replaced by
+// the other one, so alerts still reach someone; if both are invalid both are
+// cleared and the deployment is skipped like one without owners.
+func (v *EmailValidator) ValidateOwners(dep *DeploymentInfo) []InvalidOwner {
+	var invalid []InvalidOwner
+	check := func(annotation string, value *string) bool {
+		if *value == "" {
+			return false
+		}
+		address, err := v.Normalize(*value)
+		if err != nil {
+			invalid = append(invalid, InvalidOwner{
+				Namespace:  dep.Namespace,
+				Deployment: dep.Name,
+				Annotation: annotation,
+				Value:      *value,
+				Reason:     err.Error(),
+			})
+			*value = ""
+			return false
+		}
+		*value = address
+		return true
+	}
+
+	ownerOK := check(OwnerAnnotation, &dep.OwnerEmail)
+	dlOK := check(OwnerDLAnnotation, &dep.OwnerDlEmail)
+	switch {
+	case ownerOK && !dlOK && len(invalid) > 0:
+		dep.OwnerDlEmail = dep.OwnerEmail
+	case dlOK && !ownerOK && len(invalid) > 0:
+		dep.OwnerEmail = dep.OwnerDlEmail
+	}
+	return invalid
+}
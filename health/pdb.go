@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PDBFinding describes a PodDisruptionBudget that currently blocks evictions.
+type PDBFinding struct {
+	Namespace      string
+	Name           string
+	CurrentHealthy int32
+	DesiredHealthy int32
+	ExpectedPods   int32
+	BlockedSince   time.Time
+	// LongBlocked is set once the PDB has blocked evictions past the threshold
+	LongBlocked bool
+	Reason      string
+}
+
+// FindBlockingPDBs lists PDBs whose disruptionsAllowed is 0, which blocks node
+// drains. Namespaces for which skip returns true are ignored.
+func FindBlockingPDBs(ctx context.Context, client kubernetes.Interface, threshold time.Duration,
+	skip func(namespace string) bool) ([]PDBFinding, error) {
+
+	pdbs, err := client.PolicyV1().PodDisruptionBudgets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod disruption budgets: %w", err)
+	}
+
+	var findings []PDBFinding
+	for _, pdb := range pdbs.Items {
+		if skip(pdb.Namespace) || pdb.Status.DisruptionsAllowed > 0 {
+			continue
+		}
+
+		finding := PDBFinding{
+			Namespace:      pdb.Namespace,
+			Name:           pdb.Name,
+			CurrentHealthy: pdb.Status.CurrentHealthy,
+			DesiredHealthy: pdb.Status.DesiredHealthy,
+			ExpectedPods:   pdb.Status.ExpectedPods,
+			Reason:         pdbBlockReason(pdb),
+		}
+
+		// The DisruptionAllowed condition tells us how long evictions have been blocked
+		for _, cond := range pdb.Status.Conditions {
+			if cond.Type == policyv1.DisruptionAllowedCondition && cond.Status == metav1.ConditionFalse {
+				finding.BlockedSince = cond.LastTransitionTime.Time
+			}
+		}
+		if !finding.BlockedSince.IsZero() && time.Since(finding.BlockedSince) >= threshold {
+			finding.LongBlocked = true
+		}
+
+		findings = append(findings, finding)
+	}
+
+	return findings, nil
+}
+
+func pdbBlockReason(pdb policyv1.PodDisruptionBudget) string {
+	if max := pdb.Spec.MaxUnavailable; max != nil && (max.String() == "0" || max.String() == "0%") {
+		return "maxUnavailable is 0, so no pod can ever be evicted"
+	}
+	if min := pdb.Spec.MinAvailable; min != nil && pdb.Status.ExpectedPods > 0 &&
+		(min.String() == "100%" || int32(min.IntValue()) >= pdb.Status.ExpectedPods) {
+		return fmt.Sprintf("minAvailable %s covers all %d pods", min.String(), pdb.Status.ExpectedPods)
+	}
+	if pdb.Status.CurrentHealthy < pdb.Status.DesiredHealthy {
+		return fmt.Sprintf("only %d of %d required pods are healthy", pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy)
+	}
+	return "no disruptions currently allowed"
+}
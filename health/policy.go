@@ -0,0 +1,36 @@
+package health
+
+import "time"
+
+// Policy holds the thresholds a Checker applies. Start from DefaultPolicy
+// rather than the zero value.
+type Policy struct {
+	// RestartThreshold is how many restarts of a running container count as
+	// a crash loop
+	RestartThreshold int32
+	// MaxPodFailures caps the pods detailed per deployment
+	MaxPodFailures int
+	// NotReadyGrace is how long a container may be NotReady, e.g. while
+	// starting, before it counts as failing
+	NotReadyGrace time.Duration
+	// NotReadyCritical is how long a container must be NotReady for the
+	// failure to be critical rather than a warning
+	NotReadyCritical time.Duration
+	// CheckStopped checks deployments scaled to zero or paused like any
+	// other, instead of reporting them as intentionally stopped
+	CheckStopped bool
+	// StuckTerminating is how long past its grace period a pod may stay
+	// Terminating before it counts as stuck
+	StuckTerminating time.Duration
+}
+
+// DefaultPolicy returns the thresholds the monitor uses.
+func DefaultPolicy() Policy {
+	return Policy{
+		RestartThreshold: 3,
+		MaxPodFailures:   MaxPodFailures,
+		NotReadyGrace:    2 * time.Minute,
+		NotReadyCritical: 15 * time.Minute,
+		StuckTerminating: 10 * time.Minute,
+	}
+}
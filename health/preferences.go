@@ -0,0 +1,100 @@
+package health
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Notification preference annotations. Set on a namespace, they apply to its
+// workloads that don't set them.
+const (
+	// NotifyViaAnnotation lists the channels to alert on, comma separated,
+	// e.g. "slack,email"; channels left out aren't used for the workload
+	NotifyViaAnnotation = "notify_via"
+	// AlertMinSeverityAnnotation drops alerts below a severity, e.g.
+	// "critical" to never be alerted about warnings
+	AlertMinSeverityAnnotation = "alert_min_severity"
+)
+
+// Notification channels named in NotifyViaAnnotation.
+const (
+	ChannelEmail   = "email"
+	ChannelSlack   = "slack"
+	ChannelWebhook = "webhook"
+)
+
+// NotifyPreferences is how and when a workload's owners want to be alerted.
+type NotifyPreferences struct {
+	// Channels are the allowed channels; nil allows all of them
+	Channels map[string]bool
+	// MinSeverity is the lowest severity alerted on; empty alerts on all
+	MinSeverity string
+}
+
+// InheritNamespacePreferences gives a workload the preference annotations of
+// its namespace that it doesn't set itself.
+func InheritNamespacePreferences(dep *DeploymentInfo, namespaceAnnotations map[string]string) {
+	var inherited map[string]string
+	for _, key := range []string{NotifyViaAnnotation, AlertMinSeverityAnnotation} {
+		value, ok := namespaceAnnotations[key]
+		if _, set := dep.Annotations[key]; !ok || set {
+			continue
+		}
+		// Copy, since the annotations are shared with the scanned object
+		if inherited == nil {
+			inherited = make(map[string]string, len(dep.Annotations)+2)
+			for k, v := range dep.Annotations {
+				inherited[k] = v
+			}
+		}
+		inherited[key] = value
+	}
+	if inherited != nil {
+		dep.Annotations = inherited
+	}
+}
+
+// Preferences parses a workload's notification preference annotations. An
+// invalid annotation is reported and ignored, so owners still get alerted.
+func Preferences(dep DeploymentInfo) (NotifyPreferences, error) {
+	var prefs NotifyPreferences
+	var problems []string
+
+	if raw := strings.TrimSpace(dep.Annotations[NotifyViaAnnotation]); raw != "" {
+		channels := make(map[string]bool)
+		for _, channel := range strings.Split(raw, ",") {
+			switch channel = strings.ToLower(strings.TrimSpace(channel)); channel {
+			case ChannelEmail, ChannelSlack, ChannelWebhook:
+				channels[channel] = true
+			default:
+				problems = append(problems, fmt.Sprintf("unknown channel %q in %s", channel, NotifyViaAnnotation))
+			}
+		}
+		if len(problems) == 0 {
+			prefs.Channels = channels
+		}
+	}
+
+	switch severity := strings.ToLower(strings.TrimSpace(dep.Annotations[AlertMinSeverityAnnotation])); severity {
+	case "", SeverityWarning, SeverityCritical:
+		prefs.MinSeverity = severity
+	default:
+		problems = append(problems, fmt.Sprintf("unknown severity %q in %s", severity, AlertMinSeverityAnnotation))
+	}
+
+	if len(problems) > 0 {
+		return prefs, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return prefs, nil
+}
+
+// Allows reports whether alerts may go out on a channel.
+func (p NotifyPreferences) Allows(channel string) bool {
+	return p.Channels == nil || p.Channels[channel]
+}
+
+// Wants reports whether the owners want alerts of a severity. Alerts without
+// a severity are critical.
+func (p NotifyPreferences) Wants(severity string) bool {
+	return p.MinSeverity != SeverityCritical || severity != SeverityWarning
+}
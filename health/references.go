@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ReferenceChecker verifies that the ConfigMaps and Secrets a pod spec
+// references exist and contain the referenced keys. Objects are cached, so
+// use a fresh ReferenceChecker per run. Only key names are read from Secrets.
+type ReferenceChecker struct {
+	client kubernetes.Interface
+	// keys per "kind/namespace/name"; nil means the object doesn't exist
+	cache map[string]map[string]bool
+}
+
+func NewReferenceChecker(client kubernetes.Interface) *ReferenceChecker {
+	return &ReferenceChecker{client: client, cache: make(map[string]map[string]bool)}
+}
+
+// Check returns one problem per unresolvable reference, e.g. "container app:
+// env DB_PASSWORD: Secret db-creds has no key password". Optional references
+// are skipped.
+func (r *ReferenceChecker) Check(ctx context.Context, namespace string, spec corev1.PodSpec) ([]string, error) {
+	var problems []string
+	check := func(where, kind, name, key string, optional *bool) error {
+		if optional != nil && *optional {
+			return nil
+		}
+		keys, err := r.keys(ctx, kind, namespace, name)
+		if err != nil {
+			return err
+		}
+		switch {
+		case keys == nil:
+			problems = append(problems, fmt.Sprintf("%s: %s %s not found", where, kind, name))
+		case key != "" && !keys[key]:
+			problems = append(problems, fmt.Sprintf("%s: %s %s has no key %s", where, kind, name, key))
+		}
+		return nil
+	}
+
+	containers := append(append([]corev1.Container(nil), spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		where := "container " + container.Name
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				if err := check(where+": env "+env.Name, "ConfigMap", ref.Name, ref.Key, ref.Optional); err != nil {
+					return nil, err
+				}
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				if err := check(where+": env "+env.Name, "Secret", ref.Name, ref.Key, ref.Optional); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for _, from := range container.EnvFrom {
+			if ref := from.ConfigMapRef; ref != nil {
+				if err := check(where+": envFrom", "ConfigMap", ref.Name, "", ref.Optional); err != nil {
+					return nil, err
+				}
+			}
+			if ref := from.SecretRef; ref != nil {
+				if err := check(where+": envFrom", "Secret", ref.Name, "", ref.Optional); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for _, volume := range spec.Volumes {
+		where := "volume " + volume.Name
+		var err error
+		switch {
+		case volume.ConfigMap != nil:
+			err = checkItems(check, where, "ConfigMap", volume.ConfigMap.Name, volume.ConfigMap.Items, volume.ConfigMap.Optional)
+		case volume.Secret != nil:
+			err = checkItems(check, where, "Secret", volume.Secret.SecretName, volume.Secret.Items, volume.Secret.Optional)
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					err = checkItems(check, where, "ConfigMap", source.ConfigMap.Name, source.ConfigMap.Items, source.ConfigMap.Optional)
+				}
+				if err == nil && source.Secret != nil {
+					err = checkItems(check, where, "Secret", source.Secret.Name, source.Secret.Items, source.Secret.Optional)
+				}
+				if err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return problems, nil
+}
+
+// checkItems checks a volume's object and, if it projects specific keys, each
+// of those keys.
+func checkItems(check func(where, kind, name, key string, optional *bool) error,
+	where, kind, name string, items []corev1.KeyToPath, optional *bool) error {
+
+	if len(items) == 0 {
+		return check(where, kind, name, "", optional)
+	}
+	for _, item := range items {
+		if err := check(where, kind, name, item.Key, optional); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keys returns the key names of a ConfigMap or Secret, or nil if it doesn't
+// exist.
+func (r *ReferenceChecker) keys(ctx context.Context, kind, namespace, name string) (map[string]bool, error) {
+	cacheKey := kind + "/" + namespace + "/" + name
+	if keys, ok := r.cache[cacheKey]; ok {
+		return keys, nil
+	}
+
+	var keys map[string]bool
+	var err error
+	if kind == "ConfigMap" {
+		var cm *corev1.ConfigMap
+		if cm, err = r.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{}); err == nil {
+			keys = make(map[string]bool, len(cm.Data)+len(cm.BinaryData))
+			for key := range cm.Data {
+				keys[key] = true
+			}
+			for key := range cm.BinaryData {
+				keys[key] = true
+			}
+		}
+	} else {
+		var secret *corev1.Secret
+		if secret, err = r.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{}); err == nil {
+			keys = make(map[string]bool, len(secret.Data))
+			for key := range secret.Data {
+				keys[key] = true
+			}
+		}
+	}
+
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get %s %s/%s: %w", kind, namespace, name, err)
+	}
+	r.cache[cacheKey] = keys
+	return keys, nil
+}
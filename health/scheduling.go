@@ -0,0 +1,259 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/kubernetes"
+)
+
+// unschedulable returns the PodScheduled condition of a pod the scheduler
+// couldn't place, or nil.
+func unschedulable(pod corev1.Pod) *corev1.PodCondition {
+	for i, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse &&
+			cond.Reason == corev1.PodReasonUnschedulable {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// ExplainScheduling compares a pod's nodeSelector, required node affinity
+// and tolerations with the cluster's nodes, e.g. "requires label gpu=true;
+// 0/42 nodes match". It returns "" when some node satisfies all of them, in
+// which case the scheduler's own message (usually resources) is the better
+// explanation.
+func ExplainScheduling(ctx context.Context, client kubernetes.Interface, pod corev1.Pod) (string, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list nodes: %w", err)
+	}
+	return explainScheduling(pod.Spec, nodes.Items), nil
+}
+
+// constraint is one scheduling requirement and the nodes that satisfy it.
+type constraint struct {
+	description string
+	matches     func(node corev1.Node) bool
+}
+
+func explainScheduling(spec corev1.PodSpec, nodes []corev1.Node) string {
+	constraints := schedulingConstraints(spec)
+
+	var explanations []string
+	fits := 0
+	for _, node := range nodes {
+		ok := !node.Spec.Unschedulable
+		for _, c := range constraints {
+			ok = ok && c.matches(node)
+		}
+		if ok {
+			fits++
+		}
+	}
+	if fits > 0 {
+		return ""
+	}
+
+	for _, c := range constraints {
+		if c.description == "" {
+			explanations = append(explanations, describeTaints(nodes, spec.Tolerations)...)
+			continue
+		}
+		matched := 0
+		for _, node := range nodes {
+			if c.matches(node) {
+				matched++
+			}
+		}
+		if matched < len(nodes) {
+			explanations = append(explanations, fmt.Sprintf("%s; %d/%d nodes match", c.description, matched, len(nodes)))
+		}
+	}
+	if cordoned := countCordoned(nodes); cordoned > 0 {
+		explanations = append(explanations, fmt.Sprintf("%d/%d nodes are cordoned", cordoned, len(nodes)))
+	}
+
+	if len(explanations) == 0 {
+		return fmt.Sprintf("0/%d nodes satisfy the pod's placement constraints", len(nodes))
+	}
+	return strings.Join(explanations, "; ")
+}
+
+// schedulingConstraints lists the node selector, each required node affinity
+// term, and the node taints the pod must tolerate.
+func schedulingConstraints(spec corev1.PodSpec) []constraint {
+	var constraints []constraint
+
+	keys := make([]string, 0, len(spec.NodeSelector))
+	for key := range spec.NodeSelector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		key, value := key, spec.NodeSelector[key]
+		constraints = append(constraints, constraint{
+			description: fmt.Sprintf("requires label %s=%s", key, value),
+			matches: func(node corev1.Node) bool {
+				v, ok := node.Labels[key]
+				return ok && v == value
+			},
+		})
+	}
+
+	if affinity := spec.Affinity; affinity != nil && affinity.NodeAffinity != nil &&
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		// Terms are ORed, so they only narrow placement as a whole
+		terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		var descriptions []string
+		for _, term := range terms {
+			descriptions = append(descriptions, describeTerm(term))
+		}
+		constraints = append(constraints, constraint{
+			description: "requires node affinity " + strings.Join(descriptions, " or "),
+			matches: func(node corev1.Node) bool {
+				for _, term := range terms {
+					if matchesTerm(term, node) {
+						return true
+					}
+				}
+				return false
+			},
+		})
+	}
+
+	// Taints are described per taint by describeTaints
+	constraints = append(constraints, constraint{
+		matches: func(node corev1.Node) bool {
+			return untoleratedTaint(node, spec.Tolerations) == nil
+		},
+	})
+
+	return constraints
+}
+
+func describeTerm(term corev1.NodeSelectorTerm) string {
+	var parts []string
+	for _, expr := range term.MatchExpressions {
+		parts = append(parts, describeRequirement(expr))
+	}
+	for _, field := range term.MatchFields {
+		parts = append(parts, describeRequirement(field))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func describeRequirement(expr corev1.NodeSelectorRequirement) string {
+	switch expr.Operator {
+	case corev1.NodeSelectorOpExists, corev1.NodeSelectorOpDoesNotExist:
+		return fmt.Sprintf("%s %s", expr.Key, expr.Operator)
+	}
+	return fmt.Sprintf("%s %s [%s]", expr.Key, expr.Operator, strings.Join(expr.Values, ","))
+}
+
+func matchesTerm(term corev1.NodeSelectorTerm, node corev1.Node) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	for _, expr := range term.MatchExpressions {
+		if !matchesRequirement(expr, labels.Set(node.Labels)) {
+			return false
+		}
+	}
+	for _, field := range term.MatchFields {
+		if !matchesRequirement(field, labels.Set{"metadata.name": node.Name}) {
+			return false
+		}
+	}
+	return true
+}
+
+var nodeSelectorOperators = map[corev1.NodeSelectorOperator]selection.Operator{
+	corev1.NodeSelectorOpIn:           selection.In,
+	corev1.NodeSelectorOpNotIn:        selection.NotIn,
+	corev1.NodeSelectorOpExists:       selection.Exists,
+	corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	corev1.NodeSelectorOpGt:           selection.GreaterThan,
+	corev1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+func matchesRequirement(expr corev1.NodeSelectorRequirement, set labels.Set) bool {
+	op, ok := nodeSelectorOperators[expr.Operator]
+	if !ok {
+		return false
+	}
+	req, err := labels.NewRequirement(expr.Key, op, expr.Values)
+	if err != nil {
+		return false
+	}
+	return req.Matches(set)
+}
+
+// untoleratedTaint returns the first NoSchedule or NoExecute taint on the node
+// that none of the tolerations match.
+func untoleratedTaint(node corev1.Node, tolerations []corev1.Toleration) *corev1.Taint {
+	for i, taint := range node.Spec.Taints {
+		if taint.Effect != corev1.TaintEffectPreferNoSchedule && !tolerates(tolerations, taint) {
+			return &node.Spec.Taints[i]
+		}
+	}
+	return nil
+}
+
+// describeTaints reports each taint the tolerations don't cover and how many
+// nodes carry it, e.g. "untolerated taint dedicated=infra:NoSchedule on
+// 40/42 nodes".
+func describeTaints(nodes []corev1.Node, tolerations []corev1.Toleration) []string {
+	counts := make(map[string]int)
+	for _, node := range nodes {
+		for _, taint := range node.Spec.Taints {
+			if taint.Effect == corev1.TaintEffectPreferNoSchedule || tolerates(tolerations, taint) {
+				continue
+			}
+			counts[taint.ToString()]++
+		}
+	}
+
+	taints := make([]string, 0, len(counts))
+	for taint := range counts {
+		taints = append(taints, taint)
+	}
+	sort.Slice(taints, func(i, j int) bool {
+		if counts[taints[i]] != counts[taints[j]] {
+			return counts[taints[i]] > counts[taints[j]]
+		}
+		return taints[i] < taints[j]
+	})
+
+	var descriptions []string
+	for _, taint := range taints {
+		descriptions = append(descriptions, fmt.Sprintf("untolerated taint %s on %d/%d nodes", taint, counts[taint], len(nodes)))
+	}
+	return descriptions
+}
+
+func tolerates(tolerations []corev1.Toleration, taint corev1.Taint) bool {
+	for _, toleration := range tolerations {
+		if toleration.ToleratesTaint(&taint) {
+			return true
+		}
+	}
+	return false
+}
+
+func countCordoned(nodes []corev1.Node) int {
+	cordoned := 0
+	for _, node := range nodes {
+		if node.Spec.Unschedulable {
+			cordoned++
+		}
+	}
+	return cordoned
+}
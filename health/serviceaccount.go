@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// IRSAAnnotation set to "true" on a deployment declares that it needs
+	// AWS credentials through IAM Roles for Service Accounts.
+	IRSAAnnotation = "irsa"
+
+	roleARNAnnotation = "eks.amazonaws.com/role-arn"
+	// Injected into pods by the EKS pod identity webhook
+	webIdentityTokenEnv = "AWS_WEB_IDENTITY_TOKEN_FILE"
+)
+
+var roleARNPattern = regexp.MustCompile(`^arn:aws(-cn|-us-gov)?:iam::\d{12}:role/.+$`)
+
+// awsCredentialErrors appear in the logs of apps that start without usable
+// AWS credentials.
+var awsCredentialErrors = []string{
+	"NoCredentialProviders",
+	"WebIdentityErr",
+	"InvalidIdentityToken",
+	"Unable to locate credentials",
+	"failed to refresh cached credentials",
+	"could not load credentials",
+}
+
+// IsAWSCredentialError reports whether logs show an app failing to obtain AWS
+// credentials.
+func IsAWSCredentialError(logs string) bool {
+	for _, marker := range awsCredentialErrors {
+		if strings.Contains(logs, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckServiceAccount verifies that the pod spec's service account exists
+// and, when the deployment or its service account uses IRSA, that the role
+// annotation is present and well formed and that running pods received the
+// web identity token.
+func CheckServiceAccount(ctx context.Context, client kubernetes.Interface, dep DeploymentInfo, spec corev1.PodSpec) ([]string, error) {
+	name := spec.ServiceAccountName
+	if name == "" {
+		name = "default"
+	}
+
+	sa, err := client.CoreV1().ServiceAccounts(dep.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return []string{fmt.Sprintf("ServiceAccount %s not found", name)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service account %s: %w", name, err)
+	}
+
+	roleARN, annotated := sa.Annotations[roleARNAnnotation]
+	if !annotated {
+		if dep.Annotations[IRSAAnnotation] == "true" {
+			return []string{fmt.Sprintf("ServiceAccount %s has no %s annotation, but the deployment requires IRSA", name, roleARNAnnotation)}, nil
+		}
+		return nil, nil
+	}
+
+	if !roleARNPattern.MatchString(roleARN) {
+		return []string{fmt.Sprintf("ServiceAccount %s has an invalid %s annotation %q", name, roleARNAnnotation, roleARN)}, nil
+	}
+
+	// The webhook only mutates pods at creation, so pods created before the
+	// annotation was added run without credentials until restarted
+	pods, err := client.CoreV1().Pods(dep.Namespace).List(ctx, metav1.ListOptions{LabelSelector: PodSelector(dep)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	var missing []string
+	for _, pod := range pods.Items {
+		if !hasWebIdentityToken(pod.Spec) {
+			missing = append(missing, pod.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return []string{fmt.Sprintf("Pod(s) %s have no %s; restart them so the EKS pod identity webhook injects credentials for %s",
+			strings.Join(missing, ", "), webIdentityTokenEnv, roleARN)}, nil
+	}
+	return nil, nil
+}
+
+func hasWebIdentityToken(spec corev1.PodSpec) bool {
+	for _, container := range spec.Containers {
+		for _, env := range container.Env {
+			if env.Name == webIdentityTokenEnv {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// CPU usage at or above this fraction of the limit counts as throttling
+	cpuThrottleRatio = 0.9
+
+	memoryStep = 64 * 1024 * 1024 // round memory suggestions to 64Mi
+	cpuStep    = 50               // round CPU suggestions to 50m
+)
+
+// Suggester turns saturation-related failures into concrete scaling
+// suggestions using HPA status, metrics-server usage and container resources.
+type Suggester struct {
+	client kubernetes.Interface
+}
+
+func NewSuggester(client kubernetes.Interface) *Suggester {
+	return &Suggester{client: client}
+}
+
+// podMetricsList mirrors the parts of metrics.k8s.io/v1beta1 PodMetricsList we use.
+type podMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Containers []struct {
+			Name  string              `json:"name"`
+			Usage corev1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// Suggest returns suggestions for a failed check. Saturation the checker can't
+// see from pod status alone (HPA at max, CPU near its limit) upgrades the
+// result's classification. Other failures only get suggestions when their
+// logs show AWS credential errors and the service account is misconfigured.
+func (s *Suggester) Suggest(ctx context.Context, dep DeploymentInfo, result *CheckResult) []string {
+	var suggestions []string
+
+	if hpaSuggestion, atMax := s.hpaSuggestion(ctx, dep); atMax {
+		suggestions = append(suggestions, hpaSuggestion)
+		if !IsSaturation(result.Classification) {
+			result.Classification = ClassHPAAtMax
+		}
+	}
+
+	deployment, err := s.client.AppsV1().Deployments(dep.Namespace).Get(ctx, dep.Name, metav1.GetOptions{})
+	if err != nil {
+		return suggestions
+	}
+
+	usage, podCount, metricsErr := containerUsage(ctx, s.client, dep)
+
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		limits := container.Resources.Limits
+		memUsage := usage[container.Name][corev1.ResourceMemory]
+		cpuUsage := usage[container.Name][corev1.ResourceCPU]
+
+		if result.Classification == ClassOOMKilled {
+			if limit, ok := limits[corev1.ResourceMemory]; ok {
+				current := limit.Value()
+				target := roundUp(int64(math.Max(float64(current)*1.5, float64(p95(memUsage))*1.25)), memoryStep)
+				suggestions = append(suggestions, fmt.Sprintf(
+					"Increase memory limit for container %s from %s to %s; %s",
+					container.Name, limit.String(),
+					resource.NewQuantity(target, resource.BinarySI).String(),
+					usageNote(memUsage, podCount, metricsErr, formatBytes)))
+			}
+		}
+
+		if limit, ok := limits[corev1.ResourceCPU]; ok && len(cpuUsage) > 0 {
+			current := limit.MilliValue()
+			usageP95 := p95(cpuUsage)
+			if float64(usageP95) >= float64(current)*cpuThrottleRatio {
+				if !IsSaturation(result.Classification) {
+					result.Classification = ClassCPUThrottling
+				}
+				target := roundUp(int64(math.Max(float64(current)*1.5, float64(usageP95)*1.25)), cpuStep)
+				suggestions = append(suggestions, fmt.Sprintf(
+					"Increase CPU limit for container %s from %s to %s; %s",
+					container.Name, limit.String(),
+					resource.NewMilliQuantity(target, resource.DecimalSI).String(),
+					usageNote(cpuUsage, podCount, metricsErr, formatMilli)))
+			}
+		}
+	}
+
+	if !IsSaturation(result.Classification) {
+		suggestions = nil
+	}
+
+	// Apps crashing on AWS credential errors usually have a broken IRSA setup
+	if IsAWSCredentialError(result.PodLogs) {
+		problems, err := CheckServiceAccount(ctx, s.client, dep, deployment.Spec.Template.Spec)
+		if err != nil {
+			log.Printf("Warning: %s/%s: %v", dep.Namespace, dep.Name, err)
+		}
+		for _, problem := range problems {
+			suggestions = append(suggestions, "Fix the service account: "+problem)
+		}
+	}
+	return suggestions
+}
+
+// hpaSuggestion finds the HPA targeting the deployment and reports whether it
+// is pinned at maxReplicas.
+func (s *Suggester) hpaSuggestion(ctx context.Context, dep DeploymentInfo) (string, bool) {
+	hpas, err := s.client.AutoscalingV2().HorizontalPodAutoscalers(dep.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", false
+	}
+
+	for _, hpa := range hpas.Items {
+		ref := hpa.Spec.ScaleTargetRef
+		if ref.Kind != "Deployment" || ref.Name != dep.Name {
+			continue
+		}
+		if hpa.Status.CurrentReplicas < hpa.Spec.MaxReplicas {
+			return "", false
+		}
+
+		target := int32(math.Ceil(float64(hpa.Spec.MaxReplicas) * 1.5))
+		return fmt.Sprintf("HPA %s is at maxReplicas (%d/%d); raise maxReplicas to %d or increase per-pod resources",
+			hpa.Name, hpa.Status.CurrentReplicas, hpa.Spec.MaxReplicas, target), true
+	}
+
+	return "", false
+}
+
+// containerUsage returns per-container usage samples (one per pod) from
+// metrics-server, keyed by container name and resource.
+func containerUsage(ctx context.Context, clientset kubernetes.Interface, dep DeploymentInfo) (map[string]map[corev1.ResourceName][]int64, int, error) {
+	// Fake clientsets used in simulations have no REST client
+	client, ok := clientset.CoreV1().RESTClient().(*rest.RESTClient)
+	if !ok || client == nil {
+		return nil, 0, fmt.Errorf("metrics API not available")
+	}
+	raw, err := client.Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", dep.Namespace, "pods").
+		Param("labelSelector", PodSelector(dep)).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var metrics podMetricsList
+	if err := json.Unmarshal(raw, &metrics); err != nil {
+		return nil, 0, err
+	}
+
+	usage := make(map[string]map[corev1.ResourceName][]int64)
+	for _, pod := range metrics.Items {
+		for _, c := range pod.Containers {
+			if usage[c.Name] == nil {
+				usage[c.Name] = make(map[corev1.ResourceName][]int64)
+			}
+			if mem, ok := c.Usage[corev1.ResourceMemory]; ok {
+				usage[c.Name][corev1.ResourceMemory] = append(usage[c.Name][corev1.ResourceMemory], mem.Value())
+			}
+			if cpu, ok := c.Usage[corev1.ResourceCPU]; ok {
+				usage[c.Name][corev1.ResourceCPU] = append(usage[c.Name][corev1.ResourceCPU], cpu.MilliValue())
+			}
+		}
+	}
+
+	return usage, len(metrics.Items), nil
+}
+
+func usageNote(samples []int64, podCount int, metricsErr error, format func(int64) string) string {
+	if metricsErr != nil || len(samples) == 0 {
+		return "current usage unavailable (metrics-server not reachable)"
+	}
+	return fmt.Sprintf("p95 usage %s across %d pods", format(p95(samples)), podCount)
+}
+
+func p95(samples []int64) int64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]int64(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(math.Ceil(0.95*float64(len(sorted)))) - 1
+	return sorted[idx]
+}
+
+func roundUp(v, step int64) int64 {
+	return (v + step - 1) / step * step
+}
+
+func formatBytes(v int64) string {
+	return resource.NewQuantity(roundUp(v, 1024*1024), resource.BinarySI).String()
+}
+
+func formatMilli(v int64) string {
+	return resource.NewMilliQuantity(v, resource.DecimalSI).String()
+}
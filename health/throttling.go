@@ -0,0 +1,35 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CPUThrottling returns a problem for each container whose p95 CPU usage,
+// per metrics-server, is at or near its limit, so the kernel throttles it.
+// Throttled containers answer probes slowly, which routinely looks like
+// readiness probe flapping. It errors if metrics-server is unreachable.
+func CPUThrottling(ctx context.Context, client kubernetes.Interface, dep DeploymentInfo, spec corev1.PodSpec) ([]string, error) {
+	usage, podCount, err := containerUsage(ctx, client, dep)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, container := range spec.Containers {
+		limit, ok := container.Resources.Limits[corev1.ResourceCPU]
+		samples := usage[container.Name][corev1.ResourceCPU]
+		if !ok || limit.IsZero() || len(samples) == 0 {
+			continue
+		}
+		usageP95 := p95(samples)
+		if float64(usageP95) >= float64(limit.MilliValue())*cpuThrottleRatio {
+			problems = append(problems, fmt.Sprintf("container %s uses %s of its %s CPU limit (p95 across %d pods)",
+				container.Name, formatMilli(usageP95), limit.String(), podCount))
+		}
+	}
+	return problems, nil
+}
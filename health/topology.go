@@ -0,0 +1,159 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Hotspot kinds, most specific first.
+const (
+	HotspotNode      = "node"
+	HotspotNodeGroup = "node group"
+	HotspotZone      = "zone"
+)
+
+// Zone labels, current and deprecated.
+var zoneLabels = []string{"topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone"}
+
+// DefaultNodeGroupLabels are the node group labels of the major managed
+// Kubernetes offerings.
+var DefaultNodeGroupLabels = []string{
+	"eks.amazonaws.com/nodegroup",
+	"cloud.google.com/gke-nodepool",
+	"kubernetes.azure.com/agentpool",
+	"karpenter.sh/nodepool",
+}
+
+// Hotspot is a node, node group or zone that most of a scan's failing pods
+// run on, which points at the infrastructure rather than the services.
+type Hotspot struct {
+	Kind        string
+	Name        string
+	FailingPods int
+	// TotalFailingPods is how many failing pods the scan found on any node
+	TotalFailingPods int
+	Deployments      int
+}
+
+// Topology locates nodes in zones and node groups. Nodes are listed once, so
+// use a fresh Topology per run.
+type Topology struct {
+	client          kubernetes.Interface
+	nodeGroupLabels []string
+	nodes           map[string]corev1.Node
+}
+
+// NewTopology returns a Topology reading node groups from the given labels,
+// or DefaultNodeGroupLabels if there are none.
+func NewTopology(client kubernetes.Interface, nodeGroupLabels []string) *Topology {
+	if len(nodeGroupLabels) == 0 {
+		nodeGroupLabels = DefaultNodeGroupLabels
+	}
+	return &Topology{client: client, nodeGroupLabels: nodeGroupLabels}
+}
+
+// Locate fills in the zone and node group of each failing pod that is
+// scheduled on a node.
+func (t *Topology) Locate(ctx context.Context, pods []PodFailure) error {
+	if t.nodes == nil {
+		nodes, err := t.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list nodes: %w", err)
+		}
+		t.nodes = make(map[string]corev1.Node, len(nodes.Items))
+		for _, node := range nodes.Items {
+			t.nodes[node.Name] = node
+		}
+	}
+
+	for i := range pods {
+		node, ok := t.nodes[pods[i].Node]
+		if !ok {
+			continue
+		}
+		pods[i].Zone = firstLabel(node.Labels, zoneLabels)
+		pods[i].NodeGroup = firstLabel(node.Labels, t.nodeGroupLabels)
+	}
+	return nil
+}
+
+func firstLabel(labels map[string]string, keys []string) string {
+	for _, key := range keys {
+		if value := labels[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// FindHotspots groups failing pods, keyed by deployment, by node, node group
+// and zone. A location is a hotspot when at least minPods failing pods of
+// two or more deployments run there and it holds at least percent of all
+// failing pods. Only the most specific hotspot is returned for pods that
+// several cover, e.g. a node rather than its zone.
+func FindHotspots(pods map[string][]PodFailure, minPods int, percent float64) []Hotspot {
+	type location struct{ kind, name string }
+	failing := make(map[location]int)
+	deployments := make(map[location]map[string]bool)
+	total := 0
+
+	for deployment, failures := range pods {
+		for _, pod := range failures {
+			if pod.Node == "" {
+				continue
+			}
+			total++
+			for _, loc := range []location{{HotspotNode, pod.Node}, {HotspotNodeGroup, pod.NodeGroup}, {HotspotZone, pod.Zone}} {
+				if loc.name == "" {
+					continue
+				}
+				failing[loc]++
+				if deployments[loc] == nil {
+					deployments[loc] = make(map[string]bool)
+				}
+				deployments[loc][deployment] = true
+			}
+		}
+	}
+
+	var hotspots []Hotspot
+	for loc, count := range failing {
+		if count < minPods || len(deployments[loc]) < 2 || float64(count)*100 < percent*float64(total) {
+			continue
+		}
+		hotspots = append(hotspots, Hotspot{
+			Kind:             loc.kind,
+			Name:             loc.name,
+			FailingPods:      count,
+			TotalFailingPods: total,
+			Deployments:      len(deployments[loc]),
+		})
+	}
+
+	rank := map[string]int{HotspotNode: 0, HotspotNodeGroup: 1, HotspotZone: 2}
+	sort.Slice(hotspots, func(i, j int) bool {
+		if rank[hotspots[i].Kind] != rank[hotspots[j].Kind] {
+			return rank[hotspots[i].Kind] < rank[hotspots[j].Kind]
+		}
+		return hotspots[i].Name < hotspots[j].Name
+	})
+	return hotspots
+}
+
+// Covers reports whether a failing pod runs in the hotspot.
+func (h Hotspot) Covers(pod PodFailure) bool {
+	switch h.Kind {
+	case HotspotNode:
+		return pod.Node == h.Name
+	case HotspotNodeGroup:
+		return pod.NodeGroup == h.Name
+	case HotspotZone:
+		return pod.Zone == h.Name
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+// Package healthmon checks and classifies deployment health the way the
+// monitor does, for tools that want the checks without running the binary:
+//
+//	result, err := healthmon.CheckDeployment(ctx, client,
+//		healthmon.Ref{Namespace: "payments", Name: "api"}, healthmon.DefaultPolicy())
+//
+// It reads no config or environment; everything comes from its arguments.
+// Classifications are the health.Class* constants.
+package healthmon
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/health"
+)
+
+// Ref names a deployment.
+type Ref struct {
+	Namespace string
+	Name      string
+}
+
+func (r Ref) String() string {
+	return r.Namespace + "/" + r.Name
+}
+
+// Policy holds the thresholds a check applies.
+type Policy = health.Policy
+
+// Result is the outcome of a check. Healthy is false when the deployment is
+// failing, with Classification saying why. Deployments scaled to zero or
+// paused are healthy and Stopped unless the policy's CheckStopped is set.
+type Result = health.CheckResult
+
+// DefaultPolicy returns the thresholds the monitor uses.
+func DefaultPolicy() Policy {
+	return health.DefaultPolicy()
+}
+
+// CheckDeployment checks the pods of the deployment ref under policy. An error
+// means the deployment couldn't be checked, not that it is failing. Pod logs
+// aren't fetched; use health.LogFetcher for those.
+func CheckDeployment(ctx context.Context, client kubernetes.Interface, ref Ref, policy Policy) (*Result, error) {
+	dep, err := client.AppsV1().Deployments(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment %s: %w", ref, err)
+	}
+
+	checker := health.NewChecker()
+	checker.SetPolicy(policy)
+	return checker.CheckDeploymentHealth(ctx, client, health.NewDeploymentInfo(*dep))
+}
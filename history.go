@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// History pages are this long unless the caller asks for fewer.
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// historyPage is a page of incident history. NextCursor fetches the next
+// page and is empty on the last one.
+type historyPage struct {
+	Items      []interface{} `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// serveHistory implements the incident history API, oldest first:
+//
+//	GET /api/v1/history?since=&until=&namespace=&deployment=&fields=&limit=&cursor=
+//
+// since and until are RFC 3339 times bounding when incidents started (since
+// defaults to report.window ago); fields is a comma-separated list of JSON
+// fields to return; cursor is the next_cursor of the previous page.
+func (m *monitor) serveHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	now := time.Now()
+
+	since, err := parseTimeParam(query.Get("since"), now.Add(-m.cfg.Report.Window))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	until, err := parseTimeParam(query.Get("until"), time.Time{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := defaultHistoryLimit
+	if raw := query.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 || limit > maxHistoryLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxHistoryLimit), http.StatusBadRequest)
+			return
+		}
+	}
+	var after *historyCursor
+	if raw := query.Get("cursor"); raw != "" {
+		if after, err = decodeHistoryCursor(raw); err != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+	var fields []string
+	if raw := query.Get("fields"); raw != "" {
+		fields = strings.Split(raw, ",")
+	}
+
+	principal := auth.FromContext(r.Context())
+	page := historyPage{Items: []interface{}{}}
+	var last state.IncidentRecord
+	passed := after == nil
+	for _, record := range m.store.History(since) {
+		if !until.IsZero() && !record.StartedAt.Before(until) {
+			continue
+		}
+		if !principal.CanView(record.Namespace) ||
+			(query.Get("namespace") != "" && record.Namespace != query.Get("namespace")) ||
+			(query.Get("deployment") != "" && record.Deployment != query.Get("deployment")) {
+			continue
+		}
+		// Skip up to and including the last record of the previous page
+		if !passed {
+			if record.StartedAt.Before(after.StartedAt) {
+				continue
+			}
+			if record.StartedAt.Equal(after.StartedAt) {
+				passed = state.Key(record.Namespace, record.Deployment) == after.Service
+				continue
+			}
+			passed = true
+		}
+		if len(page.Items) == limit {
+			page.NextCursor = encodeHistoryCursor(last)
+			break
+		}
+		item, err := selectFields(record, fields)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		page.Items = append(page.Items, item)
+		last = record
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// historyCursor identifies the last record of a history page. Records are
+// ordered by start time, and a service has one incident per start time.
+type historyCursor struct {
+	StartedAt time.Time `json:"t"`
+	Service   string    `json:"s"`
+}
+
+func encodeHistoryCursor(record state.IncidentRecord) string {
+	raw, _ := json.Marshal(historyCursor{StartedAt: record.StartedAt, Service: state.Key(record.Namespace, record.Deployment)})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeHistoryCursor(cursor string) (*historyCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var c historyCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// selectFields returns a record with only the named JSON fields, or the whole
+// record if fields is empty.
+func selectFields(record state.IncidentRecord, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return record, nil
+	}
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]interface{}
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+	selected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := all[strings.TrimSpace(field)]; ok {
+			selected[strings.TrimSpace(field)] = value
+		}
+	}
+	return selected, nil
+}
+
+// parseTimeParam parses an RFC 3339 query parameter, returning def if it is
+// empty.
+func parseTimeParam(raw string, def time.Time) (time.Time, error) {
+	if raw == "" {
+		return def, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: want RFC 3339", raw)
+	}
+	return t, nil
+}
+
+// serveRollups implements
+//
+//	GET /api/v1/history/rollups?since=&until=&namespace=&deployment=
+//
+// returning the daily totals of incidents and crashes pruned from history,
+// oldest first. since defaults to state.rollup_retention ago.
+func (m *monitor) serveRollups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	since, err := parseTimeParam(query.Get("since"), time.Now().Add(-m.cfg.State.RollupRetention))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	until, err := parseTimeParam(query.Get("until"), time.Time{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	principal := auth.FromContext(r.Context())
+	rollups := []state.Rollup{}
+	for _, rollup := range m.store.Rollups(since) {
+		if !until.IsZero() && !rollup.Day.Before(until) {
+			continue
+		}
+		if !principal.CanView(rollup.Namespace) ||
+			(query.Get("namespace") != "" && rollup.Namespace != query.Get("namespace")) ||
+			(query.Get("deployment") != "" && rollup.Deployment != query.Get("deployment")) {
+			continue
+		}
+		rollups = append(rollups, rollup)
+	}
+	writeJSON(w, http.StatusOK, rollups)
+}
@@ -0,0 +1,194 @@
+// Package i18n holds the message catalogs used to render notifications in a
+// team's preferred language.
+package i18n
+
+import "fmt"
+
+// DefaultLocale is used when neither the deployment nor the config picks one.
+const DefaultLocale = "en"
+
+// Annotation selects the notification language for a deployment, e.g. "hi".
+const Annotation = "locale"
+
+// catalogs maps locale -> message key -> fmt format string. Every key must
+// exist in the "en" catalog, which is the fallback for missing translations.
+var catalogs = map[string]map[string]string{
+	"en": {
+		"alert.subject":                  "[URGENT] Service Health Alert: %s is DOWN",
+		"alert.subject_incident":         "[URGENT] Service Health Alert: %s is DOWN (%s)",
+		"alert.subject_warning":          "[WARNING] Service Health Alert: %s is unhealthy",
+		"alert.subject_warning_incident": "[WARNING] Service Health Alert: %s is unhealthy (%s)",
+		"alert.incident":                 "Incident",
+		"alert.acknowledged":             "Acknowledged by",
+		"alert.acknowledged_by":          "%s at %s",
+		"alert.title":                    "Service Health Alert",
+		"alert.heading":                  "Service Health Alert: %s",
+		"alert.failure_reason":           "Failure reason:",
+		"alert.runbook":                  "Runbook:",
+		"alert.freeze":                   "Change freeze active:",
+		"alert.freeze_hint":              "Check whether an unapproved change caused this failure.",
+		"alert.platform_issue":           "This is a platform issue: an admission webhook is blocking pod creation. The infrastructure team has been notified.",
+		"alert.platform_terminating":     "This is a platform issue: a pod can't finish terminating, usually because of a finalizer or a volume that won't detach. The infrastructure team has been notified.",
+		"alert.drill":                    "This is a notification drill. No service is failing and no action is needed.",
+		"alert.hotspot":                  "%d of %d failing pods in this scan, from %d services, run on %s %s. This is likely an infrastructure problem, so the infrastructure team has been alerted.",
+		"alert.upstream_cause":           "Likely caused by upstream %s, which is also failing. Check it first.",
+		"alert.owner_bounced":            "Mail to the service owner %s bounced, so this alert went to the DL. Please update the service_owner annotation.",
+		"alert.cluster":                  "Cluster",
+		"alert.namespace":                "Namespace",
+		"alert.deployment":               "Deployment",
+		"alert.service_owner":            "Service owner",
+		"alert.owner_dl":                 "Owner DL",
+		"alert.dashboard":                "Dashboard",
+		"alert.open_dashboard":           "Open service dashboard",
+		"alert.archive":                  "Full context",
+		"alert.revision":                 "Revision",
+		"alert.images":                   "Images",
+		"alert.last_rollout":             "Last rollout",
+		"alert.rollout_age":              "%v before this check",
+		"alert.what_changed":             "What changed:",
+		"alert.next_steps":               "What to do next",
+		"alert.next_steps_link":          "Follow the %s remediation guide",
+		"alert.change_version":           "version %s,",
+		"alert.change_commit":            "commit",
+		"alert.change_pipeline":          "from pipeline %s",
+		"alert.open_archive":             "Full logs, events and alert",
+		"alert.classification":           "Classification",
+		"alert.severity":                 "Severity",
+		"alert.severity_critical":        "Critical",
+		"alert.severity_warning":         "Warning",
+		"alert.probation":                "New service on probation: this alert only goes to the owner until the service has been stable for %s",
+		"alert.checked_at":               "Checked at",
+		"alert.try_first":                "What to try first",
+		"alert.suggestions":              "Suggested actions",
+		"alert.remediation":              "Automatic remediation",
+		"alert.debug":                    "Debug the pod",
+		"alert.remediation_pod":          "Pod %s at %s: %s",
+		"alert.dry_run":                  "Dry run, no action taken",
+		"alert.failed":                   "Failed:",
+		"alert.recent_logs":              "Recent pod logs (last %d lines)",
+		"alert.pods":                     "Affected pods (%d of %d)",
+		"alert.restarts":                 "Last %d restarts",
+		"alert.restart_time":             "Time",
+		"alert.restart_container":        "Pod/container",
+		"alert.restart_reason":           "Reason",
+		"alert.restart_exit_code":        "Exit code",
+		"alert.restart_count":            "%d restarts",
+		"alert.full_logs":                "Full logs:",
+		"alert.log_time":                 "Time",
+		"alert.log_level":                "Level",
+		"alert.log_message":              "Message",
+		"alert.questions_contact":        "Questions? Contact %s or %s.",
+		"alert.questions_email":          "Questions? Contact %s.",
+		"recovery.title":                 "Service Recovered",
+		"recovery.heading":               "%s has recovered",
+		"recovery.started":               "Failing since",
+		"recovery.resolved":              "Recovered at",
+		"recovery.duration":              "Duration",
+	},
+	"hi": {
+		"alert.subject":                  "[अत्यावश्यक] सेवा स्वास्थ्य अलर्ट: %s डाउन है",
+		"alert.subject_incident":         "[अत्यावश्यक] सेवा स्वास्थ्य अलर्ट: %s डाउन है (%s)",
+		"alert.subject_warning":          "[चेतावनी] सेवा स्वास्थ्य अलर्ट: %s अस्वस्थ है",
+		"alert.subject_warning_incident": "[चेतावनी] सेवा स्वास्थ्य अलर्ट: %s अस्वस्थ है (%s)",
+		"alert.incident":                 "इंसिडेंट",
+		"alert.acknowledged":             "स्वीकार किया",
+		"alert.acknowledged_by":          "%s, %s पर",
+		"alert.title":                    "सेवा स्वास्थ्य अलर्ट",
+		"alert.heading":                  "सेवा स्वास्थ्य अलर्ट: %s",
+		"alert.failure_reason":           "विफलता का कारण:",
+		"alert.runbook":                  "रनबुक:",
+		"alert.freeze":                   "चेंज फ़्रीज़ सक्रिय है:",
+		"alert.freeze_hint":              "जाँचें कि क्या किसी अस्वीकृत बदलाव के कारण यह विफलता हुई।",
+		"alert.platform_issue":           "यह प्लेटफ़ॉर्म की समस्या है: एक एडमिशन वेबहुक पॉड बनने से रोक रहा है। इंफ्रास्ट्रक्चर टीम को सूचित कर दिया गया है।",
+		"alert.platform_terminating":     "यह प्लेटफ़ॉर्म की समस्या है: एक पॉड टर्मिनेट नहीं हो पा रहा है, आमतौर पर किसी फ़ाइनलाइज़र या डिटैच न हो रहे वॉल्यूम के कारण। इंफ्रास्ट्रक्चर टीम को सूचित कर दिया गया है।",
+		"alert.drill":                    "यह एक नोटिफिकेशन ड्रिल है। कोई सेवा विफल नहीं है और किसी कार्रवाई की आवश्यकता नहीं है।",
+		"alert.hotspot":                  "इस स्कैन के %[2]d में से %[1]d विफल पॉड, %[3]d सेवाओं के, %[4]s %[5]s पर चल रहे हैं। यह संभवतः इंफ्रास्ट्रक्चर की समस्या है, इसलिए इंफ्रास्ट्रक्चर टीम को सूचित किया गया है।",
+		"alert.upstream_cause":           "संभवतः अपस्ट्रीम %s के कारण, जो भी विफल हो रहा है। पहले उसे जाँचें।",
+		"alert.owner_bounced":            "सेवा स्वामी %s को भेजा गया मेल वापस आ गया, इसलिए यह अलर्ट DL को भेजा गया। कृपया service_owner एनोटेशन अपडेट करें।",
+		"alert.cluster":                  "क्लस्टर",
+		"alert.namespace":                "नेमस्पेस",
+		"alert.deployment":               "डिप्लॉयमेंट",
+		"alert.service_owner":            "सेवा स्वामी",
+		"alert.owner_dl":                 "स्वामी DL",
+		"alert.dashboard":                "डैशबोर्ड",
+		"alert.open_dashboard":           "सेवा डैशबोर्ड खोलें",
+		"alert.archive":                  "पूरा संदर्भ",
+		"alert.revision":                 "रिविज़न",
+		"alert.images":                   "इमेज",
+		"alert.last_rollout":             "पिछला रोलआउट",
+		"alert.rollout_age":              "इस जाँच से %v पहले",
+		"alert.what_changed":             "क्या बदला:",
+		"alert.next_steps":               "आगे क्या करें",
+		"alert.next_steps_link":          "%s सुधार गाइड देखें",
+		"alert.change_version":           "वर्ज़न %s,",
+		"alert.change_commit":            "कमिट",
+		"alert.change_pipeline":          "पाइपलाइन %s से",
+		"alert.open_archive":             "पूरे लॉग, इवेंट और अलर्ट",
+		"alert.classification":           "वर्गीकरण",
+		"alert.severity":                 "गंभीरता",
+		"alert.severity_critical":        "गंभीर",
+		"alert.severity_warning":         "चेतावनी",
+		"alert.probation":                "परिवीक्षा पर नई सेवा: सेवा के %s तक स्थिर रहने तक यह अलर्ट केवल मालिक को जाता है",
+		"alert.checked_at":               "जाँच का समय",
+		"alert.try_first":                "पहले क्या आज़माएँ",
+		"alert.suggestions":              "सुझाए गए कदम",
+		"alert.remediation":              "स्वचालित सुधार",
+		"alert.debug":                    "पॉड डीबग करें",
+		"alert.remediation_pod":          "पॉड %s, %s पर: %s",
+		"alert.dry_run":                  "ड्राई रन, कोई कार्रवाई नहीं की गई",
+		"alert.failed":                   "विफल:",
+		"alert.recent_logs":              "हाल के पॉड लॉग (अंतिम %d पंक्तियाँ)",
+		"alert.pods":                     "प्रभावित पॉड (%d / %d)",
+		"alert.restarts":                 "पिछले %d रीस्टार्ट",
+		"alert.restart_time":             "समय",
+		"alert.restart_container":        "पॉड/कंटेनर",
+		"alert.restart_reason":           "कारण",
+		"alert.restart_exit_code":        "एग्ज़िट कोड",
+		"alert.restart_count":            "%d रीस्टार्ट",
+		"alert.full_logs":                "पूरे लॉग:",
+		"alert.log_time":                 "समय",
+		"alert.log_level":                "स्तर",
+		"alert.log_message":              "संदेश",
+		"alert.questions_contact":        "प्रश्न? %s या %s से संपर्क करें।",
+		"alert.questions_email":          "प्रश्न? %s से संपर्क करें।",
+		"recovery.title":                 "सेवा बहाल हुई",
+		"recovery.heading":               "%s फिर से ठीक है",
+		"recovery.started":               "विफलता की शुरुआत",
+		"recovery.resolved":              "बहाली का समय",
+		"recovery.duration":              "अवधि",
+	},
+}
+
+// Supported reports whether a catalog exists for the locale.
+func Supported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Resolve picks the locale for a deployment from its annotations, falling back
+// to the configured default and then to English.
+func Resolve(annotations map[string]string, fallback string) string {
+	if locale := annotations[Annotation]; Supported(locale) {
+		return locale
+	}
+	if Supported(fallback) {
+		return fallback
+	}
+	return DefaultLocale
+}
+
+// T formats the message for key in the given locale. Keys missing from the
+// locale's catalog fall back to English, and unknown keys are returned as is.
+func T(locale, key string, args ...interface{}) string {
+	format, ok := catalogs[locale][key]
+	if !ok {
+		format, ok = catalogs[DefaultLocale][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,102 @@
+// imagemeta/client.go
+package imagemeta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/transport"
+)
+
+// metadata is what the image metadata source reports for an image, e.g. from
+// ECR image scan findings.
+type metadata struct {
+	PushedAt time.Time `json:"pushed_at"`
+	// Findings counts vulnerabilities by severity, e.g. "CRITICAL": 2
+	Findings            map[string]int `json:"findings"`
+	BaseImageDeprecated bool           `json:"base_image_deprecated"`
+}
+
+// Client looks up image age and scan findings to advise owners of failing
+// workloads. Lookups are cached for the lifetime of the client.
+type Client struct {
+	config     config.ImageAdvisoryConfig
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]*metadata
+}
+
+func NewClient(cfg config.ImageAdvisoryConfig) *Client {
+	return &Client{
+		config:     cfg,
+		httpClient: transport.HTTPClient(config.ProxyConfig{}, nil, 10*time.Second),
+		cache:      make(map[string]*metadata),
+	}
+}
+
+// Advise returns a short advisory for an image, such as "image is 14 months
+// old, 2 critical CVEs present", or "" if there is nothing to report.
+func (c *Client) Advise(ctx context.Context, image string, now time.Time) (string, error) {
+	meta, err := c.lookup(ctx, image)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up image %s: %w", image, err)
+	}
+
+	var parts []string
+	if !meta.PushedAt.IsZero() && now.Sub(meta.PushedAt) > c.config.MaxAge {
+		parts = append(parts, fmt.Sprintf("image is %d months old", int(now.Sub(meta.PushedAt).Hours()/24/30)))
+	}
+	if critical := meta.Findings["CRITICAL"]; critical > 0 {
+		parts = append(parts, fmt.Sprintf("%d critical CVEs present", critical))
+	}
+	if meta.BaseImageDeprecated {
+		parts = append(parts, "base image is deprecated")
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+func (c *Client) lookup(ctx context.Context, image string) (*metadata, error) {
+	c.mu.Lock()
+	meta, ok := c.cache[image]
+	c.mu.Unlock()
+	if ok {
+		return meta, nil
+	}
+
+	endpoint := fmt.Sprintf("%s/images?ref=%s", strings.TrimRight(c.config.BaseURL, "/"), url.QueryEscape(image))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("image metadata source returned status %d", resp.StatusCode)
+	}
+
+	meta = &metadata{}
+	if err := json.NewDecoder(resp.Body).Decode(meta); err != nil {
+		return nil, fmt.Errorf("failed to decode image metadata: %w", err)
+	}
+
+	c.mu.Lock()
+	c.cache[image] = meta
+	c.mu.Unlock()
+	return meta, nil
+}
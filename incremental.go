@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8s "k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// changeTracker lets daemon scans skip healthy workloads whose Deployment and
+// pods are unchanged since they were last checked, by comparing
+// resourceVersions. Failing workloads, and healthy ones only healthy because
+// a readiness grace period or Terminating threshold is still running, are
+// always checked, since those move on without the objects changing; and
+// everything is checked again every resync interval. Only used under scanMu.
+type changeTracker struct {
+	client k8s.Interface
+	resync time.Duration
+	// lastResync is when the cache was last cleared
+	lastResync time.Time
+	// healthy caches the last healthy result of each workload, keyed by
+	// namespace/name, with the versions it was checked at
+	healthy map[string]trackedResult
+
+	// namespace and pods are the pods of the namespace being scanned
+	namespace string
+	pods      []corev1.Pod
+	// skipped counts the workloads the current scan didn't check
+	skipped int
+}
+
+type trackedResult struct {
+	versions string
+	result   health.CheckResult
+}
+
+func newChangeTracker(client k8s.Interface, resync time.Duration) *changeTracker {
+	return &changeTracker{
+		client:  client,
+		resync:  resync,
+		healthy: make(map[string]trackedResult),
+	}
+}
+
+// begin starts a scan, forgetting every cached result when a resync is due.
+func (t *changeTracker) begin(now time.Time) {
+	if now.Sub(t.lastResync) >= t.resync {
+		t.healthy = make(map[string]trackedResult)
+		t.lastResync = now
+		log.Println("Full resync: checking every deployment")
+	}
+	t.namespace, t.pods, t.skipped = "", nil, 0
+}
+
+// versions returns the resourceVersions of a workload and its pods, or ""
+// if its pods can't be listed.
+func (t *changeTracker) versions(ctx context.Context, dep health.DeploymentInfo) string {
+	// Scans go namespace by namespace, so one list serves a whole namespace
+	if dep.Namespace != t.namespace {
+		pods, err := t.client.CoreV1().Pods(dep.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			log.Printf("Warning: failed to list pods in %s: %v", dep.Namespace, err)
+			t.namespace, t.pods = "", nil
+			return ""
+		}
+		t.namespace, t.pods = dep.Namespace, pods.Items
+	}
+
+	selector, err := labels.Parse(health.PodSelector(dep))
+	if err != nil {
+		return ""
+	}
+	versions := []string{dep.ResourceVersion}
+	for _, pod := range t.pods {
+		if (dep.Kind == health.KindPod && pod.Name == dep.Name) || (dep.Kind != health.KindPod && selector.Matches(labels.Set(pod.Labels))) {
+			versions = append(versions, pod.Name+"="+pod.ResourceVersion)
+		}
+	}
+	sort.Strings(versions[1:])
+	return strings.Join(versions, ",")
+}
+
+// unchanged returns the cached result of a healthy workload whose versions
+// haven't changed since it was checked.
+func (t *changeTracker) unchanged(dep health.DeploymentInfo, versions string) (*health.CheckResult, bool) {
+	tracked, ok := t.healthy[state.Key(dep.Namespace, dep.Name)]
+	if !ok || versions == "" || tracked.versions != versions {
+		return nil, false
+	}
+	t.skipped++
+	result := tracked.result
+	return &result, true
+}
+
+// record remembers a workload's result for later scans if it is healthy for
+// as long as its pods don't change.
+func (t *changeTracker) record(dep health.DeploymentInfo, versions string, result *health.CheckResult) {
+	key := state.Key(dep.Namespace, dep.Name)
+	if !result.Healthy || !result.RecheckAt.IsZero() || versions == "" {
+		delete(t.healthy, key)
+		return
+	}
+	t.healthy[key] = trackedResult{versions: versions, result: *result}
+}
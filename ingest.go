@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	k8s "k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// externalReport is a failure another system reported for a workload.
+type externalReport struct {
+	Namespace  string `json:"namespace"`
+	Deployment string `json:"deployment"`
+	// Source names the reporting system, e.g. "alertmanager" or "ci"
+	Source   string `json:"source"`
+	Reason   string `json:"reason"`
+	Severity string `json:"severity"`
+	// Resolved withdraws an earlier report
+	Resolved bool `json:"resolved"`
+}
+
+// alertmanagerWebhook is the part of an Alertmanager webhook notification
+// the monitor reads.
+type alertmanagerWebhook struct {
+	Alerts []struct {
+		Status      string            `json:"status"`
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"alerts"`
+}
+
+// externalReports holds the open failure reports from other systems, keyed by
+// namespace/name. A report lapses ttl after it was last made.
+type externalReports struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	reports map[string]externalReport
+	expires map[string]time.Time
+}
+
+func newExternalReports(ttl time.Duration) *externalReports {
+	return &externalReports{
+		ttl:     ttl,
+		reports: make(map[string]externalReport),
+		expires: make(map[string]time.Time),
+	}
+}
+
+// record opens, refreshes or, if it is resolved, withdraws a report. Expired
+// reports are dropped, so ones for workloads no scan looks at don't pile up.
+func (e *externalReports) record(report externalReport, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for key, expires := range e.expires {
+		if !now.Before(expires) {
+			delete(e.reports, key)
+			delete(e.expires, key)
+		}
+	}
+	key := state.Key(report.Namespace, report.Deployment)
+	if report.Resolved {
+		delete(e.reports, key)
+		delete(e.expires, key)
+		return
+	}
+	e.reports[key] = report
+	e.expires[key] = now.Add(e.ttl)
+}
+
+// withdraw drops the report for a workload, if any.
+func (e *externalReports) withdraw(namespace, deployment string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	key := state.Key(namespace, deployment)
+	delete(e.reports, key)
+	delete(e.expires, key)
+}
+
+// open returns the open report for a workload, if any. It is safe to call on
+// a nil externalReports.
+func (e *externalReports) open(dep health.DeploymentInfo, now time.Time) (externalReport, bool) {
+	if e == nil {
+		return externalReport{}, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	key := state.Key(dep.Namespace, dep.Name)
+	if expires, ok := e.expires[key]; ok && !now.Before(expires) {
+		delete(e.reports, key)
+		delete(e.expires, key)
+	}
+	report, ok := e.reports[key]
+	return report, ok
+}
+
+// applyExternalReport fails a workload the checker found healthy if another
+// system reported it failing. The workload's first pod is picked for logs,
+// so the alert has the same context as one for a failure the checker found.
+func applyExternalReport(ctx context.Context, client k8s.Interface, dep health.DeploymentInfo, result *health.CheckResult, report externalReport) {
+	if !result.Healthy || result.Stopped {
+		return
+	}
+	result.Healthy = false
+	result.FailureReason = fmt.Sprintf("Reported by %s: %s", report.Source, report.Reason)
+	result.Classification = health.ClassExternal
+	result.Severity = health.SeverityCritical
+	if report.Severity == health.SeverityWarning {
+		result.Severity = health.SeverityWarning
+	}
+	result.Namespace = dep.Namespace
+
+	pods, err := health.ListPods(ctx, client, dep)
+	if err != nil {
+		log.Printf("Warning: %s/%s: %v", dep.Namespace, dep.Name, err)
+		return
+	}
+	if len(pods) > 0 && len(pods[0].Spec.Containers) > 0 {
+		result.Pod, result.Container = pods[0].Name, pods[0].Spec.Containers[0].Name
+	}
+}
+
+// serveIngest implements the inbound failure report API:
+//
+//	POST /api/v1/ingest
+//
+// The body is a report ({"namespace", "deployment", "source", "reason",
+// "severity", "resolved"}) or an Alertmanager webhook notification whose
+// alerts carry namespace and deployment labels. Each reported workload is
+// rechecked, so open reports are alerted on at once through its owners'
+// usual channels, and the fresh results are returned.
+func (m *monitor) serveIngest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	reports, err := decodeReports(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	principal := auth.FromContext(r.Context())
+	for _, report := range reports {
+		if !principal.CanEdit(report.Namespace) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	now := time.Now()
+	results := []state.ScanResult{}
+	for _, report := range reports {
+		m.external.record(report, now)
+		log.Printf("%s/%s reported by %s: %s (resolved: %v)", report.Namespace, report.Deployment, report.Source, report.Reason, report.Resolved)
+
+		rechecked, err := m.recheckTarget(r.Context(), report.Namespace, report.Deployment)
+		if err == errNoDeployments {
+			m.external.withdraw(report.Namespace, report.Deployment)
+			log.Printf("Warning: ignoring report for %s/%s: not a monitored deployment", report.Namespace, report.Deployment)
+			continue
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		results = append(results, rechecked...)
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// decodeReports reads the reports in an ingest request.
+func decodeReports(r *http.Request) ([]externalReport, error) {
+	var body struct {
+		externalReport
+		alertmanagerWebhook
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	reports := []externalReport{body.externalReport}
+	if len(body.Alerts) > 0 {
+		reports = reports[:0]
+		for _, alert := range body.Alerts {
+			reason := alert.Annotations["summary"]
+			if reason == "" {
+				reason = alert.Labels["alertname"]
+			}
+			reports = append(reports, externalReport{
+				Namespace:  alert.Labels["namespace"],
+				Deployment: alert.Labels["deployment"],
+				Source:     "alertmanager",
+				Reason:     reason,
+				Severity:   alert.Labels["severity"],
+				Resolved:   alert.Status == "resolved",
+			})
+		}
+	}
+
+	for i, report := range reports {
+		if report.Namespace == "" || report.Deployment == "" {
+			return nil, fmt.Errorf("every report needs a namespace and deployment")
+		}
+		if report.Source == "" {
+			reports[i].Source = "an external check"
+		}
+		if report.Reason == "" && !report.Resolved {
+			return nil, fmt.Errorf("%s/%s: missing reason", report.Namespace, report.Deployment)
+		}
+	}
+	return reports, nil
+}
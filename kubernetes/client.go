@@ -3,19 +3,48 @@ package kubernetes
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
-	"k8s-health-monitor/health"
+	"github.com/Bharath-H-R/k8s-health/health"
 )
 
+// OwnerResolver fills in ownership for deployments whose annotations don't
+// carry it, e.g. from a service catalog.
+type OwnerResolver interface {
+	ResolveOwner(ctx context.Context, dep *health.DeploymentInfo) error
+}
+
 type Scanner struct {
-	client             *kubernetes.Clientset
+	client             kubernetes.Interface
 	excludedNamespaces map[string]bool
+	ownerResolvers     []OwnerResolver
+	// owns limits the scan to a subset of namespaces, e.g. a shard
+	owns func(namespace string) bool
+	// keep limits the scan to a subset of workloads, e.g. one team's
+	keep func(health.DeploymentInfo) bool
+	// namespaces found Terminating during the last scan
+	terminating []health.StuckNamespace
+	// emails normalizes owner addresses, if set
+	emails *health.EmailValidator
+	// metadata reads owners and other metadata from labels, if set
+	metadata *health.MetadataReader
+	// owner addresses rejected during the last scan
+	invalidOwners []health.InvalidOwner
+	// namespaces in scope the last scan didn't finish
+	skipped []string
+	// replicaSets and pods opt in to scanning workloads without a Deployment
+	replicaSets bool
+	pods        bool
 }
 
-func NewScanner(client *kubernetes.Clientset, excluded []string) *Scanner {
+func NewScanner(client kubernetes.Interface, excluded []string) *Scanner {
 	excludedMap := make(map[string]bool)
 	for _, ns := range excluded {
 		excludedMap[ns] = true
@@ -27,44 +56,251 @@ func NewScanner(client *kubernetes.Clientset, excluded []string) *Scanner {
 	}
 }
 
+// AddOwnerResolver registers a resolver consulted, in order, for deployments
+// missing owner annotations.
+func (s *Scanner) AddOwnerResolver(r OwnerResolver) {
+	s.ownerResolvers = append(s.ownerResolvers, r)
+}
+
+// SetNamespaceFilter restricts scans to namespaces for which owns returns
+// true. Terminating namespaces are filtered too, so each is reported once.
+func (s *Scanner) SetNamespaceFilter(owns func(namespace string) bool) {
+	s.owns = owns
+}
+
+// SetWorkloadFilter restricts scans to workloads for which keep returns true.
+// It sees a workload's resolved owners, so it can select by owner or team.
+func (s *Scanner) SetWorkloadFilter(keep func(health.DeploymentInfo) bool) {
+	s.keep = keep
+}
+
+// SetEmailValidator makes scans normalize owner addresses and drop invalid
+// ones, which are reported by InvalidOwners.
+func (s *Scanner) SetEmailValidator(v *health.EmailValidator) {
+	s.emails = v
+}
+
+// SetMetadataReader makes scans read owners and other metadata from labels
+// as well as, or instead of, annotations.
+func (s *Scanner) SetMetadataReader(r *health.MetadataReader) {
+	s.metadata = r
+}
+
+// SetLegacyKinds makes scans also cover ReplicaSets and pods that no
+// controller manages, for workloads not yet migrated to Deployments.
+func (s *Scanner) SetLegacyKinds(replicaSets, pods bool) {
+	s.replicaSets = replicaSets
+	s.pods = pods
+}
+
+// InvalidOwners returns the owner addresses rejected during the last scan.
+func (s *Scanner) InvalidOwners() []health.InvalidOwner {
+	return s.invalidOwners
+}
+
+// TerminatingNamespaces returns the namespaces that were being deleted during
+// the last scan. Their workloads are not scanned.
+func (s *Scanner) TerminatingNamespaces() []health.StuckNamespace {
+	return s.terminating
+}
+
+// SkippedNamespaces returns the namespaces in scope that the last scan
+// didn't finish because it was stopped, e.g. by a deadline on ctx.
+func (s *Scanner) SkippedNamespaces() []string {
+	return s.skipped
+}
+
 func (s *Scanner) ScanDeployments(ctx context.Context) ([]health.DeploymentInfo, error) {
-	namespaces, err := s.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	return s.ScanDeploymentsIn(ctx, nil)
+}
+
+// ScanDeploymentsIn scans only the namespaces for which scope returns true,
+// or all of them if scope is nil.
+func (s *Scanner) ScanDeploymentsIn(ctx context.Context, scope func(namespace string) bool) ([]health.DeploymentInfo, error) {
+	var deployments []health.DeploymentInfo
+	err := s.ScanEach(ctx, scope, func(dep health.DeploymentInfo) error {
+		deployments = append(deployments, dep)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return deployments, nil
+}
 
-	var deployments []health.DeploymentInfo
+// ScanEach calls fn with each owned deployment in the namespaces in scope,
+// or all of them if scope is nil, as soon as it is found. Deployments come
+// namespace by namespace. An error from fn, or ctx ending, stops the scan and
+// is returned; the namespaces left are reported by SkippedNamespaces.
+func (s *Scanner) ScanEach(ctx context.Context, scope func(namespace string) bool, fn func(health.DeploymentInfo) error) error {
+	namespaces, err := s.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
 
-	for _, ns := range namespaces.Items {
-		// Skip excluded namespaces
-		if s.excludedNamespaces[ns.Name] {
+	s.terminating = nil
+	s.invalidOwners = nil
+	s.skipped = nil
+
+	for i, ns := range namespaces.Items {
+		if !s.inScope(ns, scope) {
 			continue
 		}
 
+		// Workloads in a namespace being deleted would only produce noise
+		if terminating(ns) {
+			s.terminating = append(s.terminating, terminatingNamespace(ns))
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			s.skip(namespaces.Items[i:], scope)
+			return err
+		}
+
 		// Get deployments in namespace
 		deps, err := s.client.AppsV1().Deployments(ns.Name).List(ctx, metav1.ListOptions{})
 		if err != nil {
+			if ctx.Err() != nil {
+				s.skip(namespaces.Items[i:], scope)
+				return ctx.Err()
+			}
 			continue // Log but continue with other namespaces
 		}
 
+		workloads := make([]health.DeploymentInfo, 0, len(deps.Items))
 		for _, dep := range deps.Items {
 			// Extract owner annotations
-			annotations := dep.GetAnnotations()
-			ownerEmail := annotations["service_owner"]
-			ownerDlEmail := annotations["owner_dl"]
-
-			// Only include deployments with required annotations
-			if ownerEmail != "" && ownerDlEmail != "" {
-				deployments = append(deployments, health.DeploymentInfo{
-					Name:         dep.Name,
-					Namespace:    ns.Name,
-					OwnerEmail:   ownerEmail,
-					OwnerDlEmail: ownerDlEmail,
-					Annotations:  annotations,
-				})
+			workloads = append(workloads, health.NewDeploymentInfo(dep))
+		}
+		workloads = append(workloads, s.legacyWorkloads(ctx, ns.Name)...)
+
+		for _, info := range workloads {
+			if s.metadata != nil {
+				s.metadata.Apply(&info)
+			}
+			health.InheritNamespacePreferences(&info, ns.Annotations)
+			for _, resolver := range s.ownerResolvers {
+				if info.OwnerEmail != "" && info.OwnerDlEmail != "" {
+					break
+				}
+				if err := resolver.ResolveOwner(ctx, &info); err != nil {
+					log.Printf("Warning: %s/%s: %v", ns.Name, info.Name, err)
+				}
+			}
+
+			if s.emails != nil {
+				s.invalidOwners = append(s.invalidOwners, s.emails.ValidateOwners(&info)...)
+			}
+
+			if s.keep != nil && !s.keep(info) {
+				continue
+			}
+
+			// Only include deployments with required ownership
+			if info.OwnerEmail != "" && info.OwnerDlEmail != "" {
+				if err := fn(info); err != nil {
+					s.skip(namespaces.Items[i:], scope)
+					return err
+				}
 			}
 		}
 	}
 
-	return deployments, nil
+	return nil
+}
+
+// Namespaces returns the namespaces in scope, leaving out those being
+// deleted.
+func (s *Scanner) Namespaces(ctx context.Context) ([]corev1.Namespace, error) {
+	namespaces, err := s.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var inScope []corev1.Namespace
+	for _, ns := range namespaces.Items {
+		if s.inScope(ns, nil) && !terminating(ns) {
+			inScope = append(inScope, ns)
+		}
+	}
+	return inScope, nil
+}
+
+// legacyWorkloads returns the ReplicaSets and pods in a namespace that no
+// controller manages, if scanning them is enabled.
+func (s *Scanner) legacyWorkloads(ctx context.Context, namespace string) []health.DeploymentInfo {
+	var workloads []health.DeploymentInfo
+	if s.replicaSets {
+		replicaSets, err := s.client.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			log.Printf("Warning: failed to list replica sets in %s: %v", namespace, err)
+		} else {
+			for _, rs := range replicaSets.Items {
+				if metav1.GetControllerOf(&rs) == nil {
+					workloads = append(workloads, health.NewReplicaSetInfo(rs))
+				}
+			}
+		}
+	}
+	if s.pods {
+		pods, err := s.client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			log.Printf("Warning: failed to list pods in %s: %v", namespace, err)
+		} else {
+			for _, pod := range pods.Items {
+				if metav1.GetControllerOf(&pod) == nil {
+					workloads = append(workloads, health.NewPodInfo(pod))
+				}
+			}
+		}
+	}
+	return workloads
+}
+
+// inScope reports whether a scan with the given scope covers a namespace.
+func (s *Scanner) inScope(ns corev1.Namespace, scope func(namespace string) bool) bool {
+	if s.excludedNamespaces[ns.Name] {
+		return false
+	}
+	if s.owns != nil && !s.owns(ns.Name) {
+		return false
+	}
+	return scope == nil || scope(ns.Name)
+}
+
+// skip records the namespaces a stopped scan didn't finish.
+func (s *Scanner) skip(namespaces []corev1.Namespace, scope func(namespace string) bool) {
+	for _, ns := range namespaces {
+		if s.inScope(ns, scope) && !terminating(ns) {
+			s.skipped = append(s.skipped, ns.Name)
+		}
+	}
+}
+
+func terminating(ns corev1.Namespace) bool {
+	return ns.Status.Phase == corev1.NamespaceTerminating || ns.DeletionTimestamp != nil
+}
+
+func terminatingNamespace(ns corev1.Namespace) health.StuckNamespace {
+	stuck := health.StuckNamespace{Name: ns.Name}
+	if ns.DeletionTimestamp != nil {
+		stuck.DeletionStarted = ns.DeletionTimestamp.Time
+		stuck.TerminatingFor = time.Since(ns.DeletionTimestamp.Time).Round(time.Second)
+	}
+
+	for _, f := range ns.Spec.Finalizers {
+		stuck.Finalizers = append(stuck.Finalizers, string(f))
+	}
+	for _, f := range ns.Finalizers {
+		stuck.Finalizers = append(stuck.Finalizers, f)
+	}
+
+	// Conditions such as NamespaceFinalizersRemaining explain what blocks deletion
+	for _, cond := range ns.Status.Conditions {
+		if cond.Status == corev1.ConditionTrue && cond.Message != "" {
+			stuck.Conditions = append(stuck.Conditions, fmt.Sprintf("%s: %s", cond.Type, cond.Message))
+		}
+	}
+
+	return stuck
 }
@@ -2,6 +2,7 @@
 package kubernetes
 
 import (
+	"net/http"
 	"path/filepath"
 
 	"k8s.io/client-go/kubernetes"
@@ -10,7 +11,9 @@ import (
 	"k8s.io/client-go/util/homedir"
 )
 
-func NewClient() (*kubernetes.Clientset, error) {
+// NewClient builds a clientset from the in-cluster config or kubeconfig. wrap,
+// if non-nil, wraps the HTTP transport (e.g. to count requests).
+func NewClient(wrap func(http.RoundTripper) http.RoundTripper) (kubernetes.Interface, error) {
 	var config *rest.Config
 	var err error
 
@@ -25,5 +28,9 @@ func NewClient() (*kubernetes.Clientset, error) {
 		}
 	}
 
+	if wrap != nil {
+		config.Wrap(wrap)
+	}
+
 	return kubernetes.NewForConfig(config)
 }
@@ -0,0 +1,71 @@
+// links/change.go
+package links
+
+import (
+	"strings"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/health"
+)
+
+// VersionAnnotation is the recommended Kubernetes key for an app's version.
+const VersionAnnotation = "app.kubernetes.io/version"
+
+// Change reads what the deployment's current rollout changed from its
+// annotations and renders the configured change links. It returns nil if
+// nothing is known about the change.
+func Change(cfg config.ChangeLinksConfig, vars map[string]string, dep health.DeploymentInfo) *health.Change {
+	change := &health.Change{
+		Version:  dep.Annotations[VersionAnnotation],
+		Commit:   firstAnnotation(dep.Annotations, cfg.CommitAnnotations),
+		Pipeline: firstAnnotation(dep.Annotations, cfg.PipelineAnnotations),
+	}
+	if change.Version == "" && change.Commit == "" && change.Pipeline == "" {
+		return nil
+	}
+
+	changeVars := map[string]string{
+		"version":  change.Version,
+		"revision": dep.Revision,
+		"commit":   change.Commit,
+		"pipeline": change.Pipeline,
+	}
+	merged := make(map[string]string, len(vars)+len(changeVars))
+	for name, value := range vars {
+		merged[name] = value
+	}
+	for name, value := range changeVars {
+		merged[name] = value
+	}
+
+	for _, t := range cfg.Links {
+		if t.URL == "" || missing(t.URL, changeVars) {
+			continue
+		}
+		change.Links = append(change.Links, health.Link{
+			Name: t.Name,
+			URL:  Expand(t.URL, merged),
+		})
+	}
+	return change
+}
+
+func firstAnnotation(annotations map[string]string, keys []string) string {
+	for _, key := range keys {
+		if value := annotations[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// missing reports whether the template uses a placeholder with no value, so
+// a commit link isn't rendered for a deployment CI didn't annotate.
+func missing(tmpl string, vars map[string]string) bool {
+	for name, value := range vars {
+		if value == "" && strings.Contains(tmpl, "{"+name+"}") {
+			return true
+		}
+	}
+	return false
+}
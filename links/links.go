@@ -0,0 +1,72 @@
+// links/links.go
+package links
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/health"
+)
+
+// DashboardAnnotation overrides the configured dashboard URL template.
+const DashboardAnnotation = "dashboard_url"
+
+// Vars returns the placeholder values available to link templates for a
+// failed service.
+func Vars(cluster string, failedService health.FailedService, pod, container string, window time.Duration) map[string]string {
+	to := failedService.CheckTime
+	from := to.Add(-window)
+
+	return map[string]string{
+		"cluster":    cluster,
+		"namespace":  failedService.Deployment.Namespace,
+		"deployment": failedService.Deployment.Name,
+		"pod":        pod,
+		"container":  container,
+		"from":       strconv.FormatInt(from.UnixMilli(), 10),
+		"to":         strconv.FormatInt(to.UnixMilli(), 10),
+		"from_iso":   from.UTC().Format(time.RFC3339),
+		"to_iso":     to.UTC().Format(time.RFC3339),
+	}
+}
+
+// Expand replaces {name} placeholders in a URL template with query-escaped
+// values. Unknown placeholders and other braces (e.g. JSON in Grafana explore
+// URLs) are left untouched.
+func Expand(tmpl string, vars map[string]string) string {
+	pairs := make([]string, 0, len(vars)*2)
+	for name, value := range vars {
+		pairs = append(pairs, "{"+name+"}", url.QueryEscape(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(tmpl)
+}
+
+// DashboardURL returns the deployment's dashboard_url annotation, or the
+// expanded config template.
+func DashboardURL(tmpl string, vars map[string]string, dep health.DeploymentInfo) string {
+	if u := dep.Annotations[DashboardAnnotation]; u != "" {
+		return u
+	}
+	if tmpl == "" {
+		return ""
+	}
+	return Expand(tmpl, vars)
+}
+
+// Render expands every configured link template.
+func Render(templates []config.LinkTemplate, vars map[string]string) []health.Link {
+	var rendered []health.Link
+	for _, t := range templates {
+		if t.URL == "" {
+			continue
+		}
+		rendered = append(rendered, health.Link{
+			Name: t.Name,
+			URL:  Expand(t.URL, vars),
+		})
+	}
+	return rendered
+}
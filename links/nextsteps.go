@@ -0,0 +1,33 @@
+package links
+
+import (
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/health"
+)
+
+// NextSteps returns the remediation page for a failure classification, or nil
+// if its category has none.
+func NextSteps(cfg config.RemediationPagesConfig, vars map[string]string, class string) *health.NextSteps {
+	category := cfg.Categories[class]
+	if category == "" {
+		category = health.Category(class)
+	}
+	if category == "" {
+		return nil
+	}
+	tmpl := cfg.Pages[category]
+	if tmpl == "" {
+		tmpl = cfg.URL
+	}
+	if tmpl == "" {
+		return nil
+	}
+
+	expanded := make(map[string]string, len(vars)+2)
+	for name, value := range vars {
+		expanded[name] = value
+	}
+	expanded["category"] = category
+	expanded["classification"] = class
+	return &health.NextSteps{Category: category, URL: Expand(tmpl, expanded)}
+}
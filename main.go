@@ -2,97 +2,381 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"log"
-	"time"
+	"os"
+	"path/filepath"
+	"strings"
 
-	"k8s-health-monitor/config"
-	"k8s-health-monitor/email"
-	"k8s-health-monitor/health"
-	"k8s-health-monitor/kubernetes"
+	k8s "k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/alertmanager"
+	"github.com/Bharath-H-R/k8s-health/archive"
+	"github.com/Bharath-H-R/k8s-health/auth"
+	"github.com/Bharath-H-R/k8s-health/backstage"
+	"github.com/Bharath-H-R/k8s-health/cmdb"
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/debug"
+	"github.com/Bharath-H-R/k8s-health/email"
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/imagemeta"
+	"github.com/Bharath-H-R/k8s-health/kubernetes"
+	"github.com/Bharath-H-R/k8s-health/outbox"
+	"github.com/Bharath-H-R/k8s-health/remediation"
+	"github.com/Bharath-H-R/k8s-health/runbook"
+	"github.com/Bharath-H-R/k8s-health/runlock"
+	"github.com/Bharath-H-R/k8s-health/sharding"
+	"github.com/Bharath-H-R/k8s-health/slack"
+	"github.com/Bharath-H-R/k8s-health/state"
+	"github.com/Bharath-H-R/k8s-health/transport"
+	"github.com/Bharath-H-R/k8s-health/usage"
+	"github.com/Bharath-H-R/k8s-health/webhook"
 )
 
 func main() {
+	// An optional subcommand comes before the flags; the default is "run"
+	command, args := "run", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	// silence, ack, recheck and state only touch the state store or a running daemon's
+	// API; render only the templates
+	switch command {
+	case "render":
+		if err := runRenderCommand(args, os.Stdout); err != nil {
+			log.Fatalf("Render failed: %v", err)
+		}
+		return
+	case "silence":
+		if err := runSilenceCommand(args, os.Stdout); err != nil {
+			log.Fatalf("Silence failed: %v", err)
+		}
+		return
+	case "ack":
+		if err := runAckCommand(args, os.Stdout); err != nil {
+			log.Fatalf("Ack failed: %v", err)
+		}
+		return
+	case "recheck":
+		if err := runRecheckCommand(args, os.Stdout); err != nil {
+			log.Fatalf("Recheck failed: %v", err)
+		}
+		return
+	case "state":
+		if err := runStateCommand(args, os.Stdout); err != nil {
+			log.Fatalf("State failed: %v", err)
+		}
+		return
+	}
+
 	// Command line flags
-	dryRun := flag.Bool("dry-run", false, "Dry run without sending emails")
-	configPath := flag.String("config", "./config.yaml", "Path to config file")
-	flag.Parse()
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "Dry run without sending emails")
+	configPath := flags.String("config", "./config.yaml", "Config file path, configmap://namespace/name[/key] or HTTP(S) URL")
+	daemon := flags.Bool("daemon", false, "Run continuously, scanning every daemon.interval")
+	audit := flags.Bool("audit", false, "Send best-practice audit reports instead of health alerts")
+	pprofAddr := flags.String("pprof-addr", "", "With --daemon, serve net/http/pprof on this address, e.g. localhost:6060")
+	format := flags.String("format", reportText, "For scan and report, the output format: text or markdown")
+	owner := flags.String("owner", "", "For scan, report and diff, only check workloads whose owner or DL is this address")
+	team := flags.String("team", "", "For scan, report and diff, only check workloads of this team")
+	drillTo := flags.String("to", "", "For drill, the test recipient (default drill.recipient)")
+	simulate := flags.String("simulate", "", "Scan a fake cluster loaded from the manifests in this directory, writing notifications to its outbox/ instead of sending them")
+	preflight := flags.Bool("preflight", false, "Check API access, RBAC, notifiers, templates and the state store, print a pass/fail table and exit; fails if any check does")
+	profile := flags.String("profile", os.Getenv(config.ProfileEnv), "Config profile to apply, e.g. staging (default $"+config.ProfileEnv+")")
+	flags.Parse(args)
 
-	// Load configuration
-	cfg, err := config.Load(*configPath)
-	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+	// A filtered scan only covers some workloads of each namespace, so it
+	// must not be stored or alert
+	filter := health.OwnerFilter{Owner: strings.TrimSpace(*owner), Team: strings.TrimSpace(*team)}
+	if filter != (health.OwnerFilter{}) && command != "scan" && command != "report" && command != "diff" {
+		log.Fatalf("--owner and --team only apply to scan, report and diff")
 	}
 
 	// Initialize components
 	ctx := context.Background()
 
-	k8sClient, err := kubernetes.NewClient()
+	// Load configuration
+	source := newConfigSource(*configPath)
+	data, err := source.read(ctx)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	cfg, err := config.Parse(data, *profile)
 	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Profile != "" {
+		log.Printf("Using config profile %s", cfg.Profile)
+	}
+	for _, warning := range cfg.Warnings {
+		log.Printf("Warning: config: %s", warning)
+	}
+
+	if err := transport.SetPolicy(cfg.TLSPolicy); err != nil {
+		log.Fatalf("Invalid TLS policy: %v", err)
+	}
+
+	if *preflight {
+		if !runPreflight(ctx, cfg, os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	tracker := usage.NewTracker()
+	var k8sClient k8s.Interface
+	var sink *outbox.Dir
+	if *simulate != "" {
+		// Simulations keep state in memory and don't touch the real
+		// cluster, relay or object store
+		cfg.State.Path = ""
+		cfg.Sharding.Enabled = false
+		cfg.RunLock.Enabled = false
+		cfg.Archive.Bucket = ""
+		cfg.SMTPConfig.CredentialsSecret, cfg.SMTPConfig.CredentialsVaultPath = nil, ""
+		cfg.Slack.CredentialsSecret, cfg.Slack.CredentialsVaultPath = nil, ""
+		if k8sClient, err = loadFixtures(*simulate); err != nil {
+			log.Fatalf("Failed to load fixtures: %v", err)
+		}
+		if sink, err = outbox.New(filepath.Join(*simulate, "outbox")); err != nil {
+			log.Fatalf("Failed to create outbox: %v", err)
+		}
+		log.Printf("Simulating a cluster from %s; notifications go to %s", *simulate, sink.Path())
+	} else if k8sClient, err = kubernetes.NewClient(tracker.WrapTransport); err != nil {
 		log.Fatalf("Failed to create Kubernetes client: %v", err)
 	}
 
-	scanner := kubernetes.NewScanner(k8sClient, cfg.ExcludedNamespaces)
-	healthChecker := health.NewChecker()
-	emailSender := email.NewSender(cfg.SMTPConfig)
+	credentials, err := newCredentialSources(k8sClient, cfg)
+	if err != nil {
+		log.Fatalf("Invalid credentials config: %v", err)
+	}
+	if err := credentials.load(ctx, cfg); err != nil {
+		log.Fatalf("Failed to load credentials: %v", err)
+	}
 
-	// Run health check
-	log.Println("Starting Kubernetes service health check...")
-	startTime := time.Now()
+	emailSender, err := email.NewSender(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create email sender: %v", err)
+	}
+	if sink != nil {
+		emailSender.SetOutbox(sink)
+	} else if cfg.TLSPolicy.ValidateOnStartup {
+		if err := emailSender.CheckRelay(); err != nil {
+			log.Fatalf("SMTP relay check failed: %v", err)
+		}
+	}
+
+	restarter, err := remediation.NewRestarter(k8sClient, cfg.Remediation, *dryRun)
+	if err != nil {
+		log.Fatalf("Failed to create remediation restarter: %v", err)
+	}
 
-	deployments, err := scanner.ScanDeployments(ctx)
+	store, err := state.Open(cfg.State.Path)
 	if err != nil {
-		log.Fatalf("Failed to scan deployments: %v", err)
+		log.Fatalf("Failed to open state store: %v", err)
+	}
+	store.SetCompressed(cfg.State.Compression == config.StateCompressionGzip)
+	if *dryRun && cfg.State.Path != "" {
+		// Dry runs read the real state but leave it to the next real run
+		store.Detach()
+		log.Printf("Dry run: state changes are kept in memory, %s is not updated", cfg.State.Path)
+	}
+
+	emailSender.SetBounceChecker(func(address string) bool {
+		_, bounced := store.Bounced(address)
+		return bounced
+	})
+
+	var slackNotifier *slack.Notifier
+	if cfg.Slack.WebhookURL != "" {
+		slackNotifier, err = slack.NewNotifier(cfg.Slack, transport.Proxy(cfg.Proxy, cfg.Slack.Proxy))
+		if err != nil {
+			log.Fatalf("Failed to create slack notifier: %v", err)
+		}
+		if sink != nil {
+			slackNotifier.SetOutbox(sink)
+		}
 	}
 
-	// Check health for each deployment
-	var failedServices []health.FailedService
-	for _, dep := range deployments {
-		if dep.OwnerEmail == "" || dep.OwnerDlEmail == "" {
-			log.Printf("Warning: Deployment %s/%s missing owner annotations", dep.Namespace, dep.Name)
-			continue
+	var webhookNotifier *webhook.Notifier
+	if len(cfg.Webhooks) > 0 {
+		webhookNotifier, err = webhook.NewNotifier(cfg.Webhooks, cfg.Proxy)
+		if err != nil {
+			log.Fatalf("Failed to create webhook notifier: %v", err)
+		}
+		if sink != nil {
+			webhookNotifier.SetOutbox(sink)
 		}
+	}
+
+	scanner := kubernetes.NewScanner(k8sClient, cfg.ExcludedNamespaces)
+	scanner.SetEmailValidator(health.NewEmailValidator(cfg.AllowedEmailDomains))
+	scanner.SetLegacyKinds(cfg.Scan.ReplicaSets, cfg.Scan.Pods)
+	if cfg.Metadata.Source != config.MetadataAnnotations {
+		scanner.SetMetadataReader(health.NewMetadataReader(cfg.Metadata))
+	}
+	if cfg.Backstage.BaseURL != "" {
+		scanner.AddOwnerResolver(backstage.NewClient(cfg.Backstage))
+	}
+
+	var cmdbClient *cmdb.Client
+	if cfg.CMDB.BaseURL != "" {
+		cmdbClient = cmdb.NewClient(cfg.CMDB)
+	}
+
+	checker := health.NewChecker()
+	policy := health.DefaultPolicy()
+	policy.NotReadyGrace, policy.NotReadyCritical = cfg.NotReady.Grace, cfg.NotReady.CriticalAfter
+	policy.CheckStopped = cfg.AlertOnStopped
+	policy.StuckTerminating = cfg.StuckPodThreshold
+	checker.SetPolicy(policy)
 
-		isHealthy, failureReason, podLogs, err := healthChecker.CheckDeploymentHealth(ctx, k8sClient, dep)
+	m := &monitor{
+		cfg:          cfg,
+		dryRun:       *dryRun,
+		k8sClient:    k8sClient,
+		scanner:      scanner,
+		checker:      checker,
+		suggester:    health.NewSuggester(k8sClient),
+		restarter:    restarter,
+		emailSender:  emailSender,
+		slack:        slackNotifier,
+		webhooks:     webhookNotifier,
+		store:        store,
+		cmdb:         cmdbClient,
+		runbooks:     runbook.NewResolver(k8sClient, cfg.KnowledgeBase),
+		usage:        tracker,
+		metrics:      newCheckMetrics(),
+		configSource: source,
+	}
+	var authenticators auth.Chain
+	if len(cfg.APIAuth.Tokens) > 0 {
+		authenticators = append(authenticators, auth.NewTokenAuthenticator(cfg.APIAuth.Tokens))
+	}
+	if cfg.APIAuth.OIDC.Issuer != "" {
+		m.oidc = auth.NewOIDC(cfg.APIAuth.OIDC)
+		authenticators = append(authenticators, m.oidc)
+	}
+	if len(authenticators) > 0 {
+		m.authn = authenticators
+	}
+	if cfg.Archive.Bucket != "" {
+		if m.archiver, err = archive.New(cfg.Archive, cfg.Proxy, k8sClient, cfg.ClusterName); err != nil {
+			log.Fatalf("Failed to create archiver: %v", err)
+		}
+	}
+	if cfg.Debug.Image != "" && cfg.Debug.LaunchToken != "" {
+		m.debugger = debug.NewLauncher(k8sClient, cfg.Debug)
+	}
+	if cfg.ImageAdvisory.BaseURL != "" {
+		m.images = imagemeta.NewClient(cfg.ImageAdvisory)
+	}
+	if cfg.Alertmanager.URL != "" {
+		m.silences = newSilenceSync(alertmanager.NewClient(cfg.Alertmanager))
+	}
+
+	m.watchCredentials(ctx, credentials)
+
+	if cfg.Sharding.Enabled {
+		identity, err := leaseIdentity(cfg.Sharding.Identity)
 		if err != nil {
-			log.Printf("Error checking health for %s/%s: %v", dep.Namespace, dep.Name, err)
-			continue
-		}
-
-		if !isHealthy {
-			failedServices = append(failedServices, health.FailedService{
-				Deployment:    dep,
-				FailureReason: failureReason,
-				PodLogs:       podLogs,
-				CheckTime:     time.Now(),
-			})
-		}
-	}
-
-	// Send notifications for failed services
-	if len(failedServices) > 0 && !*dryRun {
-		log.Printf("Found %d unhealthy services, sending notifications...", len(failedServices))
-
-		for _, failedService := range failedServices {
-			err := emailSender.SendHealthAlert(failedService)
-			if err != nil {
-				log.Printf("Failed to send email for %s/%s: %v",
-					failedService.Deployment.Namespace,
-					failedService.Deployment.Name,
-					err)
-			} else {
-				log.Printf("Notification sent for %s/%s",
-					failedService.Deployment.Namespace,
-					failedService.Deployment.Name)
+			log.Fatalf("Failed to determine shard identity: %v", err)
+		}
+		m.shards = sharding.New(k8sClient, cfg.Sharding, identity)
+		scanner.SetNamespaceFilter(m.shards.Owns)
+	}
+	if filter != (health.OwnerFilter{}) {
+		scanner.SetWorkloadFilter(filter.Matches)
+	}
+
+	switch command {
+	case "run":
+		if cfg.RunLock.Enabled && (*audit || !*daemon) {
+			lock := acquireRunLock(ctx, k8sClient, cfg.RunLock)
+			defer func() {
+				if err := lock.Release(context.Background()); err != nil {
+					log.Printf("Warning: failed to release run lock: %v", err)
+				}
+			}()
+		}
+		m.testChannels()
+		if *audit {
+			m.runAudit(ctx)
+		} else if *daemon {
+			if *pprofAddr != "" {
+				servePprof(*pprofAddr)
+			}
+			runDaemon(ctx, m)
+			if m.configChanged {
+				if err := restart(); err != nil {
+					log.Fatalf("Failed to restart: %v", err)
+				}
 			}
-			// Small delay to avoid overwhelming SMTP server
-			time.Sleep(100 * time.Millisecond)
+		} else {
+			m.runOnce(ctx)
+		}
+	case "diff":
+		if err := m.runDiff(ctx, os.Stdout); err != nil {
+			log.Fatalf("Diff failed: %v", err)
 		}
-	} else if *dryRun {
-		log.Printf("Dry run: Found %d unhealthy services (no emails sent)", len(failedServices))
-	} else {
-		log.Println("All services are healthy!")
+	case "scan":
+		if err := m.runReport(ctx, *format, os.Stdout); err != nil {
+			log.Fatalf("Scan failed: %v", err)
+		}
+	case "report":
+		if err := m.runReport(ctx, *format, os.Stdout); err != nil {
+			log.Fatalf("Report failed: %v", err)
+		}
+	case "drill":
+		if err := m.runDrill(ctx, *drillTo); err != nil {
+			log.Fatalf("Drill failed: %v", err)
+		}
+	default:
+		log.Fatalf("Unknown command %q (expected run, scan, diff, report, drill, silence, ack, recheck, state or render)", command)
 	}
+}
 
-	log.Printf("Health check completed in %v", time.Since(startTime))
+// leaseIdentity names this instance in Leases: the configured identity, else
+// $POD_NAME, else the hostname.
+func leaseIdentity(configured string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+	if name := os.Getenv("POD_NAME"); name != "" {
+		return name, nil
+	}
+	return os.Hostname()
+}
+
+// acquireRunLock takes the run lock for a one-shot run. If the previous run
+// still holds it, this run exits cleanly, or waits for it with on_conflict:
+// wait.
+func acquireRunLock(ctx context.Context, client k8s.Interface, cfg config.RunLockConfig) *runlock.Lock {
+	identity, err := leaseIdentity("")
+	if err != nil {
+		log.Fatalf("Failed to determine run lock identity: %v", err)
+	}
+	lock := runlock.New(client, cfg, identity)
+
+	if cfg.OnConflict == config.RunLockWait {
+		waitCtx, cancel := context.WithTimeout(ctx, cfg.WaitTimeout)
+		defer cancel()
+		if err := lock.Wait(waitCtx); err != nil {
+			log.Fatalf("Failed to acquire run lock %s/%s: %v", cfg.LeaseNamespace, cfg.Name, err)
+		}
+		return lock
+	}
+
+	err = lock.Acquire(ctx)
+	var held *runlock.HeldError
+	if errors.As(err, &held) {
+		log.Printf("Skipping this run: %v; it is probably still scanning, so this run exits to avoid sending duplicate alerts", err)
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("Failed to acquire run lock %s/%s: %v", cfg.LeaseNamespace, cfg.Name, err)
+	}
+	return lock
 }
@@ -0,0 +1,111 @@
+// Package metrics implements the few Prometheus metric types the monitor
+// exposes, written in the text exposition format without a client library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are latency bucket bounds in seconds, from a fast API call
+// to a slow log fetch.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Histogram counts durations into buckets. It is safe for concurrent use.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func NewHistogram(buckets []float64) *Histogram {
+	return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *Histogram) Observe(d time.Duration) {
+	seconds := d.Seconds()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, bound := range h.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// Write writes the histogram with cumulative buckets.
+func (h *Histogram) Write(w io.Writer, name, help string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	var cumulative uint64
+	for i, bound := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%g\"} %d\n", name, bound, cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(w, "%s_sum %g\n%s_count %d\n", name, h.sum, name, h.count)
+}
+
+// CounterVec is a counter partitioned by label values. It is safe for
+// concurrent use.
+type CounterVec struct {
+	mu     sync.Mutex
+	labels []string
+	values map[string]uint64
+}
+
+func NewCounterVec(labels ...string) *CounterVec {
+	return &CounterVec{labels: labels, values: make(map[string]uint64)}
+}
+
+// Inc increments the counter for the label values, given in the order of
+// the label names.
+func (c *CounterVec) Inc(values ...string) {
+	pairs := make([]string, len(c.labels))
+	for i, label := range c.labels {
+		var value string
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs[i] = fmt.Sprintf("%s=\"%s\"", label, escape(value))
+	}
+
+	c.mu.Lock()
+	c.values[strings.Join(pairs, ",")]++
+	c.mu.Unlock()
+}
+
+// Write writes one sample per label combination, sorted for stable output.
+func (c *CounterVec) Write(w io.Writer, name, help string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s{%s} %d\n", name, key, c.values[key])
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(value string) string {
+	return labelEscaper.Replace(value)
+}
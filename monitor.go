@@ -0,0 +1,746 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	k8s "k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/archive"
+	"github.com/Bharath-H-R/k8s-health/auth"
+	"github.com/Bharath-H-R/k8s-health/cmdb"
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/debug"
+	"github.com/Bharath-H-R/k8s-health/email"
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/i18n"
+	"github.com/Bharath-H-R/k8s-health/imagemeta"
+	"github.com/Bharath-H-R/k8s-health/kubernetes"
+	"github.com/Bharath-H-R/k8s-health/links"
+	"github.com/Bharath-H-R/k8s-health/payload"
+	"github.com/Bharath-H-R/k8s-health/remediation"
+	"github.com/Bharath-H-R/k8s-health/runbook"
+	"github.com/Bharath-H-R/k8s-health/sharding"
+	"github.com/Bharath-H-R/k8s-health/slack"
+	"github.com/Bharath-H-R/k8s-health/state"
+	"github.com/Bharath-H-R/k8s-health/usage"
+	"github.com/Bharath-H-R/k8s-health/webhook"
+)
+
+// monitor wires the scanner, checker and notifiers together for a single run.
+type monitor struct {
+	cfg         *config.Config
+	dryRun      bool
+	k8sClient   k8s.Interface
+	scanner     *kubernetes.Scanner
+	checker     *health.Checker
+	suggester   *health.Suggester
+	restarter   *remediation.Restarter
+	emailSender *email.Sender
+	slack       *slack.Notifier
+	webhooks    *webhook.Notifier
+	store       *state.Store
+	cmdb        *cmdb.Client
+	runbooks    *runbook.Resolver
+	usage       *usage.Tracker
+	metrics     *checkMetrics
+	shards      *sharding.Sharder
+	debugger    *debug.Launcher
+	archiver    *archive.Archiver
+	// images advises owners of failing workloads about their images in
+	// audit reports; nil disables advisories
+	images *imagemeta.Client
+	// incremental skips healthy deployments unchanged since the last scan;
+	// nil checks every deployment
+	incremental *changeTracker
+	// external holds failures reported through the ingest API; nil when
+	// ingestion is off
+	external *externalReports
+	// silences syncs silences with Alertmanager in daemon mode; nil when
+	// sync is off
+	silences *silenceSync
+	// authn authenticates REST API and dashboard users; nil leaves both open
+	authn auth.Authenticator
+	oidc  *auth.OIDC
+	// configSource is polled for config changes in daemon mode;
+	// configChanged is set when the daemon stops to apply one
+	configSource  *configSource
+	configChanged bool
+
+	// scanMu serializes scans and rechecks, which share the scanner
+	scanMu sync.Mutex
+	// clusterNotified is when each ongoing cluster-level incident was last
+	// alerted, keyed by namespace ("" for the cluster); guarded by scanMu
+	clusterNotified map[string]time.Time
+
+	mu      sync.Mutex
+	lastRun *runSummary
+	// channels are the results of the last notification channel self-test
+	channels []channelStatus
+
+	// background tracks notifications sent outside scans, e.g. maintenance
+	// invites, for shutdown to wait for
+	background sync.WaitGroup
+}
+
+// checkedDeployment pairs a deployment with its health check result.
+type checkedDeployment struct {
+	dep    health.DeploymentInfo
+	result *health.CheckResult
+}
+
+// check scans the namespaces in scope (all if nil) and checks every owned
+// deployment. It has no side effects beyond reading from the API server. If
+// ctx ends mid-scan, the deployments checked so far are returned with the
+// error.
+func (m *monitor) check(ctx context.Context, scope func(namespace string) bool) ([]checkedDeployment, error) {
+	var checked []checkedDeployment
+	err := m.checkEach(ctx, scope, func(c checkedDeployment) {
+		checked = append(checked, c)
+	})
+	// A scan stopped by ctx still returns what it checked
+	return checked, err
+}
+
+// checkEach is check, calling fn with each deployment as soon as it has been
+// checked instead of collecting them.
+func (m *monitor) checkEach(ctx context.Context, scope func(namespace string) bool, fn func(checkedDeployment)) error {
+	return m.scanner.ScanEach(ctx, scope, func(dep health.DeploymentInfo) error {
+		var versions string
+		if m.incremental != nil {
+			versions = m.incremental.versions(ctx, dep)
+			// Workloads other systems reported failing are always checked
+			if _, reported := m.external.open(dep, time.Now()); !reported {
+				if result, ok := m.incremental.unchanged(dep, versions); ok {
+					fn(checkedDeployment{dep: dep, result: result})
+					return ctx.Err()
+				}
+			}
+		}
+		if c, ok := m.checkDeployment(ctx, dep); ok {
+			if m.incremental != nil {
+				m.incremental.record(dep, versions, c.result)
+			}
+			fn(c)
+		}
+		return ctx.Err()
+	})
+}
+
+// checkDeployments checks the health of the given deployments.
+func (m *monitor) checkDeployments(ctx context.Context, deployments []health.DeploymentInfo) []checkedDeployment {
+	var checked []checkedDeployment
+	for _, dep := range deployments {
+		if c, ok := m.checkDeployment(ctx, dep); ok {
+			checked = append(checked, c)
+		}
+	}
+	return checked
+}
+
+// checkDeployment checks one deployment. It returns false if the deployment
+// lacks owners or couldn't be checked.
+func (m *monitor) checkDeployment(ctx context.Context, dep health.DeploymentInfo) (checkedDeployment, bool) {
+	if dep.OwnerEmail == "" || dep.OwnerDlEmail == "" {
+		log.Printf("Warning: Deployment %s/%s missing owner annotations", dep.Namespace, dep.Name)
+		return checkedDeployment{}, false
+	}
+
+	start := time.Now()
+	result, err := m.checker.CheckDeploymentHealth(ctx, m.k8sClient, dep)
+	m.metrics.checkLatency.Observe(time.Since(start))
+	if err != nil {
+		log.Printf("Error checking health for %s/%s: %v", dep.Namespace, dep.Name, err)
+		return checkedDeployment{}, false
+	}
+	if report, ok := m.external.open(dep, time.Now()); ok {
+		applyExternalReport(ctx, m.k8sClient, dep, result, report)
+	}
+	m.metrics.checks.Inc(dep.Namespace)
+	if !result.Healthy {
+		m.metrics.failures.Inc(dep.Namespace, result.Classification)
+	}
+
+	return checkedDeployment{dep: dep, result: result}, true
+}
+
+// scanResults converts check results into the form stored between runs.
+func scanResults(checked []checkedDeployment, now time.Time) map[string]state.ScanResult {
+	results := make(map[string]state.ScanResult, len(checked))
+	for _, c := range checked {
+		results[state.Key(c.dep.Namespace, c.dep.Name)] = state.ScanResult{
+			Namespace:      c.dep.Namespace,
+			Deployment:     c.dep.Name,
+			Healthy:        c.result.Healthy,
+			Reason:         c.result.FailureReason,
+			Classification: c.result.Classification,
+			CheckedAt:      now,
+			Stopped:        c.result.Stopped,
+		}
+	}
+	return results
+}
+
+// runOnce scans the whole cluster, alerts on failures and returns the results.
+func (m *monitor) runOnce(ctx context.Context) []checkedDeployment {
+	return m.runScan(ctx, nil)
+}
+
+// runScan scans the namespaces in scope, or the whole cluster if scope is
+// nil, alerts on failures and returns the results.
+func (m *monitor) runScan(ctx context.Context, scope func(namespace string) bool) []checkedDeployment {
+	m.scanMu.Lock()
+	defer m.scanMu.Unlock()
+
+	// Run health check
+	log.Println("Starting Kubernetes service health check...")
+	startTime := time.Now()
+	startUsage := m.usage.Snapshot()
+
+	if m.shards != nil {
+		if err := m.shards.Sync(ctx); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	scanCtx, cancel := m.scanContext(ctx)
+	defer cancel()
+
+	if m.incremental != nil {
+		m.incremental.begin(startTime)
+	}
+
+	if m.cfg.Scan.Stream {
+		return m.streamScan(ctx, scanCtx, scope, startTime, startUsage)
+	}
+
+	checked, err := m.check(scanCtx, scope)
+	scope, ok := m.partialScope(ctx, err, scope)
+	if !ok {
+		log.Printf("Failed to scan deployments: %v", err)
+		return nil
+	}
+
+	invalidOwners := m.reportScanProblems(scope)
+
+	changes, err := m.store.SaveScanIn(scanResults(checked, startTime), scope)
+	if err != nil {
+		log.Printf("Failed to save scan results: %v", err)
+	}
+	m.handleResults(ctx, checked, changes)
+	m.collectGarbage(checked, scope)
+
+	m.finishRun(startTime, startUsage, len(changes), invalidOwners)
+	return checked
+}
+
+// reportScanProblems reports the stuck namespaces and invalid owner
+// annotations the scan of scope found, returning the latter.
+func (m *monitor) reportScanProblems(scope func(namespace string) bool) []health.InvalidOwner {
+	m.reportStuckNamespaces(m.scanner.TerminatingNamespaces(), scope)
+	invalidOwners := m.scanner.InvalidOwners()
+	for _, owner := range invalidOwners {
+		log.Printf("Warning: %s/%s has invalid %s annotation %q: %s",
+			owner.Namespace, owner.Deployment, owner.Annotation, owner.Value, owner.Reason)
+	}
+	return invalidOwners
+}
+
+// scanContext bounds the checking part of a scan by scan.deadline.
+func (m *monitor) scanContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.cfg.Scan.Deadline <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, m.cfg.Scan.Deadline)
+}
+
+// partialScope decides what to do with a scan that returned err. A scan
+// stopped by its deadline (but not by ctx, e.g. on shutdown) is still handled,
+// with scope narrowed to leave the skipped namespaces' state alone; any other
+// error fails the scan.
+func (m *monitor) partialScope(ctx context.Context, err error, scope func(namespace string) bool) (func(namespace string) bool, bool) {
+	if err == nil {
+		return scope, true
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+		return scope, false
+	}
+
+	// Nothing was scanned if the deadline hit listing namespaces
+	skipped := m.scanner.SkippedNamespaces()
+	if len(skipped) == 0 {
+		return scope, false
+	}
+	log.Printf("Warning: scan deadline of %v exceeded; results are INCOMPLETE, %d namespace(s) skipped: %s",
+		m.cfg.Scan.Deadline, len(skipped), strings.Join(skipped, ", "))
+	skip := make(map[string]bool, len(skipped))
+	for _, ns := range skipped {
+		skip[ns] = true
+	}
+	return func(namespace string) bool {
+		return !skip[namespace] && (scope == nil || scope(namespace))
+	}, true
+}
+
+// finishRun records and logs a completed scan.
+func (m *monitor) finishRun(startTime time.Time, startUsage usage.Usage, changes int, invalidOwners []health.InvalidOwner) {
+	runUsage := m.usage.Snapshot().Sub(startUsage)
+	summary := runSummary{StartedAt: startTime, Duration: time.Since(startTime), Usage: runUsage, InvalidOwners: invalidOwners,
+		BrokenChannels: m.brokenChannels()}
+	status := "completed"
+	if skipped := m.scanner.SkippedNamespaces(); len(skipped) > 0 {
+		summary.Incomplete, summary.SkippedNamespaces = true, skipped
+		status = fmt.Sprintf("INCOMPLETE, %d namespace(s) skipped,", len(skipped))
+	}
+	if m.incremental != nil {
+		summary.Unchanged = m.incremental.skipped
+		log.Printf("Skipped %d healthy deployment(s) unchanged since the last scan", summary.Unchanged)
+	}
+	m.setLastRun(summary)
+	log.Printf("Health check %s in %v (%d state change(s), %d API request(s), %d log byte(s), %d notification(s), %d invalid owner annotation(s))",
+		status, time.Since(startTime), changes, runUsage.APIRequests, runUsage.LogBytes, runUsage.Notifications, len(invalidOwners))
+}
+
+// collectGarbage prunes state for workloads missing from a scan of the
+// namespaces in scope. Namespaces out of scope or owned by other shards are
+// left alone.
+func (m *monitor) collectGarbage(checked []checkedDeployment, scope func(namespace string) bool) {
+	seen := make(map[string]bool, len(checked))
+	for _, c := range checked {
+		seen[state.Key(c.dep.Namespace, c.dep.Name)] = true
+	}
+	for _, ns := range m.scanner.TerminatingNamespaces() {
+		seen[state.Key(ns.Name, "")] = true
+	}
+
+	present := func(namespace, deployment string) bool {
+		if m.shards != nil && !m.shards.Owns(namespace) {
+			return true
+		}
+		if scope != nil && !scope(namespace) {
+			return true
+		}
+		return seen[state.Key(namespace, deployment)]
+	}
+
+	if m.archiver != nil {
+		if removed, err := m.archiver.Prune(context.Background(), m.cfg.Archive.Retention, time.Now()); err != nil {
+			log.Printf("Failed to prune archives: %v", err)
+		} else if removed > 0 {
+			log.Printf("Pruned %d archived objects", removed)
+		}
+	}
+
+	if m.cfg.LogSpillDir != "" {
+		if err := health.PruneSpillDir(m.cfg.LogSpillDir, m.cfg.State.Retention, time.Now()); err != nil {
+			log.Printf("Failed to prune spilled logs: %v", err)
+		}
+	}
+
+	retention := state.Retention{
+		Incidents: m.cfg.State.HistoryRetention,
+		Crashes:   m.cfg.State.CrashRetention,
+		Rollups:   m.cfg.State.RollupRetention,
+	}
+	if removed, err := m.store.PruneHistory(retention, time.Now()); err != nil {
+		log.Printf("Failed to prune incident history: %v", err)
+	} else if removed > 0 {
+		log.Printf("Pruned %d history records", removed)
+	}
+
+	removed, err := m.store.GC(present, m.cfg.State.Retention, time.Now())
+	if err != nil {
+		log.Printf("Failed to prune state: %v", err)
+	} else if removed > 0 {
+		log.Printf("Pruned %d stale state entries", removed)
+	}
+}
+
+// recheck checks a subset of deployments between full scans and alerts on
+// them the same way a full scan would.
+func (m *monitor) recheck(ctx context.Context, deployments []health.DeploymentInfo) []checkedDeployment {
+	m.scanMu.Lock()
+	defer m.scanMu.Unlock()
+
+	checked := m.checkDeployments(ctx, deployments)
+
+	changes, err := m.store.MergeScan(scanResults(checked, time.Now()))
+	if err != nil {
+		log.Printf("Failed to save scan results: %v", err)
+	}
+	m.handleResults(ctx, checked, changes)
+	return checked
+}
+
+// handleResults resolves recovered incidents and enriches and notifies
+// failures. changes are the state changes since the previous scan.
+func (m *monitor) handleResults(ctx context.Context, checked []checkedDeployment, changes []state.Change) {
+	m.observeRestarts(checked)
+
+	// Fetch logs for all failing pods up front, concurrently
+	var failed []*health.CheckResult
+	for _, c := range checked {
+		if !c.result.Healthy {
+			failed = append(failed, c.result)
+		}
+	}
+	fetcher := health.NewLogFetcher(m.k8sClient, m.cfg.LogTailLines, m.cfg.LogLimitBytes, m.cfg.LogFetchWorkers)
+	fetcher.SetLatencyObserver(m.metrics.logFetchLatency.Observe)
+	fetcher.SetMemoryLimits(m.cfg.LogBufferBytes, m.cfg.LogRunBudgetBytes)
+	if m.cfg.LogSpillDir != "" {
+		fetcher.SetSpillDir(m.cfg.LogSpillDir, m.cfg.LogSpillLimitBytes)
+	}
+	fetcher.FetchAll(ctx, failed)
+
+	changed := make(map[string]string, len(changes))
+	for _, change := range changes {
+		changed[state.Key(change.Current.Namespace, change.Current.Deployment)] = change.Kind
+	}
+
+	hotspots := m.findHotspots(ctx, checked)
+	clusterIncidents, covered := m.detectClusterIncidents(checked)
+	m.notifyClusterIncidents(clusterIncidents)
+
+	var failedServices []health.FailedService
+	for _, c := range checked {
+		dep, result := c.dep, c.result
+
+		if result.Healthy {
+			incident, resolved, err := m.store.Resolve(dep.Namespace, dep.Name)
+			if err != nil {
+				log.Printf("Failed to resolve incident for %s/%s: %v", dep.Namespace, dep.Name, err)
+			} else if resolved {
+				log.Printf("Service %s/%s recovered", dep.Namespace, dep.Name)
+			}
+			if changed[state.Key(dep.Namespace, dep.Name)] == state.ChangeRecovered {
+				m.notifyRecovery(dep, incident)
+			}
+			continue
+		}
+
+		incident, err := m.store.RecordFailure(dep.Namespace, dep.Name, result.FailureReason, time.Now())
+		if err != nil {
+			log.Printf("Failed to record incident for %s/%s: %v", dep.Namespace, dep.Name, err)
+		}
+
+		suggestions := m.suggester.Suggest(ctx, dep, result)
+		failedService := health.FailedService{
+			Deployment:     dep,
+			FailureReason:  result.FailureReason,
+			Classification: result.Classification,
+			PodLogs:        result.PodLogs,
+			StructuredLogs: m.parseLogs(result.PodLogs),
+			Pods:           result.Pods,
+			TotalPods:      result.TotalPods,
+			CheckTime:      time.Now(),
+			Remediations:   m.restarter.Remediate(ctx, dep),
+			Suggestions:    suggestions,
+			Locale:         i18n.Resolve(dep.Annotations, m.cfg.DefaultLocale),
+			IncidentID:     incident.ID(m.cfg.ClusterName),
+			Hotspot:        hotspotFor(hotspots, result.Pods),
+			Restarts:       m.recentRestarts(dep),
+			Severity:       result.Severity,
+			NotReadyFor:    result.NotReadyFor,
+		}
+
+		failingSince := incident.StartedAt
+		if failingSince.IsZero() {
+			failingSince = failedService.CheckTime
+		}
+		if failedService.Probation = m.probation(dep, failingSince, failedService.CheckTime); failedService.Probation > 0 {
+			failedService.Severity = health.SeverityWarning
+		}
+
+		if debug.Critical(m.cfg.Debug, failedService.Classification) && result.Pod != "" {
+			failedService.DebugCommand = debug.Command(m.cfg.Debug.Image, dep.Namespace, result.Pod, result.Container)
+		}
+
+		linkVars := links.Vars(m.cfg.ClusterName, failedService, result.Pod, result.Container, m.cfg.LogLinks.TimeWindow)
+		failedService.LogLinks = links.Render(m.cfg.LogLinks.Links, linkVars)
+		failedService.DashboardURL = links.DashboardURL(m.cfg.DashboardURLTemplate, linkVars, dep)
+		failedService.Change = links.Change(m.cfg.ChangeLinks, linkVars, dep)
+		failedService.NextSteps = links.NextSteps(m.cfg.RemediationPages, linkVars, failedService.Classification)
+
+		if err := m.runbooks.Resolve(ctx, &failedService); err != nil {
+			log.Printf("Warning: runbook for %s/%s: %v", dep.Namespace, dep.Name, err)
+		}
+
+		if m.cmdb != nil {
+			if err := m.cmdb.Enrich(ctx, &failedService); err != nil {
+				log.Printf("Warning: CMDB lookup for %s/%s failed: %v", dep.Namespace, dep.Name, err)
+			}
+		}
+		failedServices = append(failedServices, failedService)
+	}
+
+	// Send notifications for failed services
+	if len(failedServices) > 0 && !m.dryRun {
+		log.Printf("Found %d unhealthy services, sending notifications...", len(failedServices))
+
+		for _, failedService := range failedServices {
+			dep := failedService.Deployment
+			if covered[state.Key(dep.Namespace, dep.Name)] {
+				log.Printf("Skipping notification for %s/%s: part of a cluster-level incident", dep.Namespace, dep.Name)
+				continue
+			}
+			m.notify(ctx, failedService, changed[state.Key(dep.Namespace, dep.Name)])
+			// Small delay to avoid overwhelming SMTP server
+			time.Sleep(100 * time.Millisecond)
+		}
+		m.reportBreaker()
+	} else if m.dryRun {
+		log.Printf("Dry run: Found %d unhealthy services (no emails sent)", len(failedServices))
+	} else {
+		log.Println("All services are healthy!")
+	}
+}
+
+// parseLogs extracts structured fields from JSON logs using the configured
+// field names, or returns nil to fall back to raw logs.
+func (m *monitor) parseLogs(raw string) []health.LogEntry {
+	cfg := m.cfg.StructuredLogs
+	if cfg.Disabled {
+		return nil
+	}
+
+	fields := health.DefaultLogFields
+	if len(cfg.TimeFields) > 0 {
+		fields.Time = cfg.TimeFields
+	}
+	if len(cfg.LevelFields) > 0 {
+		fields.Level = cfg.LevelFields
+	}
+	if len(cfg.MessageFields) > 0 {
+		fields.Message = cfg.MessageFields
+	}
+
+	return health.ParseStructuredLogs(raw, fields)
+}
+
+// notifyRecovery tells chat channels and webhooks that a previously failing
+// service is healthy again. The incident is empty if it wasn't tracked.
+func (m *monitor) notifyRecovery(dep health.DeploymentInfo, incident state.Incident) {
+	var incidentID string
+	if !incident.StartedAt.IsZero() {
+		incidentID = incident.ID(m.cfg.ClusterName)
+	}
+
+	if m.dryRun {
+		return
+	}
+	prefs := m.preferences(dep)
+	// Only owners who got the alert need the all-clear, in the same thread
+	if incidentID != "" && !incident.LastNotified.IsZero() && prefs.Allows(health.ChannelEmail) {
+		locale := i18n.Resolve(dep.Annotations, m.cfg.DefaultLocale)
+		if err := m.emailSender.SendRecovery(dep, locale, incidentID, incident.StartedAt, time.Now()); err != nil {
+			log.Printf("Failed to send recovery email for %s/%s: %v", dep.Namespace, dep.Name, err)
+		} else {
+			m.usage.AddNotification()
+		}
+	}
+	if m.slack != nil && prefs.Allows(health.ChannelSlack) {
+		if err := m.slack.PostMessage(strings.TrimSpace(fmt.Sprintf(":large_green_circle: %s/%s recovered %s", dep.Namespace, dep.Name, incidentID))); err != nil {
+			log.Printf("Failed to send slack recovery for %s/%s: %v", dep.Namespace, dep.Name, err)
+		} else {
+			m.usage.AddNotification()
+		}
+	}
+	if m.webhooks != nil && prefs.Allows(health.ChannelWebhook) {
+		if err := m.webhooks.Send(payload.NewRecovery(m.cfg.ClusterName, dep, incidentID, incident.StartedAt, time.Now())); err != nil {
+			log.Printf("Failed to send webhook recovery for %s/%s: %v", dep.Namespace, dep.Name, err)
+		} else {
+			m.usage.AddNotification()
+		}
+	}
+}
+
+// reportStuckNamespaces alerts the infra team about namespaces Terminating for
+// longer than the configured threshold, once per notification cooldown, and
+// resolves the incidents of namespaces in scope that no longer are.
+func (m *monitor) reportStuckNamespaces(terminating []health.StuckNamespace, scope func(namespace string) bool) {
+	now := time.Now()
+	var stuck []health.StuckNamespace
+
+	stillTerminating := make(map[string]bool, len(terminating))
+	for _, ns := range terminating {
+		stillTerminating[ns.Name] = true
+	}
+	for _, incident := range m.store.Incidents() {
+		namespace := incident.Namespace
+		if incident.Deployment != "" || stillTerminating[namespace] ||
+			(m.shards != nil && !m.shards.Owns(namespace)) || (scope != nil && !scope(namespace)) {
+			continue
+		}
+		if _, _, err := m.store.Resolve(namespace, ""); err != nil {
+			log.Printf("Failed to resolve incident for namespace %s: %v", namespace, err)
+			continue
+		}
+		log.Printf("Namespace %s is no longer stuck Terminating", namespace)
+	}
+
+	for _, ns := range terminating {
+		if ns.TerminatingFor < m.cfg.StuckNamespaceThreshold {
+			log.Printf("Namespace %s is terminating, skipping its workloads", ns.Name)
+			continue
+		}
+
+		log.Printf("Warning: namespace %s stuck Terminating for %v", ns.Name, ns.TerminatingFor)
+		if _, err := m.store.RecordFailure(ns.Name, "", "namespace stuck Terminating", now); err != nil {
+			log.Printf("Failed to record incident for namespace %s: %v", ns.Name, err)
+		}
+		if m.store.ShouldNotify(ns.Name, "", m.cfg.State.NotifyCooldown, now) {
+			stuck = append(stuck, ns)
+		}
+	}
+
+	if len(stuck) == 0 || m.dryRun || m.cfg.InfraEmail == "" {
+		return
+	}
+
+	report := health.InfraReport{GeneratedAt: now, StuckNamespaces: stuck}
+	if err := m.emailSender.SendInfraReport(m.cfg.InfraEmail, report, m.cfg.PDB.BlockedThreshold); err != nil {
+		log.Printf("Failed to send stuck namespace alert: %v", err)
+		return
+	}
+
+	m.usage.AddNotification()
+	log.Printf("Stuck namespace alert sent to %s", m.cfg.InfraEmail)
+	for _, ns := range stuck {
+		if err := m.store.MarkNotified(ns.Name, "", now); err != nil {
+			log.Printf("Failed to update state for namespace %s: %v", ns.Name, err)
+		}
+	}
+}
+
+// notify sends a failed service to every configured channel, honoring
+// silences and the per-incident notification cooldown. A change since the
+// previous scan (newly failed or a new reason) bypasses the cooldown.
+func (m *monitor) notify(ctx context.Context, failedService health.FailedService, change string) {
+	dep := failedService.Deployment
+	now := time.Now()
+
+	if silence, ok := m.store.Silenced(dep.Namespace, dep.Name, now); ok {
+		log.Printf("Skipping notification for %s/%s: silenced until %s by %s",
+			dep.Namespace, dep.Name, silence.Until.Format(time.RFC3339), silence.CreatedBy)
+		return
+	}
+
+	prefs := m.preferences(dep)
+	if !prefs.Wants(failedService.Severity) {
+		log.Printf("Skipping notification for %s/%s: owners only want %s alerts",
+			dep.Namespace, dep.Name, prefs.MinSeverity)
+		return
+	}
+
+	for _, upstream := range health.Dependencies(dep, m.cfg.Dependencies.Graph) {
+		namespace, name, _ := strings.Cut(upstream, "/")
+		if _, failing := m.store.Incident(namespace, name); failing {
+			failedService.UpstreamCause = upstream
+			break
+		}
+	}
+	if failedService.UpstreamCause != "" && m.cfg.Dependencies.SuppressDownstream {
+		log.Printf("Skipping notification for %s/%s: likely caused by failing upstream %s",
+			dep.Namespace, dep.Name, failedService.UpstreamCause)
+		return
+	}
+
+	// Acknowledged incidents already have someone on them: reminders stop,
+	// and alerts about a change say who is on it
+	incident, ok := m.store.Incident(dep.Namespace, dep.Name)
+	if ok && incident.AcknowledgedBy != "" && !incident.LastNotified.IsZero() && change == "" {
+		log.Printf("Skipping notification for %s/%s: acknowledged by %s",
+			dep.Namespace, dep.Name, incident.AcknowledgedBy)
+		return
+	}
+	failedService.FollowUp = ok && !incident.LastNotified.IsZero()
+	failedService.AcknowledgedBy = incident.AcknowledgedBy
+	failedService.AcknowledgedAt = incident.AcknowledgedAt
+	if bounce, ok := m.store.Bounced(dep.OwnerEmail); ok {
+		log.Printf("Warning: owner address %s of %s/%s bounced at %s, alerting the DL instead",
+			dep.OwnerEmail, dep.Namespace, dep.Name, bounce.BouncedAt.Format(time.RFC3339))
+		failedService.OwnerBounced = true
+	}
+
+	if change == "" && !m.store.ShouldNotify(dep.Namespace, dep.Name, m.cfg.State.NotifyCooldown, now) {
+		log.Printf("Skipping notification for %s/%s: notified within the last %v",
+			dep.Namespace, dep.Name, m.cfg.State.NotifyCooldown)
+		return
+	}
+
+	// Drills test the channels, so they don't count toward the breaker
+	if !failedService.Drill && !m.allowNotification(dep) {
+		return
+	}
+
+	if m.archiver != nil {
+		m.archive(ctx, &failedService)
+	}
+
+	if prefs.Allows(health.ChannelEmail) {
+		if err := m.emailSender.SendHealthAlert(failedService); err != nil {
+			log.Printf("Failed to send email for %s/%s: %v", dep.Namespace, dep.Name, err)
+		} else {
+			m.usage.AddNotification()
+			log.Printf("Notification sent for %s/%s", dep.Namespace, dep.Name)
+		}
+	}
+
+	// New services on probation only alert their owner, by email
+	if failedService.Probation > 0 {
+		log.Printf("%s/%s is on probation: only the owner was notified", dep.Namespace, dep.Name)
+	} else if m.slack != nil && prefs.Allows(health.ChannelSlack) {
+		if err := m.slack.SendHealthAlert(failedService); err != nil {
+			log.Printf("Failed to send slack alert for %s/%s: %v", dep.Namespace, dep.Name, err)
+		} else {
+			m.usage.AddNotification()
+		}
+	}
+
+	if m.webhooks != nil && failedService.Probation == 0 && prefs.Allows(health.ChannelWebhook) {
+		if err := m.webhooks.Send(payload.NewFailure(m.cfg.ClusterName, failedService)); err != nil {
+			log.Printf("Failed to send webhook alert for %s/%s: %v", dep.Namespace, dep.Name, err)
+		} else {
+			m.usage.AddNotification()
+		}
+	}
+
+	if err := m.store.MarkNotified(dep.Namespace, dep.Name, now); err != nil {
+		log.Printf("Failed to update state for %s/%s: %v", dep.Namespace, dep.Name, err)
+	}
+}
+
+// preferences returns the owners' notification preferences for a workload,
+// logging invalid preference annotations.
+func (m *monitor) preferences(dep health.DeploymentInfo) health.NotifyPreferences {
+	prefs, err := health.Preferences(dep)
+	if err != nil {
+		log.Printf("Warning: %s/%s: %v", dep.Namespace, dep.Name, err)
+	}
+	return prefs
+}
+
+// archive uploads the failed service's full context and links it from the
+// notification. Failures are logged and the alert goes out without the link.
+func (m *monitor) archive(ctx context.Context, failedService *health.FailedService) {
+	dep := failedService.Deployment
+
+	// Render the alert as sent, apart from the link to itself
+	alertHTML, err := m.emailSender.RenderHealthAlert(*failedService)
+	if err != nil {
+		log.Printf("Failed to archive %s/%s: %v", dep.Namespace, dep.Name, err)
+		return
+	}
+
+	url, err := m.archiver.Archive(ctx, *failedService, alertHTML)
+	if err != nil {
+		log.Printf("Failed to archive %s/%s: %v", dep.Namespace, dep.Name, err)
+		return
+	}
+	failedService.ArchiveURL = url
+}
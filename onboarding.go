@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/links"
+	"github.com/Bharath-H-R/k8s-health/runbook"
+)
+
+// onboardingAnnotations are the workload annotations an onboarding report
+// checks, required ones first.
+var onboardingAnnotations = []health.AnnotationCoverage{
+	{Annotation: health.OwnerAnnotation, Required: true},
+	{Annotation: health.OwnerDLAnnotation, Required: true},
+	{Annotation: tierAnnotation},
+	{Annotation: runbook.URLAnnotation},
+	{Annotation: links.DashboardAnnotation},
+	{Annotation: health.NotifyViaAnnotation},
+	{Annotation: health.AlertMinSeverityAnnotation},
+}
+
+// checkNewNamespaces sends an onboarding report for each namespace created
+// since onboarding reports were turned on, once it is old enough for its
+// workloads to be deployed. The first check only records the namespaces that
+// already exist, as do later ones for old namespaces that come into scope,
+// e.g. from another shard.
+func (m *monitor) checkNewNamespaces(ctx context.Context) {
+	namespaces, err := m.scanner.Namespaces(ctx)
+	if err != nil {
+		log.Printf("Failed to list namespaces: %v", err)
+		return
+	}
+
+	now := time.Now()
+	since, known := m.store.Onboarded()
+	present := make(map[string]bool, len(namespaces))
+	var recorded []string
+	for _, ns := range namespaces {
+		present[ns.Name] = true
+		if _, ok := known[ns.Name]; ok {
+			continue
+		}
+		if since.IsZero() || ns.CreationTimestamp.Time.Before(since) {
+			recorded = append(recorded, ns.Name)
+			continue
+		}
+		if now.Sub(ns.CreationTimestamp.Time) < m.cfg.Onboarding.Delay {
+			continue
+		}
+
+		report, err := m.onboardingReport(ctx, ns)
+		if err != nil {
+			log.Printf("Failed to build onboarding report for %s: %v", ns.Name, err)
+			continue
+		}
+		if m.dryRun || m.cfg.Onboarding.Email == "" {
+			log.Printf("Onboarding report for new namespace %s covers %d workload(s) (no email sent)", ns.Name, len(report.Workloads))
+		} else if err := m.emailSender.SendOnboardingReport(m.cfg.Onboarding.Email, report); err != nil {
+			// Retried on the next check
+			log.Printf("Failed to send onboarding report for %s: %v", ns.Name, err)
+			continue
+		} else {
+			log.Printf("Onboarding report for new namespace %s sent to %s", ns.Name, m.cfg.Onboarding.Email)
+		}
+		recorded = append(recorded, ns.Name)
+	}
+
+	if since.IsZero() {
+		log.Printf("Recorded %d existing namespace(s); namespaces created from now on get an onboarding report", len(recorded))
+	}
+	if err := m.store.MarkOnboarded(recorded, present, now); err != nil {
+		log.Printf("Failed to save onboarded namespaces: %v", err)
+	}
+}
+
+// onboardingReport describes a namespace's deployments and suggests the
+// annotations that would route their alerts.
+func (m *monitor) onboardingReport(ctx context.Context, ns corev1.Namespace) (health.NamespaceReport, error) {
+	report := health.NamespaceReport{Namespace: ns.Name, CreatedAt: ns.CreationTimestamp.Time}
+
+	deployments, err := m.k8sClient.AppsV1().Deployments(ns.Name).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return report, err
+	}
+	// The scan applies owner resolvers and validation, so it tells which
+	// workloads are really monitored
+	m.scanMu.Lock()
+	scanned, err := m.scanner.ScanDeploymentsIn(ctx, func(namespace string) bool { return namespace == ns.Name })
+	m.scanMu.Unlock()
+	if err != nil {
+		return report, err
+	}
+	monitored := make(map[string]bool)
+	owners := make(map[[2]string]int)
+	for _, dep := range scanned {
+		monitored[dep.Name] = true
+		owners[[2]string{dep.OwnerEmail, dep.OwnerDlEmail}]++
+	}
+
+	report.Coverage = make([]health.AnnotationCoverage, len(onboardingAnnotations))
+	copy(report.Coverage, onboardingAnnotations)
+	tiered := false
+	var metadata *health.MetadataReader
+	if m.cfg.Metadata.Source != config.MetadataAnnotations {
+		metadata = health.NewMetadataReader(m.cfg.Metadata)
+	}
+	for _, item := range deployments.Items {
+		dep := health.NewDeploymentInfo(item)
+		if metadata != nil {
+			metadata.Apply(&dep)
+		}
+		workload := health.WorkloadAnnotations{Name: dep.Name, Monitored: monitored[dep.Name]}
+		for i := range report.Coverage {
+			coverage := &report.Coverage[i]
+			coverage.Total++
+			// Preferences set on the namespace apply to its workloads
+			if dep.Annotations[coverage.Annotation] != "" || ns.Annotations[coverage.Annotation] != "" {
+				coverage.Set++
+			} else {
+				workload.Missing = append(workload.Missing, coverage.Annotation)
+			}
+		}
+		tiered = tiered || dep.Annotations[tierAnnotation] != ""
+		report.Workloads = append(report.Workloads, workload)
+	}
+	sort.Slice(report.Workloads, func(i, j int) bool { return report.Workloads[i].Name < report.Workloads[j].Name })
+
+	// Unmonitored workloads most likely belong to whoever owns the others
+	owner, dl, most := "<owner@example.com>", "<team-dl@example.com>", 0
+	for pair, n := range owners {
+		if n > most || (n == most && pair[0] < owner) {
+			owner, dl, most = pair[0], pair[1], n
+		}
+	}
+	for _, workload := range report.Workloads {
+		if !workload.Monitored {
+			report.Suggestions = append(report.Suggestions, fmt.Sprintf("kubectl -n %s annotate deployment %s %s=%s %s=%s",
+				ns.Name, workload.Name, health.OwnerAnnotation, owner, health.OwnerDLAnnotation, dl))
+		}
+	}
+
+	if len(report.Workloads) > 0 && ns.Annotations[health.NotifyViaAnnotation] == "" {
+		channels := []string{health.ChannelEmail}
+		if m.slack != nil {
+			channels = append(channels, health.ChannelSlack)
+		}
+		if m.webhooks != nil {
+			channels = append(channels, health.ChannelWebhook)
+		}
+		if len(channels) > 1 {
+			report.Suggestions = append(report.Suggestions,
+				"# keep only the channels the team wants alerts on",
+				fmt.Sprintf("kubectl annotate namespace %s %s=%s", ns.Name, health.NotifyViaAnnotation, strings.Join(channels, ",")))
+		}
+	}
+
+	if len(report.Workloads) > 0 && !tiered && len(m.cfg.Daemon.PriorityTiers) > 0 {
+		report.Suggestions = append(report.Suggestions,
+			"# recheck critical services between scans",
+			fmt.Sprintf("kubectl -n %s annotate deployment <name> %s=%s", ns.Name, tierAnnotation, m.cfg.Daemon.PriorityTiers[0]))
+	}
+
+	return report, nil
+}
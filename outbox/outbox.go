@@ -0,0 +1,40 @@
+// outbox/outbox.go
+package outbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Dir writes outgoing notifications to files instead of delivering them, for
+// simulations and local development. Files are named so they sort in the
+// order they were written.
+type Dir struct {
+	path string
+	seq  atomic.Int64
+}
+
+// New creates the directory if needed.
+func New(path string) (*Dir, error) {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create outbox: %w", err)
+	}
+	return &Dir{path: path}, nil
+}
+
+// Write stores one notification for a channel, e.g. "email" with ext "eml".
+func (d *Dir) Write(channel, ext string, data []byte) error {
+	name := fmt.Sprintf("%s-%04d-%s.%s", time.Now().UTC().Format("20060102T150405"), d.seq.Add(1), channel, ext)
+	if err := os.WriteFile(filepath.Join(d.path, name), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write to outbox: %w", err)
+	}
+	return nil
+}
+
+// Path returns the directory notifications are written to.
+func (d *Dir) Path() string {
+	return d.path
+}
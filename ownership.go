@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+	"github.com/Bharath-H-R/k8s-health/email"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// ownershipLinkTTL is how long the links in an ownership review work.
+const ownershipLinkTTL = 30 * 24 * time.Hour
+
+// ownershipClaim is what an ownership review link vouches for: that Owner
+// owned the deployment when the link was sent.
+type ownershipClaim struct {
+	Namespace  string `json:"n"`
+	Deployment string `json:"d"`
+	Owner      string `json:"o"`
+	Expires    int64  `json:"e"`
+}
+
+// ownershipRequest is the body of POST /api/v1/ownership/{ns}/{deployment}/confirm
+// and .../transfer.
+type ownershipRequest struct {
+	By string `json:"by"`
+	// To is who to transfer the deployment to
+	To string `json:"to,omitempty"`
+}
+
+// runOwnershipReview emails each owner the deployments attributed to them,
+// each with a link to confirm or transfer it. Deployments their current owner
+// hasn't confirmed within stale_after are flagged, and the owner DL is copied
+// so someone notices when the owner has moved on.
+func (m *monitor) runOwnershipReview(ctx context.Context) {
+	log.Println("Starting ownership review...")
+	now := time.Now()
+
+	deployments, err := m.scanner.ScanDeployments(ctx)
+	if err != nil {
+		log.Printf("Failed to scan deployments: %v", err)
+		return
+	}
+
+	reviewsByOwner := make(map[string][]email.OwnershipReview)
+	dlByOwner := make(map[string]map[string]bool)
+	stale := 0
+	for _, dep := range deployments {
+		if dep.OwnerEmail == "" {
+			continue
+		}
+		owner := strings.ToLower(dep.OwnerEmail)
+		review := email.OwnershipReview{
+			Namespace:  dep.Namespace,
+			Deployment: dep.Name,
+			Stale:      true,
+			ReviewURL: m.ownershipLink(ownershipClaim{
+				Namespace:  dep.Namespace,
+				Deployment: dep.Name,
+				Owner:      owner,
+				Expires:    now.Add(ownershipLinkTTL).Unix(),
+			}),
+		}
+		if record, ok := m.store.Ownership(dep.Namespace, dep.Name); ok && strings.EqualFold(record.Owner, owner) {
+			review.ConfirmedAt = record.ConfirmedAt
+			review.TransferTo = record.TransferTo
+			review.Stale = !record.ConfirmedFor(owner, now.Add(-m.cfg.Ownership.StaleAfter))
+		}
+		if review.Stale {
+			stale++
+			if dlByOwner[owner] == nil {
+				dlByOwner[owner] = make(map[string]bool)
+			}
+			if dl := strings.ToLower(dep.OwnerDlEmail); dl != "" && dl != owner {
+				dlByOwner[owner][dl] = true
+			}
+		}
+		reviewsByOwner[owner] = append(reviewsByOwner[owner], review)
+	}
+
+	log.Printf("Ownership review covers %d owner(s), %d deployment(s) unconfirmed", len(reviewsByOwner), stale)
+
+	for owner, reviews := range reviewsByOwner {
+		sort.Slice(reviews, func(i, j int) bool {
+			return state.Key(reviews[i].Namespace, reviews[i].Deployment) < state.Key(reviews[j].Namespace, reviews[j].Deployment)
+		})
+		if m.dryRun {
+			log.Printf("Dry run: ownership review for %s covers %d deployment(s) (no email sent)", owner, len(reviews))
+			continue
+		}
+
+		var cc []string
+		for dl := range dlByOwner[owner] {
+			cc = append(cc, dl)
+		}
+
+		if err := m.emailSender.SendOwnershipReview(owner, cc, reviews); err != nil {
+			log.Printf("Failed to send ownership review to %s: %v", owner, err)
+		} else {
+			log.Printf("Ownership review sent to %s", owner)
+		}
+		// Small delay to avoid overwhelming SMTP server
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// ownershipLink returns the review page URL for a claim.
+func (m *monitor) ownershipLink(claim ownershipClaim) string {
+	payload, _ := json.Marshal(claim)
+	mac := hmac.New(sha256.New, []byte(m.cfg.Ownership.Secret))
+	mac.Write(payload)
+	token := base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	return strings.TrimSuffix(m.cfg.Ownership.PublicURL, "/") + "/ownership?token=" + url.QueryEscape(token)
+}
+
+// verifyOwnershipToken checks a review link's signature and expiry.
+func (m *monitor) verifyOwnershipToken(token string, now time.Time) (ownershipClaim, error) {
+	var claim ownershipClaim
+	invalid := errors.New("invalid ownership link")
+
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return claim, invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return claim, invalid
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return claim, invalid
+	}
+	mac := hmac.New(sha256.New, []byte(m.cfg.Ownership.Secret))
+	mac.Write(payload)
+	if !hmac.Equal(sum, mac.Sum(nil)) || json.Unmarshal(payload, &claim) != nil {
+		return claim, invalid
+	}
+	if now.Unix() > claim.Expires {
+		return claim, errors.New("this ownership link has expired; use the one in the latest review email")
+	}
+	return claim, nil
+}
+
+// currentOwner looks up the owner annotation of a deployment as it is now.
+func (m *monitor) currentOwner(ctx context.Context, namespace, deployment string) (string, error) {
+	m.scanMu.Lock()
+	deployments, err := m.scanner.ScanDeploymentsIn(ctx, func(ns string) bool { return ns == namespace })
+	m.scanMu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	for _, dep := range deployments {
+		if dep.Name == deployment {
+			return strings.ToLower(dep.OwnerEmail), nil
+		}
+	}
+	return "", errNoDeployments
+}
+
+// ownerAddresses returns the owner and DL addresses of a deployment as it is
+// now.
+func (m *monitor) ownerAddresses(namespace, deployment string) ([]string, error) {
+	m.scanMu.Lock()
+	deployments, err := m.scanner.ScanDeploymentsIn(context.Background(), func(ns string) bool { return ns == namespace })
+	m.scanMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	for _, dep := range deployments {
+		if dep.Name == deployment {
+			return []string{dep.OwnerEmail, dep.OwnerDlEmail}, nil
+		}
+	}
+	return nil, errNoDeployments
+}
+
+// changeOwnership applies a confirm or transfer action on behalf of owner.
+func (m *monitor) changeOwnership(namespace, deployment, owner, action, to, by string) (state.Ownership, error) {
+	switch action {
+	case "confirm":
+		return m.store.ConfirmOwnership(namespace, deployment, owner, by, time.Now())
+	case "transfer":
+		address, err := mail.ParseAddress(to)
+		if err != nil {
+			return state.Ownership{}, fmt.Errorf("invalid address to transfer to: %w", err)
+		}
+		if strings.EqualFold(address.Address, owner) {
+			return state.Ownership{}, fmt.Errorf("%s already owns %s/%s", owner, namespace, deployment)
+		}
+		record, err := m.store.RequestTransfer(namespace, deployment, owner, strings.ToLower(address.Address), by, time.Now())
+		if err == nil {
+			log.Printf("%s asked to transfer %s/%s to %s", by, namespace, deployment, record.TransferTo)
+		}
+		return record, err
+	}
+	return state.Ownership{}, fmt.Errorf("unknown action %q", action)
+}
+
+var ownershipTemplate = template.Must(template.New("ownership").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; max-width: 40em; }
+h2 { color: {{.Color}}; }
+.error { color: #c62828; font-weight: bold; }
+.done { color: #2e7d32; font-weight: bold; }
+form { margin: 1em 0; }
+</style></head>
+<body>
+<h2>{{.Title}}: {{.Cluster}}</h2>
+{{if .Error}}<p class="error">{{.Error}}</p>{{else}}
+<p><b>{{.Service}}</b> names {{.Owner}} as its owner, so its health alerts go there.</p>
+{{if .Done}}<p class="done">{{.Done}}</p>{{end}}
+{{if .Record.TransferTo}}<p>Transfer to {{.Record.TransferTo}} requested {{.Record.TransferRequestedAt.Format "2006-01-02"}}. It completes once the owner annotation is updated.</p>
+{{else if not .Record.ConfirmedAt.IsZero}}<p>Last confirmed {{.Record.ConfirmedAt.Format "2006-01-02"}}.</p>{{end}}
+<form method="post"><input type="hidden" name="token" value="{{.Token}}"><input type="hidden" name="action" value="confirm">
+<button type="submit">I still own it</button></form>
+<form method="post"><input type="hidden" name="token" value="{{.Token}}"><input type="hidden" name="action" value="transfer">
+<input type="email" name="to" placeholder="new.owner@example.com" required> <button type="submit">Transfer it</button></form>
+{{end}}
+</body></html>
+`))
+
+// serveOwnershipPage is where the links in ownership review emails lead. The
+// signed token in the link authorizes the owner it was sent to, as long as
+// they still own the deployment; changes are only made on POST so link
+// scanners can't confirm anything.
+func (m *monitor) serveOwnershipPage(w http.ResponseWriter, r *http.Request) {
+	page := struct {
+		Title   string
+		Color   string
+		Cluster string
+		Service string
+		Owner   string
+		Token   string
+		Record  state.Ownership
+		Done    string
+		Error   string
+	}{
+		Title:   m.cfg.Branding.ProductName,
+		Color:   m.cfg.Branding.PrimaryColor,
+		Cluster: m.cfg.ClusterName,
+		Token:   r.FormValue("token"),
+	}
+	render := func(status int) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(status)
+		if err := ownershipTemplate.Execute(w, page); err != nil {
+			log.Printf("Failed to render ownership page: %v", err)
+		}
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claim, err := m.verifyOwnershipToken(page.Token, time.Now())
+	if err != nil {
+		page.Error = err.Error()
+		render(http.StatusForbidden)
+		return
+	}
+	page.Service = state.Key(claim.Namespace, claim.Deployment)
+	owner, err := m.currentOwner(r.Context(), claim.Namespace, claim.Deployment)
+	switch {
+	case errors.Is(err, errNoDeployments):
+		page.Error = page.Service + " no longer exists."
+		render(http.StatusNotFound)
+		return
+	case err != nil:
+		page.Error = err.Error()
+		render(http.StatusBadGateway)
+		return
+	case owner != claim.Owner:
+		page.Error = fmt.Sprintf("%s is now owned by %q; this link was sent to %s.", page.Service, owner, claim.Owner)
+		render(http.StatusConflict)
+		return
+	}
+	page.Owner = owner
+
+	if r.Method == http.MethodPost {
+		record, err := m.changeOwnership(claim.Namespace, claim.Deployment, owner, r.FormValue("action"), r.FormValue("to"), owner)
+		if err != nil {
+			page.Error = err.Error()
+			render(http.StatusBadRequest)
+			return
+		}
+		page.Done = "Thanks, your confirmation was recorded."
+		if record.TransferTo != "" {
+			page.Done = "Thanks, the transfer request was recorded."
+		}
+	}
+	page.Record, _ = m.store.Ownership(claim.Namespace, claim.Deployment)
+	render(http.StatusOK)
+}
+
+// serveOwnership implements the ownership REST API:
+//
+//	GET  /api/v1/ownership                                list confirmations
+//	POST /api/v1/ownership/{ns}/{deployment}/confirm      confirm the current owner
+//	POST /api/v1/ownership/{ns}/{deployment}/transfer     ask to transfer it (ownershipRequest body)
+func (m *monitor) serveOwnership(w http.ResponseWriter, r *http.Request) {
+	principal := auth.FromContext(r.Context())
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/ownership":
+		records := []state.Ownership{}
+		for _, record := range m.store.Ownerships() {
+			if principal.CanView(record.Namespace) {
+				records = append(records, record)
+			}
+		}
+		writeJSON(w, http.StatusOK, records)
+
+	case r.Method == http.MethodPost && (strings.HasSuffix(r.URL.Path, "/confirm") || strings.HasSuffix(r.URL.Path, "/transfer")):
+		service, action, _ := cutLast(strings.TrimPrefix(r.URL.Path, "/api/v1/ownership/"), "/")
+		namespace, deployment, err := splitService(service)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !principal.CanEdit(namespace) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		var req ownershipRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil && err != io.EOF {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		// Authenticated callers act under their own name
+		if principal != nil {
+			req.By = principal.Name
+		}
+		if req.By == "" {
+			http.Error(w, `"by" is required`, http.StatusBadRequest)
+			return
+		}
+
+		owner, err := m.currentOwner(r.Context(), namespace, deployment)
+		if errors.Is(err, errNoDeployments) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		if owner == "" {
+			http.Error(w, fmt.Sprintf("%s/%s has no owner annotation", namespace, deployment), http.StatusConflict)
+			return
+		}
+		record, err := m.changeOwnership(namespace, deployment, owner, action, req.To, req.By)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, record)
+
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,314 @@
+// Package payload defines the versioned JSON document sent to machine
+// consumers of alerts (webhooks, and any future Kafka or REST sinks).
+//
+// Compatibility policy: within a schema version fields are only ever added,
+// never renamed, retyped or removed, and added fields are optional. Consumers
+// must ignore fields they don't know. Anything else is a breaking change and
+// gets a new SchemaVersion; the previous version stays available for at least
+// one release so consumers can migrate. schema_v1.json is the reference for v1.
+package payload
+
+import (
+	_ "embed"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/health"
+)
+
+// SchemaV1 is the current schema version.
+const SchemaV1 = "v1"
+
+// Event types.
+const (
+	EventFailure  = "failure"
+	EventRecovery = "recovery"
+)
+
+//go:embed schema_v1.json
+var schemaV1 []byte
+
+// Schema returns the JSON Schema document for a version, or nil if the
+// version is unknown.
+func Schema(version string) []byte {
+	if version == SchemaV1 {
+		return schemaV1
+	}
+	return nil
+}
+
+// Alert is the v1 notification payload.
+type Alert struct {
+	SchemaVersion string `json:"schema_version"`
+	Event         string `json:"event"`
+	// IncidentID is stable for the life of an incident; use it as a dedup key
+	IncidentID     string `json:"incident_id,omitempty"`
+	Cluster        string `json:"cluster"`
+	Namespace      string `json:"namespace"`
+	Deployment     string `json:"deployment"`
+	Owner          Owner  `json:"owner"`
+	Classification string `json:"classification,omitempty"`
+	// Severity is "critical" or "warning"
+	Severity string `json:"severity,omitempty"`
+	// NotReadySeconds is how long the container has been NotReady, for
+	// readiness failures
+	NotReadySeconds  int64         `json:"not_ready_seconds,omitempty"`
+	Reason           string        `json:"reason,omitempty"`
+	CheckedAt        time.Time     `json:"checked_at"`
+	StartedAt        *time.Time    `json:"started_at,omitempty"`
+	Links            []Link        `json:"links,omitempty"`
+	Suggestions      []string      `json:"suggestions,omitempty"`
+	RemediationSteps []string      `json:"remediation_steps,omitempty"`
+	Remediations     []Remediation `json:"remediations,omitempty"`
+	ChangeFreeze     *ChangeFreeze `json:"change_freeze,omitempty"`
+	// Pods lists each failing pod, of TotalPods in the deployment
+	Pods         []Pod  `json:"pods,omitempty"`
+	TotalPods    int    `json:"total_pods,omitempty"`
+	DebugCommand string `json:"debug_command,omitempty"`
+	// PlatformIssue marks failures the infra team owns, e.g. admission webhooks
+	PlatformIssue bool `json:"platform_issue,omitempty"`
+	// Acknowledgement is set once a responder has taken the incident
+	Acknowledgement *Acknowledgement `json:"acknowledgement,omitempty"`
+	// Version is the deployment's revision and images when checked
+	Version *Version `json:"version,omitempty"`
+	// Change is what the failing rollout changed, from CI annotations
+	Change *Change `json:"change,omitempty"`
+	// UpstreamCause is a failing dependency that likely caused the failure
+	UpstreamCause string `json:"upstream_cause,omitempty"`
+	// Hotspot is set when most of the scan's failing pods share a node,
+	// node group or zone with this service's
+	Hotspot *Hotspot `json:"hotspot,omitempty"`
+	// Drill marks a synthetic alert sent to test notifications
+	Drill bool `json:"drill,omitempty"`
+	// Restarts are the most recent container restarts, newest first
+	Restarts []Restart `json:"restarts,omitempty"`
+}
+
+// Restart is one or more restarts of a container between two scans,
+// described by the last of them.
+type Restart struct {
+	Pod       string    `json:"pod"`
+	Container string    `json:"container"`
+	Count     int32     `json:"count"`
+	Reason    string    `json:"reason,omitempty"`
+	ExitCode  int32     `json:"exit_code"`
+	At        time.Time `json:"at"`
+}
+
+// Hotspot is infrastructure that most failing pods run on.
+type Hotspot struct {
+	Kind             string `json:"kind"`
+	Name             string `json:"name"`
+	FailingPods      int    `json:"failing_pods"`
+	TotalFailingPods int    `json:"total_failing_pods"`
+	Deployments      int    `json:"deployments"`
+}
+
+// Change links an alert to the commit and pipeline that produced the
+// deployment.
+type Change struct {
+	Version  string `json:"version,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+	Pipeline string `json:"pipeline,omitempty"`
+	Links    []Link `json:"links,omitempty"`
+}
+
+// Version identifies what a deployment was running.
+type Version struct {
+	Revision    string     `json:"revision,omitempty"`
+	Images      []Image    `json:"images,omitempty"`
+	LastRollout *time.Time `json:"last_rollout,omitempty"`
+}
+
+// Image is the image one container runs.
+type Image struct {
+	Container string `json:"container"`
+	Image     string `json:"image"`
+}
+
+type Owner struct {
+	Email        string `json:"email"`
+	DL           string `json:"dl,omitempty"`
+	Team         string `json:"team,omitempty"`
+	SlackChannel string `json:"slack_channel,omitempty"`
+	// Bounced is set when mail to Email is undeliverable
+	Bounced bool `json:"bounced,omitempty"`
+}
+
+// Link kinds are "runbook", "dashboard", "logs", "next_steps" and "archive".
+type Link struct {
+	Kind string `json:"kind"`
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url"`
+}
+
+type Remediation struct {
+	Action string    `json:"action"`
+	Pod    string    `json:"pod"`
+	Reason string    `json:"reason,omitempty"`
+	Time   time.Time `json:"time"`
+	DryRun bool      `json:"dry_run,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+type Pod struct {
+	Name           string `json:"name"`
+	Container      string `json:"container,omitempty"`
+	Reason         string `json:"reason"`
+	Classification string `json:"classification,omitempty"`
+	Node           string `json:"node,omitempty"`
+	Zone           string `json:"zone,omitempty"`
+	NodeGroup      string `json:"node_group,omitempty"`
+	// NotReadySeconds is how long the pod's container has been NotReady
+	NotReadySeconds int64 `json:"not_ready_seconds,omitempty"`
+}
+
+type Acknowledgement struct {
+	By string    `json:"by"`
+	At time.Time `json:"at"`
+}
+
+type ChangeFreeze struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+}
+
+// NewFailure builds the payload for a failed service.
+func NewFailure(cluster string, failedService health.FailedService) Alert {
+	alert := newAlert(EventFailure, cluster, failedService.Deployment, failedService.CheckTime)
+	alert.IncidentID = failedService.IncidentID
+	alert.Classification = failedService.Classification
+	alert.Severity = failedService.Severity
+	alert.NotReadySeconds = int64(failedService.NotReadyFor / time.Second)
+	alert.Reason = failedService.FailureReason
+	alert.Suggestions = failedService.Suggestions
+	alert.RemediationSteps = failedService.RemediationSteps
+	alert.TotalPods = failedService.TotalPods
+	alert.DebugCommand = failedService.DebugCommand
+	alert.PlatformIssue = health.IsPlatform(failedService.Classification)
+	for _, pod := range failedService.Pods {
+		alert.Pods = append(alert.Pods, Pod{
+			Name:            pod.Pod,
+			Container:       pod.Container,
+			Reason:          pod.Reason,
+			Classification:  pod.Classification,
+			Node:            pod.Node,
+			Zone:            pod.Zone,
+			NodeGroup:       pod.NodeGroup,
+			NotReadySeconds: int64(pod.NotReadyFor / time.Second),
+		})
+	}
+	if failedService.AcknowledgedBy != "" {
+		alert.Acknowledgement = &Acknowledgement{By: failedService.AcknowledgedBy, At: failedService.AcknowledgedAt}
+	}
+
+	if failedService.RunbookURL != "" {
+		alert.Links = append(alert.Links, Link{Kind: "runbook", URL: failedService.RunbookURL})
+	}
+	if failedService.DashboardURL != "" {
+		alert.Links = append(alert.Links, Link{Kind: "dashboard", URL: failedService.DashboardURL})
+	}
+	for _, link := range failedService.LogLinks {
+		alert.Links = append(alert.Links, Link{Kind: "logs", Name: link.Name, URL: link.URL})
+	}
+	if next := failedService.NextSteps; next != nil {
+		alert.Links = append(alert.Links, Link{Kind: "next_steps", Name: next.Category, URL: next.URL})
+	}
+	alert.Owner.Bounced = failedService.OwnerBounced
+	alert.UpstreamCause = failedService.UpstreamCause
+	alert.Drill = failedService.Drill
+	for _, restart := range failedService.Restarts {
+		alert.Restarts = append(alert.Restarts, Restart{
+			Pod:       restart.Pod,
+			Container: restart.Container,
+			Count:     restart.Count,
+			Reason:    restart.Reason,
+			ExitCode:  restart.ExitCode,
+			At:        restart.At,
+		})
+	}
+	if hotspot := failedService.Hotspot; hotspot != nil {
+		alert.Hotspot = &Hotspot{
+			Kind:             hotspot.Kind,
+			Name:             hotspot.Name,
+			FailingPods:      hotspot.FailingPods,
+			TotalFailingPods: hotspot.TotalFailingPods,
+			Deployments:      hotspot.Deployments,
+		}
+	}
+	if failedService.ArchiveURL != "" {
+		alert.Links = append(alert.Links, Link{Kind: "archive", URL: failedService.ArchiveURL})
+	}
+
+	for _, action := range failedService.Remediations {
+		alert.Remediations = append(alert.Remediations, Remediation{
+			Action: action.Action,
+			Pod:    action.Pod,
+			Reason: action.Reason,
+			Time:   action.Time,
+			DryRun: action.DryRun,
+			Error:  action.Error,
+		})
+	}
+
+	if change := failedService.Change; change != nil {
+		alert.Change = &Change{Version: change.Version, Commit: change.Commit, Pipeline: change.Pipeline}
+		for _, link := range change.Links {
+			alert.Change.Links = append(alert.Change.Links, Link{Kind: "change", Name: link.Name, URL: link.URL})
+		}
+	}
+	if freeze := failedService.ChangeFreeze; freeze != nil {
+		alert.ChangeFreeze = &ChangeFreeze{
+			ID:          freeze.ID,
+			Description: freeze.Description,
+			Start:       freeze.Start,
+			End:         freeze.End,
+		}
+	}
+	return alert
+}
+
+// NewRecovery builds the payload for a service that is healthy again after
+// failing since startedAt.
+func NewRecovery(cluster string, dep health.DeploymentInfo, incidentID string, startedAt, now time.Time) Alert {
+	alert := newAlert(EventRecovery, cluster, dep, now)
+	alert.IncidentID = incidentID
+	if !startedAt.IsZero() {
+		alert.StartedAt = &startedAt
+	}
+	return alert
+}
+
+func newAlert(event, cluster string, dep health.DeploymentInfo, checkedAt time.Time) Alert {
+	return Alert{
+		SchemaVersion: SchemaV1,
+		Event:         event,
+		Cluster:       cluster,
+		Namespace:     dep.Namespace,
+		Deployment:    dep.Name,
+		Owner: Owner{
+			Email:        dep.OwnerEmail,
+			DL:           dep.OwnerDlEmail,
+			Team:         dep.Team,
+			SlackChannel: dep.SlackChannel,
+		},
+		CheckedAt: checkedAt,
+		Version:   newVersion(dep),
+	}
+}
+
+func newVersion(dep health.DeploymentInfo) *Version {
+	if dep.Revision == "" && len(dep.Images) == 0 && dep.LastRollout.IsZero() {
+		return nil
+	}
+	version := &Version{Revision: dep.Revision}
+	for _, image := range dep.Images {
+		version.Images = append(version.Images, Image{Container: image.Container, Image: image.Image})
+	}
+	if !dep.LastRollout.IsZero() {
+		lastRollout := dep.LastRollout
+		version.LastRollout = &lastRollout
+	}
+	return version
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/email"
+	"github.com/Bharath-H-R/k8s-health/kubernetes"
+	"github.com/Bharath-H-R/k8s-health/slack"
+	"github.com/Bharath-H-R/k8s-health/state"
+	"github.com/Bharath-H-R/k8s-health/transport"
+	"github.com/Bharath-H-R/k8s-health/webhook"
+)
+
+// permission is an API access the monitor needs, checked cluster-wide unless
+// it names a namespace.
+type permission struct {
+	verb, group, resource, subresource, namespace string
+}
+
+func (p permission) String() string {
+	resource := p.resource
+	if p.group != "" {
+		resource += "." + p.group
+	}
+	if p.subresource != "" {
+		resource += "/" + p.subresource
+	}
+	if p.namespace != "" {
+		resource = p.namespace + "/" + resource
+	}
+	return p.verb + " " + resource
+}
+
+// requiredPermissions lists what scans need, plus what the features turned on
+// in cfg need.
+func requiredPermissions(cfg *config.Config) []permission {
+	perms := []permission{
+		{verb: "list", resource: "namespaces"},
+		{verb: "list", group: "apps", resource: "deployments"},
+		{verb: "get", group: "apps", resource: "deployments"},
+		{verb: "list", group: "apps", resource: "replicasets"},
+		{verb: "list", resource: "pods"},
+		{verb: "get", resource: "pods"},
+		{verb: "get", resource: "pods", subresource: "log"},
+		{verb: "list", resource: "events"},
+		{verb: "list", resource: "nodes"},
+		{verb: "list", group: "autoscaling", resource: "horizontalpodautoscalers"},
+		{verb: "list", group: "policy", resource: "poddisruptionbudgets"},
+		{verb: "get", resource: "configmaps"},
+		{verb: "get", resource: "serviceaccounts"},
+	}
+	if cfg.Remediation.MaxActionsPerRun > 0 {
+		perms = append(perms, permission{verb: "delete", resource: "pods"})
+	}
+	if cfg.Debug.Image != "" && cfg.Debug.LaunchToken != "" {
+		perms = append(perms, permission{verb: "update", resource: "pods", subresource: "ephemeralcontainers"})
+	}
+	for _, ref := range []*config.SecretRef{cfg.SMTPConfig.CredentialsSecret, cfg.Slack.CredentialsSecret} {
+		if ref != nil {
+			perms = append(perms,
+				permission{verb: "get", resource: "secrets", namespace: ref.Namespace},
+				permission{verb: "watch", resource: "secrets", namespace: ref.Namespace})
+		}
+	}
+	if cfg.Sharding.Enabled {
+		for _, verb := range []string{"get", "list", "create", "update"} {
+			perms = append(perms, permission{verb: verb, group: "coordination.k8s.io", resource: "leases", namespace: cfg.Sharding.LeaseNamespace})
+		}
+	}
+	if cfg.RunLock.Enabled {
+		for _, verb := range []string{"get", "create", "update", "delete"} {
+			perms = append(perms, permission{verb: verb, group: "coordination.k8s.io", resource: "leases", namespace: cfg.RunLock.LeaseNamespace})
+		}
+	}
+	return perms
+}
+
+// preflightResult is one row of the preflight table.
+type preflightResult struct {
+	check  string
+	err    error
+	detail string
+}
+
+// runPreflight checks everything the monitor depends on without scanning or
+// sending anything, and prints a pass/fail table. It reports whether every
+// check passed, so it can gate an init container or a CI job.
+func runPreflight(ctx context.Context, cfg *config.Config, out io.Writer) bool {
+	var results []preflightResult
+	add := func(check string, err error, detail string) {
+		results = append(results, preflightResult{check: check, err: err, detail: detail})
+	}
+
+	client, err := kubernetes.NewClient(nil)
+	if err == nil {
+		var version fmt.Stringer
+		if version, err = client.Discovery().ServerVersion(); err == nil {
+			add("kubernetes api", nil, "server "+version.String())
+		}
+	}
+	if err != nil {
+		add("kubernetes api", err, "")
+		client = nil
+	}
+	if client != nil {
+		for _, perm := range requiredPermissions(cfg) {
+			add("rbac "+perm.String(), checkPermission(ctx, client, perm), "")
+		}
+	}
+
+	credentials, err := newCredentialSources(client, cfg)
+	if err == nil && client == nil && (cfg.SMTPConfig.CredentialsSecret != nil || cfg.Slack.CredentialsSecret != nil) {
+		err = fmt.Errorf("credentials_secret needs the kubernetes api")
+	}
+	if err == nil {
+		err = credentials.load(ctx, cfg)
+	}
+	if err != nil || credentials.smtp != nil || credentials.slack != nil {
+		add("credentials", err, "")
+	}
+
+	sender, err := email.NewSender(cfg)
+	add("email templates", err, "")
+	if sender != nil {
+		add("smtp "+net.JoinHostPort(cfg.SMTPConfig.Host, fmt.Sprint(cfg.SMTPConfig.Port)), sender.CheckRelay(), "")
+	}
+	if cfg.Slack.WebhookURL != "" {
+		notifier, err := slack.NewNotifier(cfg.Slack, transport.Proxy(cfg.Proxy, cfg.Slack.Proxy))
+		if err == nil {
+			err = notifier.Check()
+		}
+		add("slack", err, "")
+	}
+	if len(cfg.Webhooks) > 0 {
+		notifier, err := webhook.NewNotifier(cfg.Webhooks, cfg.Proxy)
+		if err != nil {
+			add("webhooks", err, "")
+		} else {
+			errs := notifier.Check()
+			for _, target := range cfg.Webhooks {
+				add("webhook/"+target.Name, errs[target.Name], "")
+			}
+		}
+	}
+
+	detail, err := checkStateStore(cfg.State.Path)
+	add("state store", err, detail)
+
+	passed := true
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tRESULT\tDETAIL")
+	for _, result := range results {
+		status, detail := "PASS", result.detail
+		if result.err != nil {
+			status, detail, passed = "FAIL", strings.ReplaceAll(result.err.Error(), "\n", " "), false
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.check, status, detail)
+	}
+	w.Flush()
+	return passed
+}
+
+// checkPermission asks the API server whether the monitor's own identity
+// may perform perm.
+func checkPermission(ctx context.Context, client k8s.Interface, perm permission) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Verb:        perm.verb,
+				Group:       perm.group,
+				Resource:    perm.resource,
+				Subresource: perm.subresource,
+				Namespace:   perm.namespace,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	if !review.Status.Allowed {
+		if review.Status.Reason != "" {
+			return fmt.Errorf("denied: %s", review.Status.Reason)
+		}
+		return fmt.Errorf("denied")
+	}
+	return nil
+}
+
+// checkStateStore loads the state file and makes sure its directory is
+// writable, without touching the file itself.
+func checkStateStore(path string) (string, error) {
+	if path == "" {
+		return "in memory", nil
+	}
+	if _, err := state.Open(path); err != nil {
+		return "", err
+	}
+	probe, err := os.CreateTemp(filepath.Dir(path), ".preflight-*")
+	if err != nil {
+		return "", err
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return path, nil
+}
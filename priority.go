@@ -0,0 +1,130 @@
+package main
+
+import (
+	"container/heap"
+	"strings"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// tierAnnotation marks a deployment's business tier, e.g. "p1".
+const tierAnnotation = "tier"
+
+// Reasons a deployment is rechecked between full scans, most urgent first.
+const (
+	priorityFailing = iota
+	priorityTier
+	priorityDeployed
+)
+
+type recheckItem struct {
+	dep      health.DeploymentInfo
+	priority int
+	due      time.Time
+	index    int
+}
+
+// recheckQueue orders deployments by when they are next due, and by priority
+// among those due at the same time. It implements heap.Interface.
+type recheckQueue []*recheckItem
+
+func (q recheckQueue) Len() int { return len(q) }
+
+func (q recheckQueue) Less(i, j int) bool {
+	if !q[i].due.Equal(q[j].due) {
+		return q[i].due.Before(q[j].due)
+	}
+	return q[i].priority < q[j].priority
+}
+
+func (q recheckQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *recheckQueue) Push(x interface{}) {
+	item := x.(*recheckItem)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *recheckQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// next returns when the earliest item is due, or false if the queue is empty.
+func (q recheckQueue) next() (time.Time, bool) {
+	if len(q) == 0 {
+		return time.Time{}, false
+	}
+	return q[0].due, true
+}
+
+// popDue removes and returns every deployment due at or before now.
+func (q *recheckQueue) popDue(now time.Time) []health.DeploymentInfo {
+	var due []health.DeploymentInfo
+	for q.Len() > 0 && !(*q)[0].due.After(now) {
+		due = append(due, heap.Pop(q).(*recheckItem).dep)
+	}
+	return due
+}
+
+// schedule replaces the queue with the priority deployments among checked,
+// each due one priority interval from now.
+func (q *recheckQueue) schedule(items []*recheckItem, now time.Time, interval time.Duration) {
+	*q = (*q)[:0]
+	for _, item := range items {
+		item.due = now.Add(interval)
+		heap.Push(q, item)
+	}
+}
+
+// add queues more deployments, replacing queued entries for the same ones.
+func (q *recheckQueue) add(items []*recheckItem, now time.Time, interval time.Duration) {
+	queued := make(map[string]*recheckItem, q.Len())
+	for _, item := range *q {
+		queued[state.Key(item.dep.Namespace, item.dep.Name)] = item
+	}
+
+	for _, item := range items {
+		item.due = now.Add(interval)
+		if existing, ok := queued[state.Key(item.dep.Namespace, item.dep.Name)]; ok {
+			existing.dep, existing.priority, existing.due = item.dep, item.priority, item.due
+			heap.Fix(q, existing.index)
+			continue
+		}
+		heap.Push(q, item)
+	}
+}
+
+// prioritize picks the checked deployments that deserve rechecks between full
+// scans: failing ones, priority tiers, and ones rolled out recently.
+func (m *monitor) prioritize(checked []checkedDeployment, now time.Time) []*recheckItem {
+	tiers := make(map[string]bool, len(m.cfg.Daemon.PriorityTiers))
+	for _, tier := range m.cfg.Daemon.PriorityTiers {
+		tiers[strings.ToLower(tier)] = true
+	}
+
+	var items []*recheckItem
+	for _, c := range checked {
+		priority := -1
+		switch {
+		case !c.result.Healthy:
+			priority = priorityFailing
+		case tiers[strings.ToLower(c.dep.Annotations[tierAnnotation])]:
+			priority = priorityTier
+		case !c.dep.LastRollout.IsZero() && now.Sub(c.dep.LastRollout) < m.cfg.Daemon.RecentRolloutWindow:
+			priority = priorityDeployed
+		}
+		if priority >= 0 {
+			items = append(items, &recheckItem{dep: c.dep, priority: priority})
+		}
+	}
+	return items
+}
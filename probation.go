@@ -0,0 +1,48 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// probation returns how long a failing workload must stay stable to leave
+// probation, or 0 if it isn't on probation: it is older than
+// probation.max_age, or has already been stable for probation.stable_for.
+func (m *monitor) probation(dep health.DeploymentInfo, failingSince time.Time, now time.Time) time.Duration {
+	cfg := m.cfg.Probation
+	if cfg.MaxAge == 0 || dep.Created.IsZero() || now.Sub(dep.Created) >= cfg.MaxAge {
+		return 0
+	}
+
+	var incidents []state.IncidentRecord
+	for _, record := range m.store.History(dep.Created) {
+		if record.Namespace == dep.Namespace && record.Deployment == dep.Name {
+			incidents = append(incidents, record)
+		}
+	}
+	if graduated(dep.Created, incidents, failingSince, cfg.StableFor) {
+		return 0
+	}
+	return cfg.StableFor
+}
+
+// graduated reports whether a workload created at created ran without
+// incidents for stableFor at some point before failingSince. incidents are
+// its incidents, oldest first.
+func graduated(created time.Time, incidents []state.IncidentRecord, failingSince time.Time, stableFor time.Duration) bool {
+	stableSince := created
+	for _, incident := range incidents {
+		if !incident.StartedAt.Before(failingSince) {
+			break
+		}
+		if incident.StartedAt.Sub(stableSince) >= stableFor {
+			return true
+		}
+		if incident.ResolvedAt.After(stableSince) {
+			stableSince = incident.ResolvedAt
+		}
+	}
+	return failingSince.Sub(stableSince) >= stableFor
+}
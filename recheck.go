@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// recheckTimeout bounds a CLI recheck, which includes fetching logs and
+// sending any alerts for the rechecked deployments.
+const recheckTimeout = 2 * time.Minute
+
+// runRecheckCommand implements
+//
+//	recheck <namespace>[/<deployment>] -server URL
+//
+// which asks a running daemon to check the deployments right away, e.g. after
+// deploying a fix, and prints the fresh results. It fails if any is still
+// unhealthy, so scripts can wait for recovery.
+func runRecheckCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("recheck", flag.ExitOnError)
+	server := flags.String("server", "", "Base URL of a running daemon, e.g. http://k8s-health:8080")
+
+	positional := parseInterleaved(flags, args)
+	if len(positional) != 1 || *server == "" {
+		return fmt.Errorf("usage: recheck <namespace>[/<deployment>] -server URL")
+	}
+
+	results, err := newAPIClient(*server).Recheck(positional[0])
+	if err != nil {
+		return err
+	}
+	if err := printResults(out, results); err != nil {
+		return err
+	}
+
+	failing := 0
+	for _, result := range results {
+		if !result.Healthy {
+			failing++
+		}
+	}
+	if failing > 0 {
+		return fmt.Errorf("%d deployment(s) still unhealthy", failing)
+	}
+	return nil
+}
+
+func printResults(out io.Writer, results []state.ScanResult) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tSTATUS\tCLASSIFICATION\tREASON")
+	for _, result := range results {
+		status, classification := "healthy", "-"
+		if !result.Healthy {
+			status, classification = "unhealthy", result.Classification
+		} else if result.Stopped {
+			status = "stopped"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", state.Key(result.Namespace, result.Deployment), status, classification, result.Reason)
+	}
+	return w.Flush()
+}
+
+// serveRecheck implements the recheck REST API:
+//
+//	POST /api/v1/recheck/{ns}              recheck every deployment in a namespace
+//	POST /api/v1/recheck/{ns}/{deployment} recheck one deployment
+//
+// The deployments are checked, recorded and alerted on as in a scan, and the
+// fresh results are returned.
+func (m *monitor) serveRecheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	namespace, deployment, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/recheck/"), "/")
+	if namespace == "" {
+		http.Error(w, "expected /api/v1/recheck/{namespace}[/{deployment}]", http.StatusBadRequest)
+		return
+	}
+
+	if !auth.FromContext(r.Context()).CanEdit(namespace) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	results, err := m.recheckTarget(r.Context(), namespace, deployment)
+	if err == errNoDeployments {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+var errNoDeployments = errors.New("no monitored deployments found")
+
+// recheckTarget rechecks one deployment, or every deployment in the
+// namespace if deployment is empty, and returns the fresh results.
+func (m *monitor) recheckTarget(ctx context.Context, namespace, deployment string) ([]state.ScanResult, error) {
+	m.scanMu.Lock()
+	deployments, err := m.scanner.ScanDeploymentsIn(ctx, func(ns string) bool { return ns == namespace })
+	m.scanMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []health.DeploymentInfo
+	for _, dep := range deployments {
+		if deployment == "" || dep.Name == deployment {
+			selected = append(selected, dep)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, errNoDeployments
+	}
+
+	results := scanResults(m.recheck(ctx, selected), time.Now())
+	sorted := make([]state.ScanResult, 0, len(results))
+	for _, dep := range selected {
+		if result, ok := results[state.Key(dep.Namespace, dep.Name)]; ok {
+			sorted = append(sorted, result)
+		}
+	}
+	return sorted, nil
+}
+
+// Recheck asks the daemon to recheck a namespace or namespace/deployment.
+func (c *apiClient) Recheck(target string) ([]state.ScanResult, error) {
+	client := *c.httpClient
+	client.Timeout = recheckTimeout
+
+	resp, err := client.Post(c.baseURL+"/api/v1/recheck/"+strings.Trim(target, "/"), "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	var results []state.ScanResult
+	return results, json.NewDecoder(resp.Body).Decode(&results)
+}
@@ -0,0 +1,241 @@
+// remediation/restarter.go
+package remediation
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/health"
+)
+
+const (
+	// AnnotationKey is the deployment annotation used to opt in to remediation.
+	AnnotationKey = "remediation"
+
+	PolicyRestartPod = "restart_pod"
+	ActionRestartPod = "restart_pod"
+)
+
+// AuditEntry is a single line of the remediation audit log.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Namespace  string    `json:"namespace"`
+	Deployment string    `json:"deployment"`
+	Pod        string    `json:"pod"`
+	Action     string    `json:"action"`
+	Reason     string    `json:"reason"`
+	DryRun     bool      `json:"dry_run"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type Restarter struct {
+	client  kubernetes.Interface
+	cfg     config.RemediationConfig
+	dryRun  bool
+	actions int
+	// last successful action per namespace/deployment, used for cooldowns
+	lastAction map[string]time.Time
+}
+
+func NewRestarter(client kubernetes.Interface, cfg config.RemediationConfig, dryRun bool) (*Restarter, error) {
+	r := &Restarter{
+		client:     client,
+		cfg:        cfg,
+		dryRun:     dryRun,
+		lastAction: make(map[string]time.Time),
+	}
+
+	if err := r.loadAuditLog(); err != nil {
+		return nil, fmt.Errorf("failed to load remediation audit log: %w", err)
+	}
+
+	return r, nil
+}
+
+// Remediate restarts at most one stuck pod of a deployment that opted in via
+// the remediation annotation. Actions are rate-limited per run and per
+// deployment, and every attempt is written to the audit trail.
+func (r *Restarter) Remediate(ctx context.Context, dep health.DeploymentInfo) []health.RemediationAction {
+	// Nothing would recreate a deleted standalone pod
+	if dep.Annotations[AnnotationKey] != PolicyRestartPod || dep.Kind == health.KindPod {
+		return nil
+	}
+
+	key := dep.Namespace + "/" + dep.Name
+	if last, ok := r.lastAction[key]; ok && time.Since(last) < r.cfg.Cooldown {
+		log.Printf("Remediation for %s skipped: last action at %s is within cooldown",
+			key, last.Format(time.RFC3339))
+		return nil
+	}
+
+	if r.actions >= r.cfg.MaxActionsPerRun {
+		log.Printf("Remediation for %s skipped: run limit of %d actions reached",
+			key, r.cfg.MaxActionsPerRun)
+		return nil
+	}
+
+	pods, err := r.client.CoreV1().Pods(dep.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: health.PodSelector(dep),
+	})
+	if err != nil {
+		log.Printf("Remediation for %s skipped: failed to list pods: %v", key, err)
+		return nil
+	}
+
+	for _, pod := range pods.Items {
+		reason, stuck := r.stuckReason(ctx, pod)
+		if !stuck {
+			continue
+		}
+
+		action := health.RemediationAction{
+			Action: ActionRestartPod,
+			Pod:    pod.Name,
+			Reason: reason,
+			Time:   time.Now(),
+			DryRun: r.dryRun,
+		}
+
+		if !r.dryRun {
+			err := r.client.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+			if err != nil {
+				action.Error = err.Error()
+			} else {
+				r.lastAction[key] = action.Time
+			}
+		}
+
+		r.actions++
+		r.audit(dep, action)
+
+		// Only one pod per deployment per run, so a restart never takes
+		// down every replica at once.
+		return []health.RemediationAction{action}
+	}
+
+	return nil
+}
+
+// stuckReason reports whether a pod has been in a state that a restart may fix
+// for longer than the configured threshold: NotReady, or failing its liveness
+// probe over and over.
+func (r *Restarter) stuckReason(ctx context.Context, pod corev1.Pod) (string, bool) {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+		return "", false
+	}
+
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type != corev1.PodReady || cond.Status == corev1.ConditionTrue {
+			continue
+		}
+
+		notReadyFor := time.Since(cond.LastTransitionTime.Time)
+		if notReadyFor < r.cfg.NotReadyThreshold {
+			break
+		}
+
+		reason := fmt.Sprintf("Pod NotReady for %s", notReadyFor.Round(time.Second))
+		if cond.Reason != "" {
+			reason = fmt.Sprintf("%s (%s)", reason, cond.Reason)
+		}
+		return reason, true
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.RestartCount < r.cfg.ProbeRestartThreshold {
+			continue
+		}
+
+		killed, err := health.LivenessKilled(ctx, r.client, pod.Namespace, pod.Name, status.Name)
+		if err != nil {
+			log.Printf("Warning: could not check liveness kills for %s/%s: %v", pod.Namespace, pod.Name, err)
+			continue
+		}
+		if killed {
+			return fmt.Sprintf("Container %s restarted %d times by its liveness probe", status.Name, status.RestartCount), true
+		}
+	}
+
+	return "", false
+}
+
+func (r *Restarter) audit(dep health.DeploymentInfo, action health.RemediationAction) {
+	entry := AuditEntry{
+		Time:       action.Time,
+		Namespace:  dep.Namespace,
+		Deployment: dep.Name,
+		Pod:        action.Pod,
+		Action:     action.Action,
+		Reason:     action.Reason,
+		DryRun:     action.DryRun,
+		Error:      action.Error,
+	}
+
+	log.Printf("AUDIT remediation: action=%s pod=%s/%s deployment=%s reason=%q dry_run=%t error=%q",
+		entry.Action, entry.Namespace, entry.Pod, entry.Deployment, entry.Reason, entry.DryRun, entry.Error)
+
+	if r.cfg.AuditLog == "" {
+		return
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Failed to encode audit entry: %v", err)
+		return
+	}
+
+	f, err := os.OpenFile(r.cfg.AuditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("Failed to open audit log %s: %v", r.cfg.AuditLog, err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Printf("Failed to write audit log %s: %v", r.cfg.AuditLog, err)
+	}
+}
+
+// loadAuditLog restores per-deployment cooldowns from previous runs.
+func (r *Restarter) loadAuditLog() error {
+	if r.cfg.AuditLog == "" {
+		return nil
+	}
+
+	f, err := os.Open(r.cfg.AuditLog)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // Skip malformed lines
+		}
+		if entry.DryRun || entry.Error != "" {
+			continue
+		}
+
+		key := entry.Namespace + "/" + entry.Deployment
+		if entry.Time.After(r.lastAction[key]) {
+			r.lastAction[key] = entry.Time
+		}
+	}
+
+	return scanner.Err()
+}
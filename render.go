@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/email"
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/payload"
+	"github.com/Bharath-H-R/k8s-health/slack"
+)
+
+// runRenderCommand renders the email, Slack and webhook alerts for each
+// FailedService fixture (JSON, one per file) and compares them with the
+// golden files next to the fixtures, so template changes show up as golden
+// file diffs in review. -update rewrites the golden files instead.
+func runRenderCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	configPath := flags.String("config", "./config.yaml", "Path to config file")
+	dir := flags.String("fixtures", "fixtures/render", "Directory of FailedService fixtures; golden files are in its golden/")
+	update := flags.Bool("update", false, "Rewrite the golden files with the current output")
+	flags.Parse(args)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	sender, err := email.NewSender(cfg)
+	if err != nil {
+		return err
+	}
+	notifier, err := slack.NewNotifier(cfg.Slack, cfg.Proxy)
+	if err != nil {
+		return err
+	}
+
+	fixtures, err := filepath.Glob(filepath.Join(*dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(fixtures) == 0 {
+		return fmt.Errorf("no fixtures found in %s", *dir)
+	}
+
+	var mismatches int
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".json")
+		rendered, err := renderFixture(fixture, cfg.ClusterName, sender, notifier)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fixture, err)
+		}
+
+		for _, r := range rendered {
+			golden := filepath.Join(*dir, "golden", name+r.suffix)
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					return err
+				}
+				if err := os.WriteFile(golden, r.content, 0o644); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "updated %s\n", golden)
+				continue
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				return fmt.Errorf("%w (run with -update to create it)", err)
+			}
+			if line, wantLine, gotLine, ok := firstDifference(want, r.content); !ok {
+				mismatches++
+				fmt.Fprintf(out, "FAIL %s: line %d differs\n  want: %s\n  got:  %s\n", golden, line, wantLine, gotLine)
+			} else {
+				fmt.Fprintf(out, "ok   %s\n", golden)
+			}
+		}
+	}
+
+	if mismatches > 0 {
+		return fmt.Errorf("%d golden file(s) differ; rerun with -update if the change is intended", mismatches)
+	}
+	return nil
+}
+
+type renderedAlert struct {
+	suffix  string
+	content []byte
+}
+
+// renderFixture renders one fixture for every channel.
+func renderFixture(path, cluster string, sender *email.Sender, notifier *slack.Notifier) ([]renderedAlert, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var failedService health.FailedService
+	if err := json.Unmarshal(content, &failedService); err != nil {
+		return nil, err
+	}
+
+	html, err := sender.RenderHealthAlert(failedService)
+	if err != nil {
+		return nil, err
+	}
+	// The footer's copyright year would otherwise break every golden file
+	// each January
+	html = strings.ReplaceAll(html, fmt.Sprintf("&copy; %d", time.Now().Year()), "&copy; YEAR")
+
+	slackJSON, err := json.MarshalIndent(notifier.AlertPayload(failedService), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	webhookJSON, err := json.MarshalIndent(payload.NewFailure(cluster, failedService), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return []renderedAlert{
+		{".email.html", []byte(html)},
+		{".slack.json", append(slackJSON, '\n')},
+		{".webhook.json", append(webhookJSON, '\n')},
+	}, nil
+}
+
+// firstDifference returns the first line, counting from 1, where want and
+// got differ, or ok if they are equal.
+func firstDifference(want, got []byte) (line int, wantLine, gotLine string, ok bool) {
+	if bytes.Equal(want, got) {
+		return 0, "", "", true
+	}
+	wantLines := strings.Split(string(want), "\n")
+	gotLines := strings.Split(string(got), "\n")
+	for i := 0; ; i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g || i >= len(wantLines) || i >= len(gotLines) {
+			return i + 1, strings.TrimSpace(w), strings.TrimSpace(g), false
+		}
+	}
+}
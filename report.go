@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// Report formats.
+const (
+	reportText     = "text"
+	reportMarkdown = "markdown"
+)
+
+// runReport checks the cluster and prints a summary of the scan, e.g. for
+// automation that posts post-deploy health to pull requests or release pages.
+// Like diff, it doesn't update the store or send notifications, which also
+// makes it the scan command, for owners checking their own workloads.
+func (m *monitor) runReport(ctx context.Context, format string, out io.Writer) error {
+	if format != reportText && format != reportMarkdown {
+		return fmt.Errorf("unknown format %q (expected %s or %s)", format, reportText, reportMarkdown)
+	}
+
+	now := time.Now()
+	checked, err := m.check(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to scan deployments: %w", err)
+	}
+
+	// Failing first, critical before warnings, then by name
+	rank := func(c checkedDeployment) int {
+		switch {
+		case c.result.Healthy:
+			return 2
+		case c.result.Severity == health.SeverityWarning:
+			return 1
+		}
+		return 0
+	}
+	sort.Slice(checked, func(i, j int) bool {
+		if ri, rj := rank(checked[i]), rank(checked[j]); ri != rj {
+			return ri < rj
+		}
+		return state.Key(checked[i].dep.Namespace, checked[i].dep.Name) < state.Key(checked[j].dep.Namespace, checked[j].dep.Name)
+	})
+	changes := state.Diff(m.store.LastScan(), scanResults(checked, now))
+
+	if format == reportMarkdown {
+		return m.writeMarkdownReport(out, checked, changes, now)
+	}
+
+	if len(checked) == 0 {
+		fmt.Fprintln(out, "No workloads matched.")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tSTATUS\tCLASSIFICATION\tREASON")
+	for _, c := range checked {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", state.Key(c.dep.Namespace, c.dep.Name), reportStatus(c.result), c.result.Classification, c.result.FailureReason)
+	}
+	return w.Flush()
+}
+
+// writeMarkdownReport renders a scan as Markdown that GitHub and Confluence
+// both display: a summary line, a table of failures and the changes since the
+// last stored scan.
+func (m *monitor) writeMarkdownReport(out io.Writer, checked []checkedDeployment, changes []state.Change, now time.Time) error {
+	var failing, warnings, stopped int
+	for _, c := range checked {
+		switch {
+		case c.result.Stopped:
+			stopped++
+		case c.result.Healthy:
+		case c.result.Severity == health.SeverityWarning:
+			warnings++
+		default:
+			failing++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Health report: %s\n\n", markdownEscape(m.cfg.ClusterName))
+	status := "✅ All services healthy"
+	if failing > 0 {
+		status = fmt.Sprintf("❌ %d service(s) failing", failing)
+	} else if warnings > 0 {
+		status = fmt.Sprintf("⚠️ %d service(s) with warnings", warnings)
+	}
+	fmt.Fprintf(&b, "**%s** · %d checked, %d healthy, %d failing, %d warning(s), %d stopped · %s\n",
+		status, len(checked), len(checked)-failing-warnings-stopped, failing, warnings, stopped, now.UTC().Format(time.RFC3339))
+
+	if failing+warnings > 0 {
+		b.WriteString("\n| Status | Service | Classification | Reason |\n|---|---|---|---|\n")
+		for _, c := range checked {
+			if c.result.Healthy {
+				continue
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | %s | %s |\n", reportStatus(c.result),
+				state.Key(c.dep.Namespace, c.dep.Name), c.result.Classification, markdownEscape(c.result.FailureReason))
+		}
+	}
+
+	if len(changes) > 0 {
+		b.WriteString("\n### Changes since the last scan\n\n")
+		for _, change := range changes {
+			curr := change.Current
+			service := state.Key(curr.Namespace, curr.Deployment)
+			switch change.Kind {
+			case state.ChangeNewlyFailed:
+				fmt.Fprintf(&b, "- ❌ `%s` newly failed: %s\n", service, markdownEscape(curr.Reason))
+			case state.ChangeRecovered:
+				fmt.Fprintf(&b, "- ✅ `%s` recovered (was: %s)\n", service, markdownEscape(change.Previous.Reason))
+			case state.ChangeReasonChanged:
+				fmt.Fprintf(&b, "- 🔁 `%s` changed from %s to %s: %s\n", service,
+					change.Previous.Classification, curr.Classification, markdownEscape(curr.Reason))
+			}
+		}
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func reportStatus(result *health.CheckResult) string {
+	switch {
+	case result.Stopped:
+		return "stopped"
+	case result.Healthy:
+		return "healthy"
+	case result.Severity == health.SeverityWarning:
+		return "warning"
+	}
+	return "failing"
+}
+
+// markdownEscape keeps free text such as failure reasons from breaking a
+// table row or being read as markup.
+var markdownEscape = strings.NewReplacer(
+	"|", `\|`,
+	"\n", " ",
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"<", "&lt;",
+	">", "&gt;",
+).Replace
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// observeRestarts records the container restarts since each deployment's
+// previous scan. Restarts of a deployment that is healthy again never alert,
+// so they're logged here and reported in the audit digest.
+func (m *monitor) observeRestarts(checked []checkedDeployment) {
+	observed := make([]state.DeploymentRestarts, 0, len(checked))
+	for _, c := range checked {
+		dep := state.DeploymentRestarts{Namespace: c.dep.Namespace, Deployment: c.dep.Name, Healthy: c.result.Healthy}
+		for _, r := range c.result.Restarts {
+			dep.Containers = append(dep.Containers, state.RestartCount{
+				Pod:        r.Pod,
+				Container:  r.Container,
+				Count:      r.Count,
+				Reason:     r.LastReason,
+				ExitCode:   r.LastExitCode,
+				FinishedAt: r.LastFinishedAt,
+			})
+		}
+		observed = append(observed, dep)
+	}
+
+	crashes, err := m.store.ObserveRestarts(observed, time.Now())
+	if err != nil {
+		log.Printf("Failed to record restarts: %v", err)
+	}
+	for _, crash := range crashes {
+		if crash.SelfRecovered {
+			log.Printf("Service %s/%s: container %s of pod %s restarted %d time(s) since the last scan and recovered (%s, exit code %d)",
+				crash.Namespace, crash.Deployment, crash.Container, crash.Pod, crash.Restarts, crash.Reason, crash.ExitCode)
+		}
+	}
+}
+
+// recentRestarts returns the deployment's last restarts for its alert.
+func (m *monitor) recentRestarts(dep health.DeploymentInfo) []health.Restart {
+	var restarts []health.Restart
+	for _, crash := range m.store.RecentCrashes(dep.Namespace, dep.Name, health.MaxRestarts) {
+		restarts = append(restarts, health.Restart{
+			Pod:       crash.Pod,
+			Container: crash.Container,
+			Count:     crash.Restarts,
+			Reason:    crash.Reason,
+			ExitCode:  crash.ExitCode,
+			At:        crash.At,
+		})
+	}
+	return restarts
+}
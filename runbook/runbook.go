@@ -0,0 +1,145 @@
+// runbook/runbook.go
+package runbook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/health"
+)
+
+const (
+	// URLAnnotation links a deployment to its runbook.
+	URLAnnotation = "runbook_url"
+	// MarkdownAnnotation references a ConfigMap holding a runbook snippet, as
+	// "name" (key runbook.md) or "name/key", in the deployment's namespace.
+	MarkdownAnnotation = "runbook_md"
+
+	defaultMarkdownKey = "runbook.md"
+	maxSnippetBytes    = 4096
+)
+
+// DefaultKnowledgeBase holds generic remediation steps per failure
+// classification. Config entries replace these per classification.
+var DefaultKnowledgeBase = map[string][]string{
+	health.ClassCrashLoop: {
+		"Check the logs of the previous container instance: kubectl logs <pod> -c <container> --previous",
+		"Look for missing configuration, failed dependency connections or panics at startup",
+		"If the crash started with a rollout, consider kubectl rollout undo",
+	},
+	health.ClassOOMKilled: {
+		"Compare memory usage with the container's limit (kubectl top pod)",
+		"Raise the memory limit or fix the leak; check heap settings such as -Xmx or GOMEMLIMIT",
+	},
+	health.ClassLivenessProbe: {
+		"The container isn't crashing: the kubelet kills it because its liveness probe fails; fix the probe rather than the code",
+		"Raise initialDelaySeconds, timeoutSeconds or failureThreshold, or add a startupProbe for slow starts",
+		"Keep the liveness endpoint cheap and independent of downstream dependencies",
+	},
+	health.ClassImagePull: {
+		"Verify the image name and tag exist in the registry",
+		"Check imagePullSecrets and registry credentials for the namespace",
+	},
+	health.ClassConfigError: {
+		"Check that referenced ConfigMaps and Secrets exist and contain the expected keys",
+		"kubectl describe pod <pod> shows the exact missing reference",
+	},
+	health.ClassInitCrashLoop: {
+		"Check the init container's logs: kubectl logs <pod> -c <container> --previous",
+		"Init containers often wait on migrations or dependencies; check that what it waits for is reachable",
+	},
+	health.ClassInitConfigError: {
+		"Check that ConfigMaps and Secrets referenced by the init container exist and contain the expected keys",
+		"kubectl describe pod <pod> shows the exact missing reference",
+	},
+	health.ClassInitWaiting: {
+		"kubectl describe pod <pod> and check Events for volume mount or image errors",
+	},
+	health.ClassPodNotRunning: {
+		"kubectl describe pod <pod> and check Events for scheduling or volume errors",
+		"Check node capacity and taints if the pod is Pending",
+	},
+	health.ClassUnschedulable: {
+		"Compare the pod's nodeSelector, node affinity and tolerations with node labels and taints (kubectl get nodes --show-labels)",
+		"Fix the constraint in the deployment, or label or untaint nodes so they match",
+	},
+	health.ClassNotReady: {
+		"Check the readiness probe endpoint and its timeouts",
+		"Verify downstream dependencies the readiness check relies on",
+	},
+	health.ClassAdmissionWebhook: {
+		"The infrastructure team owns this failure; check the webhook named in the event: kubectl get validatingwebhookconfigurations,mutatingwebhookconfigurations",
+		"If the webhook denied the request, its message says which policy the pod template violates",
+	},
+	health.ClassStuckTerminating: {
+		"The infrastructure team owns this failure; check the pod's finalizers and the controller that should remove them: kubectl get pod <pod> -o jsonpath='{.metadata.finalizers}'",
+		"Check the node's kubelet and the CSI driver for volumes that won't unmount or detach: kubectl describe node <node>",
+		"As a last resort, once the node is known to be down, kubectl delete pod <pod> --grace-period=0 --force",
+	},
+	health.ClassNoPods: {
+		"Check the ReplicaSet events: kubectl describe rs -l app=<deployment>",
+		"Look for quota or admission webhook errors preventing pod creation",
+	},
+	health.ClassScaledObject: {
+		"The service is scaled to zero by KEDA on purpose; fix the ScaledObject so it can scale back up: kubectl describe scaledobject -n <namespace>",
+		"Check that the TriggerAuthentication and the secrets it references exist and hold valid credentials",
+		"Check the KEDA operator logs for scaler errors",
+	},
+	health.ClassExternal: {
+		"The failure was reported by another system; follow its alert or check for details",
+		"The monitor's own checks pass, so compare the reported symptom with the recent logs below",
+	},
+}
+
+// Resolver adds runbook links, snippets and knowledge base steps to alerts.
+type Resolver struct {
+	client        kubernetes.Interface
+	knowledgeBase map[string][]string
+}
+
+func NewResolver(client kubernetes.Interface, overrides map[string][]string) *Resolver {
+	kb := make(map[string][]string, len(DefaultKnowledgeBase))
+	for class, steps := range DefaultKnowledgeBase {
+		kb[class] = steps
+	}
+	for class, steps := range overrides {
+		kb[class] = steps
+	}
+	return &Resolver{client: client, knowledgeBase: kb}
+}
+
+func (r *Resolver) Resolve(ctx context.Context, failedService *health.FailedService) error {
+	dep := failedService.Deployment
+	failedService.RunbookURL = dep.Annotations[URLAnnotation]
+	failedService.RemediationSteps = r.knowledgeBase[failedService.Classification]
+
+	ref := dep.Annotations[MarkdownAnnotation]
+	if ref == "" {
+		return nil
+	}
+
+	name, key := ref, defaultMarkdownKey
+	if i := strings.Index(ref, "/"); i >= 0 {
+		name, key = ref[:i], ref[i+1:]
+	}
+
+	cm, err := r.client.CoreV1().ConfigMaps(dep.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to read runbook ConfigMap %s: %w", name, err)
+	}
+
+	snippet, ok := cm.Data[key]
+	if !ok {
+		return fmt.Errorf("runbook ConfigMap %s has no key %q", name, key)
+	}
+	if len(snippet) > maxSnippetBytes {
+		snippet = snippet[:maxSnippetBytes] + "\n..."
+	}
+	failedService.RunbookSnippet = snippet
+
+	return nil
+}
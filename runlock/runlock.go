@@ -0,0 +1,202 @@
+// Package runlock keeps one-shot runs, e.g. from a CronJob, from overlapping.
+// A run holds a Lease for as long as it lasts, renewing it as it goes, and a
+// run that finds the Lease held by a live run exits or waits for it.
+package runlock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+)
+
+// HeldError is returned when another run holds the lock.
+type HeldError struct {
+	Holder string
+	// Since is when the holder acquired the lock
+	Since time.Time
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("another run (%s) has held the run lock since %s", e.Holder, e.Since.Format(time.RFC3339))
+}
+
+type Lock struct {
+	client   kubernetes.Interface
+	cfg      config.RunLockConfig
+	identity string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func New(client kubernetes.Interface, cfg config.RunLockConfig, identity string) *Lock {
+	return &Lock{client: client, cfg: cfg, identity: identity}
+}
+
+// Acquire takes the lock, failing with a *HeldError if a live run holds it,
+// and keeps renewing it until Release. An expired Lease is taken over, so a
+// crashed run only blocks others for lease_duration.
+func (l *Lock) Acquire(ctx context.Context) error {
+	if err := l.tryAcquire(ctx); err != nil {
+		return err
+	}
+
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go l.keepRenewing()
+	return nil
+}
+
+// Wait acquires the lock, polling until the run holding it is done or ctx
+// ends.
+func (l *Lock) Wait(ctx context.Context) error {
+	logged := false
+	for {
+		err := l.Acquire(ctx)
+		var held *HeldError
+		if !errors.As(err, &held) {
+			return err
+		}
+		if !logged {
+			log.Printf("%v; waiting for it to finish", err)
+			logged = true
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for the run lock: %w", err)
+		case <-time.After(l.cfg.LeaseDuration / 4):
+		}
+	}
+}
+
+// Release stops renewing the lock and deletes the Lease, so the next run
+// doesn't wait for it to expire.
+func (l *Lock) Release(ctx context.Context) error {
+	if l.stop == nil {
+		return nil
+	}
+	close(l.stop)
+	<-l.done
+
+	leases := l.client.CoordinationV1().Leases(l.cfg.LeaseNamespace)
+	lease, err := leases.Get(ctx, l.cfg.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != l.identity {
+		return nil
+	}
+	err = leases.Delete(ctx, l.cfg.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{ResourceVersion: &lease.ResourceVersion},
+	})
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		return nil
+	}
+	return err
+}
+
+func (l *Lock) tryAcquire(ctx context.Context) error {
+	leases := l.client.CoordinationV1().Leases(l.cfg.LeaseNamespace)
+	duration := int32(l.cfg.LeaseDuration.Seconds())
+	now := metav1.NewMicroTime(time.Now())
+
+	lease, err := leases.Get(ctx, l.cfg.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: l.cfg.Name},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &l.identity,
+				LeaseDurationSeconds: &duration,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			// Another run created it first
+			return l.tryAcquire(ctx)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if holder := held(lease, now.Time); holder != "" && holder != l.identity {
+		var since time.Time
+		if lease.Spec.AcquireTime != nil {
+			since = lease.Spec.AcquireTime.Time
+		}
+		return &HeldError{Holder: holder, Since: since}
+	}
+
+	lease.Spec.HolderIdentity = &l.identity
+	lease.Spec.LeaseDurationSeconds = &duration
+	lease.Spec.AcquireTime = &now
+	lease.Spec.RenewTime = &now
+	// The update fails on a conflict if another run took the Lease since
+	// it was read
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return l.tryAcquire(ctx)
+	}
+	return err
+}
+
+// held returns the holder of an unexpired Lease, or "".
+func held(lease *coordinationv1.Lease, now time.Time) string {
+	spec := lease.Spec
+	if spec.HolderIdentity == nil || spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+		return ""
+	}
+	if now.After(spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second)) {
+		return ""
+	}
+	return *spec.HolderIdentity
+}
+
+// keepRenewing renews the Lease three times per lease_duration until Release.
+func (l *Lock) keepRenewing() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.cfg.LeaseDuration / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			if err := l.renew(); err != nil {
+				log.Printf("Warning: failed to renew run lock: %v", err)
+			}
+		}
+	}
+}
+
+func (l *Lock) renew() error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.cfg.LeaseDuration/3)
+	defer cancel()
+
+	leases := l.client.CoordinationV1().Leases(l.cfg.LeaseNamespace)
+	lease, err := leases.Get(ctx, l.cfg.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != l.identity {
+		return fmt.Errorf("run lock was taken over by %s", held(lease, time.Now()))
+	}
+	now := metav1.NewMicroTime(time.Now())
+	lease.Spec.RenewTime = &now
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+)
+
+// scanSchedule decides which namespaces each daemon tick scans when some
+// namespaces have their own interval. Ticks come every period(); a namespace
+// is due once its interval has elapsed, give or take half a tick.
+type scanSchedule struct {
+	interval  time.Duration
+	overrides map[string]time.Duration
+
+	lastDefault time.Time
+	last        map[string]time.Time
+}
+
+func newScanSchedule(cfg config.DaemonConfig) *scanSchedule {
+	s := &scanSchedule{
+		interval:  cfg.Interval,
+		overrides: make(map[string]time.Duration, len(cfg.NamespaceOverrides)),
+		last:      make(map[string]time.Time, len(cfg.NamespaceOverrides)),
+	}
+	for ns, override := range cfg.NamespaceOverrides {
+		s.overrides[ns] = override.Interval
+	}
+	return s
+}
+
+// period is the tick interval: the shortest configured interval.
+func (s *scanSchedule) period() time.Duration {
+	period := s.interval
+	for _, interval := range s.overrides {
+		if interval < period {
+			period = interval
+		}
+	}
+	return period
+}
+
+// due marks the namespaces due at now as scanned and returns them as a scan
+// scope, or nil if every namespace is due.
+func (s *scanSchedule) due(now time.Time) func(namespace string) bool {
+	tolerance := s.period() / 2
+	elapsed := func(last time.Time, interval time.Duration) bool {
+		return last.IsZero() || now.Sub(last) >= interval-tolerance
+	}
+
+	defaultDue := elapsed(s.lastDefault, s.interval)
+	if defaultDue {
+		s.lastDefault = now
+	}
+
+	all := defaultDue
+	dueOverrides := make(map[string]bool, len(s.overrides))
+	for ns, interval := range s.overrides {
+		if elapsed(s.last[ns], interval) {
+			dueOverrides[ns] = true
+			s.last[ns] = now
+		} else {
+			all = false
+		}
+	}
+
+	if all {
+		return nil
+	}
+	return func(namespace string) bool {
+		if _, ok := s.overrides[namespace]; ok {
+			return dueOverrides[namespace]
+		}
+		return defaultDue
+	}
+}
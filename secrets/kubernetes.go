@@ -0,0 +1,96 @@
+// Package secrets loads notifier credentials from external secret stores.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+)
+
+// How long to wait before re-establishing a failed or expired watch.
+const watchRetryDelay = 10 * time.Second
+
+// Source provides a set of credentials as key/value pairs.
+type Source interface {
+	// Get reads the current credentials.
+	Get(ctx context.Context) (map[string][]byte, error)
+	// Watch calls onUpdate with fresh credentials until ctx is done.
+	Watch(ctx context.Context, onUpdate func(data map[string][]byte))
+}
+
+// KubernetesSource reads credentials from a Kubernetes Secret and watches it
+// for changes.
+type KubernetesSource struct {
+	client kubernetes.Interface
+	ref    config.SecretRef
+}
+
+func NewKubernetesSource(client kubernetes.Interface, ref config.SecretRef) *KubernetesSource {
+	return &KubernetesSource{client: client, ref: ref}
+}
+
+func (s *KubernetesSource) Get(ctx context.Context) (map[string][]byte, error) {
+	secret, err := s.client.CoreV1().Secrets(s.ref.Namespace).Get(ctx, s.ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s/%s: %w", s.ref.Namespace, s.ref.Name, err)
+	}
+	return secret.Data, nil
+}
+
+// Watch re-establishes the watch after errors. A deleted Secret leaves the
+// last credentials in place.
+func (s *KubernetesSource) Watch(ctx context.Context, onUpdate func(data map[string][]byte)) {
+	for ctx.Err() == nil {
+		if err := s.watchOnce(ctx, onUpdate); err != nil {
+			log.Printf("Warning: watch on secret %s/%s: %v", s.ref.Namespace, s.ref.Name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(watchRetryDelay):
+		}
+	}
+}
+
+func (s *KubernetesSource) watchOnce(ctx context.Context, onUpdate func(map[string][]byte)) error {
+	// Re-read first so updates made while the watch was down aren't missed
+	secret, err := s.client.CoreV1().Secrets(s.ref.Namespace).Get(ctx, s.ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	onUpdate(secret.Data)
+
+	w, err := s.client.CoreV1().Secrets(s.ref.Namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", s.ref.Name).String(),
+		ResourceVersion: secret.ResourceVersion,
+	})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	for event := range w.ResultChan() {
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			if secret, ok := event.Object.(*corev1.Secret); ok {
+				log.Printf("Reloaded credentials from secret %s/%s", s.ref.Namespace, s.ref.Name)
+				onUpdate(secret.Data)
+			}
+		case watch.Deleted:
+			log.Printf("Warning: secret %s/%s was deleted, keeping the last credentials", s.ref.Namespace, s.ref.Name)
+		case watch.Error:
+			return apierrors.FromObject(event.Object)
+		}
+	}
+	return nil
+}
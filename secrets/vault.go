@@ -0,0 +1,181 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/transport"
+)
+
+// Vault logs in with the Kubernetes auth method and reads KV secrets. The
+// token is renewed, or a new one obtained, whenever it is close to expiry.
+type Vault struct {
+	cfg        config.VaultConfig
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	ttl       time.Duration
+	renewable bool
+}
+
+func NewVault(cfg config.VaultConfig) *Vault {
+	return &Vault{
+		cfg:        cfg,
+		httpClient: transport.HTTPClient(config.ProxyConfig{}, nil, 10*time.Second),
+	}
+}
+
+// Source returns a credential source for a KV (v1 or v2) secret path, e.g.
+// "secret/data/k8s-health/smtp".
+func (v *Vault) Source(path string) *VaultSource {
+	return &VaultSource{vault: v, path: strings.Trim(path, "/")}
+}
+
+type vaultAuth struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// ensureToken returns a token valid for at least a third of its TTL.
+func (v *Vault) ensureToken(ctx context.Context) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.token != "" && time.Until(v.expiresAt) > v.ttl/3 {
+		return v.token, nil
+	}
+
+	if v.token != "" && v.renewable {
+		var auth vaultAuth
+		err := v.do(ctx, http.MethodPost, "auth/token/renew-self", v.token, nil, &auth)
+		if err == nil {
+			v.setToken(auth)
+			return v.token, nil
+		}
+		log.Printf("Warning: vault token renewal failed, logging in again: %v", err)
+	}
+
+	jwt, err := os.ReadFile(v.cfg.TokenPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read service account token: %w", err)
+	}
+
+	var auth vaultAuth
+	body := map[string]string{"role": v.cfg.Role, "jwt": strings.TrimSpace(string(jwt))}
+	if err := v.do(ctx, http.MethodPost, "auth/"+strings.Trim(v.cfg.AuthPath, "/")+"/login", "", body, &auth); err != nil {
+		return "", fmt.Errorf("vault login failed: %w", err)
+	}
+	v.setToken(auth)
+	return v.token, nil
+}
+
+// setToken stores a login or renewal response. Callers must hold v.mu.
+func (v *Vault) setToken(auth vaultAuth) {
+	v.token = auth.Auth.ClientToken
+	v.ttl = time.Duration(auth.Auth.LeaseDuration) * time.Second
+	v.expiresAt = time.Now().Add(v.ttl)
+	v.renewable = auth.Auth.Renewable
+}
+
+func (v *Vault) do(ctx context.Context, method, path, token string, in, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(v.cfg.Address, "/")+"/v1/"+path, &body)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault returned status %d for %s", resp.StatusCode, path)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// VaultSource reads one KV secret and polls it for changes.
+type VaultSource struct {
+	vault *Vault
+	path  string
+}
+
+func (s *VaultSource) Get(ctx context.Context) (map[string][]byte, error) {
+	token, err := s.vault.ensureToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := s.vault.do(ctx, http.MethodGet, s.path, token, nil, &secret); err != nil {
+		return nil, fmt.Errorf("failed to read vault secret %s: %w", s.path, err)
+	}
+
+	// KV v2 nests the values under data.data next to data.metadata
+	values := secret.Data
+	if nested, ok := values["data"].(map[string]interface{}); ok {
+		if _, ok := values["metadata"]; ok {
+			values = nested
+		}
+	}
+
+	data := make(map[string][]byte, len(values))
+	for key, value := range values {
+		if str, ok := value.(string); ok {
+			data[key] = []byte(str)
+		}
+	}
+	return data, nil
+}
+
+// Watch re-reads the secret every refresh interval, which also keeps the
+// token renewed.
+func (s *VaultSource) Watch(ctx context.Context, onUpdate func(data map[string][]byte)) {
+	ticker := time.NewTicker(s.vault.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		data, err := s.Get(ctx)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		onUpdate(data)
+	}
+}
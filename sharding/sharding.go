@@ -0,0 +1,141 @@
+// Package sharding splits namespaces between monitor replicas. Every replica
+// keeps a Lease alive; namespaces are assigned to the live replicas by
+// rendezvous hashing, so a replica joining or leaving only moves its share.
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+)
+
+// groupLabel marks the member Leases of a shard group.
+const groupLabel = "k8s-health-monitor/shard-group"
+
+type Sharder struct {
+	client   kubernetes.Interface
+	cfg      config.ShardingConfig
+	identity string
+
+	mu      sync.Mutex
+	members []string
+}
+
+func New(client kubernetes.Interface, cfg config.ShardingConfig, identity string) *Sharder {
+	return &Sharder{client: client, cfg: cfg, identity: identity}
+}
+
+// Sync renews this replica's Lease and refreshes the list of live members.
+// Call it at the start of every run.
+func (s *Sharder) Sync(ctx context.Context) error {
+	if err := s.renew(ctx); err != nil {
+		return fmt.Errorf("failed to renew shard lease: %w", err)
+	}
+
+	leases, err := s.client.CoordinationV1().Leases(s.cfg.LeaseNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: groupLabel + "=" + s.cfg.Group,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list shard leases: %w", err)
+	}
+
+	now := time.Now()
+	members := []string{s.identity}
+	for _, lease := range leases.Items {
+		spec := lease.Spec
+		if spec.HolderIdentity == nil || *spec.HolderIdentity == s.identity ||
+			spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+			continue
+		}
+		expires := spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second)
+		if now.Before(expires) {
+			members = append(members, *spec.HolderIdentity)
+		}
+	}
+	sort.Strings(members)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(members) != len(s.members) {
+		log.Printf("Shard group %s has %d live member(s): %v", s.cfg.Group, len(members), members)
+	}
+	s.members = members
+	return nil
+}
+
+func (s *Sharder) renew(ctx context.Context) error {
+	leases := s.client.CoordinationV1().Leases(s.cfg.LeaseNamespace)
+	name := s.cfg.Group + "-" + s.identity
+	duration := int32(s.cfg.LeaseDuration.Seconds())
+	now := metav1.NewMicroTime(time.Now())
+
+	lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{groupLabel: s.cfg.Group},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &s.identity,
+				LeaseDurationSeconds: &duration,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	lease.Spec.HolderIdentity = &s.identity
+	lease.Spec.LeaseDurationSeconds = &duration
+	lease.Spec.RenewTime = &now
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+// Release deletes this replica's Lease so the others take over its share
+// without waiting for it to expire.
+func (s *Sharder) Release(ctx context.Context) error {
+	err := s.client.CoordinationV1().Leases(s.cfg.LeaseNamespace).Delete(ctx, s.cfg.Group+"-"+s.identity, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// Owns reports whether this replica should scan the namespace. Before the
+// first successful Sync it owns everything, so a Lease outage means duplicate
+// scans rather than missed ones.
+func (s *Sharder) Owns(namespace string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.members) == 0 {
+		return true
+	}
+
+	var owner string
+	var best uint64
+	for _, member := range s.members {
+		h := fnv.New64a()
+		h.Write([]byte(member + "/" + namespace))
+		if score := h.Sum64(); owner == "" || score > best {
+			owner, best = member, score
+		}
+	}
+	return owner == s.identity
+}
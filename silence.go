@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+	"github.com/Bharath-H-R/k8s-health/email"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// silenceRequest is the body of POST /api/v1/silences.
+type silenceRequest struct {
+	Namespace  string `json:"namespace"`
+	Deployment string `json:"deployment"`
+	Duration   string `json:"duration"`
+	Reason     string `json:"reason,omitempty"`
+	CreatedBy  string `json:"created_by,omitempty"`
+	// Starts schedules the silence as a maintenance window starting at an
+	// RFC 3339 time; empty silences at once
+	Starts string `json:"starts,omitempty"`
+}
+
+// silenceBackend is where the silence command reads and writes silences:
+// the state file directly, or a running daemon's REST API.
+type silenceBackend interface {
+	AddSilence(silence state.Silence) error
+	Silences(now time.Time) []state.Silence
+	RemoveSilence(namespace, deployment string) (bool, error)
+}
+
+// runSilenceCommand implements
+//
+//	silence <namespace/deployment> <duration> [-starts time] [-reason text] [-by name]
+//	silence list
+//	silence remove <namespace/deployment>
+//
+// Use -server to go through a running daemon; writing the state file while
+// the daemon runs would be overwritten by its next save.
+func runSilenceCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("silence", flag.ExitOnError)
+	configPath := flags.String("config", "./config.yaml", "Path to config file")
+	server := flags.String("server", "", "Base URL of a running daemon, e.g. http://k8s-health:8080")
+	reason := flags.String("reason", "", "Why notifications are silenced")
+	by := flags.String("by", os.Getenv("USER"), "Who is silencing")
+	starts := flags.String("starts", "", "Schedule a maintenance window starting at this RFC 3339 time instead of silencing now")
+
+	positional := parseInterleaved(flags, args)
+
+	var backend silenceBackend
+	if *server != "" {
+		backend = newAPIClient(*server)
+	} else {
+		store, err := openStateFile(*configPath)
+		if err != nil {
+			return err
+		}
+		backend = store
+	}
+
+	switch {
+	case len(positional) == 1 && positional[0] == "list":
+		silences := backend.Silences(time.Now())
+		if client, ok := backend.(*apiClient); ok && client.err != nil {
+			return client.err
+		}
+		return printSilences(out, silences)
+
+	case len(positional) == 2 && positional[0] == "remove":
+		namespace, deployment, err := splitService(positional[1])
+		if err != nil {
+			return err
+		}
+		removed, err := backend.RemoveSilence(namespace, deployment)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return fmt.Errorf("%s is not silenced", positional[1])
+		}
+		fmt.Fprintf(out, "Removed silence for %s\n", positional[1])
+		return nil
+
+	case len(positional) == 2:
+		namespace, deployment, err := splitService(positional[0])
+		if err != nil {
+			return err
+		}
+		duration, err := time.ParseDuration(positional[1])
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("invalid duration %q", positional[1])
+		}
+		now := time.Now()
+		startsAt, err := parseTimeParam(*starts, time.Time{})
+		if err != nil {
+			return err
+		}
+		silence := state.Silence{
+			Namespace:  namespace,
+			Deployment: deployment,
+			Until:      silenceStart(now, startsAt).Add(duration),
+			Reason:     *reason,
+			CreatedBy:  *by,
+			CreatedAt:  now,
+			StartsAt:   startsAt,
+		}
+		if err := backend.AddSilence(silence); err != nil {
+			return err
+		}
+		if !startsAt.IsZero() {
+			fmt.Fprintf(out, "Scheduled maintenance for %s from %s until %s\n", positional[0],
+				startsAt.Format(time.RFC3339), silence.Until.Format(time.RFC3339))
+			return nil
+		}
+		fmt.Fprintf(out, "Silenced %s until %s\n", positional[0], silence.Until.Format(time.RFC3339))
+		return nil
+	}
+
+	return fmt.Errorf("usage: silence <namespace/deployment> <duration> [-starts time] [-reason text] | silence list | silence remove <namespace/deployment>")
+}
+
+// silenceStart returns when a silence created at now takes effect.
+func silenceStart(now, startsAt time.Time) time.Time {
+	if startsAt.IsZero() {
+		return now
+	}
+	return startsAt
+}
+
+func printSilences(out io.Writer, silences []state.Silence) error {
+	if len(silences) == 0 {
+		fmt.Fprintln(out, "No active silences.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tFROM\tUNTIL\tBY\tREASON")
+	for _, s := range silences {
+		from := "now"
+		if !s.StartsAt.IsZero() {
+			from = s.StartsAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", state.Key(s.Namespace, s.Deployment),
+			from, s.Until.Format(time.RFC3339), s.CreatedBy, s.Reason)
+	}
+	return w.Flush()
+}
+
+// serveSilences implements the silences REST API:
+//
+//	GET    /api/v1/silences                  list active silences
+//	POST   /api/v1/silences                  add one (silenceRequest body)
+//	DELETE /api/v1/silences/{ns}/{deployment} remove one early
+func (m *monitor) serveSilences(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/silences":
+		principal := auth.FromContext(r.Context())
+		silences := []state.Silence{}
+		for _, silence := range m.store.Silences(time.Now()) {
+			if principal.CanView(silence.Namespace) {
+				silences = append(silences, silence)
+			}
+		}
+		writeJSON(w, http.StatusOK, silences)
+
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/silences":
+		var req silenceRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 || req.Namespace == "" || req.Deployment == "" {
+			http.Error(w, "namespace, deployment and a positive duration are required", http.StatusBadRequest)
+			return
+		}
+		startsAt, err := parseTimeParam(req.Starts, time.Time{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		principal := auth.FromContext(r.Context())
+		if !principal.CanEdit(req.Namespace) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		// Authenticated callers act under their own name
+		if principal != nil {
+			req.CreatedBy = principal.Name
+		}
+		now := time.Now()
+		silence := state.Silence{
+			Namespace:  req.Namespace,
+			Deployment: req.Deployment,
+			Until:      silenceStart(now, startsAt).Add(duration),
+			Reason:     req.Reason,
+			CreatedBy:  req.CreatedBy,
+			CreatedAt:  now,
+			StartsAt:   startsAt,
+		}
+		if err := m.store.AddSilence(silence); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !startsAt.IsZero() && m.cfg.MaintenanceInvites && !m.dryRun {
+			// Finding the owners waits for any scan in progress
+			m.background.Add(1)
+			go func() {
+				defer m.background.Done()
+				m.sendMaintenanceInvite(context.Background(), silence)
+			}()
+		}
+		writeJSON(w, http.StatusCreated, silence)
+
+	case r.Method == http.MethodDelete:
+		namespace, deployment, err := splitService(strings.TrimPrefix(r.URL.Path, "/api/v1/silences/"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !auth.FromContext(r.Context()).CanEdit(namespace) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		removed, err := m.store.RemoveSilence(namespace, deployment)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !removed {
+			http.Error(w, "not silenced", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (c *apiClient) AddSilence(silence state.Silence) error {
+	req := silenceRequest{
+		Namespace:  silence.Namespace,
+		Deployment: silence.Deployment,
+		Duration:   silence.Until.Sub(silenceStart(silence.CreatedAt, silence.StartsAt)).String(),
+		Reason:     silence.Reason,
+		CreatedBy:  silence.CreatedBy,
+	}
+	if !silence.StartsAt.IsZero() {
+		req.Starts = silence.StartsAt.Format(time.RFC3339)
+	}
+	body, _ := json.Marshal(req)
+	resp, err := c.httpClient.Post(c.baseURL+"/api/v1/silences", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp, http.StatusCreated)
+}
+
+// Silences returns nil on error; the error is kept in c.err.
+func (c *apiClient) Silences(now time.Time) []state.Silence {
+	resp, err := c.httpClient.Get(c.baseURL + "/api/v1/silences")
+	if err != nil {
+		c.err = err
+		return nil
+	}
+	defer resp.Body.Close()
+	if c.err = checkResponse(resp, http.StatusOK); c.err != nil {
+		return nil
+	}
+
+	var silences []state.Silence
+	c.err = json.NewDecoder(resp.Body).Decode(&silences)
+	return silences
+}
+
+func (c *apiClient) RemoveSilence(namespace, deployment string) (bool, error) {
+	req, err := http.NewRequest(http.MethodDelete, c.baseURL+"/api/v1/silences/"+namespace+"/"+deployment, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return true, checkResponse(resp, http.StatusNoContent)
+}
+
+// sendMaintenanceInvite emails a calendar event for a scheduled silence to
+// the deployment's owners and the infra DL.
+func (m *monitor) sendMaintenanceInvite(ctx context.Context, silence state.Silence) {
+	m.scanMu.Lock()
+	deployments, err := m.scanner.ScanDeploymentsIn(ctx, func(ns string) bool { return ns == silence.Namespace })
+	m.scanMu.Unlock()
+	if err != nil {
+		log.Printf("Warning: failed to find the owners of %s/%s: %v", silence.Namespace, silence.Deployment, err)
+	}
+
+	var recipients []string
+	seen := make(map[string]bool)
+	add := func(address string) {
+		if address != "" && !seen[address] {
+			seen[address] = true
+			recipients = append(recipients, address)
+		}
+	}
+	for _, dep := range deployments {
+		if dep.Name == silence.Deployment {
+			add(dep.OwnerEmail)
+			add(dep.OwnerDlEmail)
+		}
+	}
+	add(m.cfg.InfraEmail)
+	if len(recipients) == 0 {
+		log.Printf("Warning: no one to invite to maintenance of %s/%s", silence.Namespace, silence.Deployment)
+		return
+	}
+
+	err = m.emailSender.SendMaintenanceInvite(recipients[:1], recipients[1:], email.Maintenance{
+		Namespace:  silence.Namespace,
+		Deployment: silence.Deployment,
+		Start:      silence.StartsAt,
+		End:        silence.Until,
+		Reason:     silence.Reason,
+		CreatedBy:  silence.CreatedBy,
+	})
+	if err != nil {
+		log.Printf("Failed to send maintenance invite for %s/%s: %v", silence.Namespace, silence.Deployment, err)
+		return
+	}
+	m.usage.AddNotification()
+	log.Printf("Maintenance invite for %s/%s sent to %s", silence.Namespace, silence.Deployment, strings.Join(recipients, ", "))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/alertmanager"
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// silenceSync mirrors silences between the state file and Alertmanager, so
+// an outage only needs silencing once.
+type silenceSync struct {
+	client *alertmanager.Client
+	// known are the Alertmanager silences mirrored here as of the last sync,
+	// to tell ones removed here from ones new there
+	known map[string]bool
+}
+
+func newSilenceSync(client *alertmanager.Client) *silenceSync {
+	return &silenceSync{client: client, known: make(map[string]bool)}
+}
+
+// sync brings the store's silences and Alertmanager's in line. Changes made
+// on either side since the last sync win; a deployment has at most one
+// silence here, so an Alertmanager silence for an already silenced
+// deployment isn't mirrored.
+func (s *silenceSync) sync(ctx context.Context, store *state.Store, now time.Time) error {
+	remote, err := s.client.Silences(ctx)
+	if err != nil {
+		return err
+	}
+
+	mirrored := make(map[string]bool)
+	silenced := make(map[string]bool)
+	for _, silence := range store.Silences(now) {
+		silenced[state.Key(silence.Namespace, silence.Deployment)] = true
+		id := silence.AlertmanagerID
+		remoteSilence, ok := remote[id]
+		switch {
+		case id == "":
+			if silence.AlertmanagerID, err = s.client.CreateSilence(ctx, silence); err != nil {
+				log.Printf("Warning: %s/%s: %v", silence.Namespace, silence.Deployment, err)
+				continue
+			}
+			if err := store.AddSilence(silence); err != nil {
+				log.Printf("Failed to save silence for %s/%s: %v", silence.Namespace, silence.Deployment, err)
+			}
+			mirrored[silence.AlertmanagerID] = true
+		case ok:
+			mirrored[id] = true
+			if !remoteSilence.Until.Equal(silence.Until) {
+				silence.Until = remoteSilence.Until
+				if err := store.AddSilence(silence); err != nil {
+					log.Printf("Failed to save silence for %s/%s: %v", silence.Namespace, silence.Deployment, err)
+				}
+			}
+		default:
+			log.Printf("Silence for %s/%s was expired in Alertmanager, removing it", silence.Namespace, silence.Deployment)
+			if _, err := store.RemoveSilence(silence.Namespace, silence.Deployment); err != nil {
+				log.Printf("Failed to remove silence for %s/%s: %v", silence.Namespace, silence.Deployment, err)
+			}
+		}
+	}
+
+	for id, silence := range remote {
+		if mirrored[id] {
+			continue
+		}
+		if s.known[id] {
+			log.Printf("Silence for %s/%s was removed, expiring it in Alertmanager", silence.Namespace, silence.Deployment)
+			if err := s.client.ExpireSilence(ctx, id); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			continue
+		}
+		if silenced[state.Key(silence.Namespace, silence.Deployment)] {
+			continue
+		}
+		log.Printf("Mirroring Alertmanager silence for %s/%s by %s until %s",
+			silence.Namespace, silence.Deployment, silence.CreatedBy, silence.Until.Format(time.RFC3339))
+		if err := store.AddSilence(silence); err != nil {
+			log.Printf("Failed to save silence for %s/%s: %v", silence.Namespace, silence.Deployment, err)
+			continue
+		}
+		mirrored[id] = true
+	}
+
+	s.known = mirrored
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// loadFixtures returns a fake clientset holding the objects in the YAML and
+// JSON manifests in dir, e.g. Deployments, Pods, Events, Nodes and
+// ConfigMaps. Namespaces the objects are in are created if the fixtures
+// don't define them.
+func loadFixtures(dir string) (k8s.Interface, error) {
+	var paths []string
+	for _, pattern := range []string{"*.yaml", "*.yml", "*.json"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no fixtures found in %s", dir)
+	}
+
+	var objects []runtime.Object
+	namespaces := make(map[string]bool)
+	defined := make(map[string]bool)
+	for _, path := range paths {
+		objs, err := decodeManifests(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				defined[ns.Name] = true
+			} else if meta, ok := obj.(metav1.Object); ok && meta.GetNamespace() != "" {
+				namespaces[meta.GetNamespace()] = true
+			}
+			objects = append(objects, obj)
+		}
+	}
+	for name := range namespaces {
+		if !defined[name] {
+			objects = append(objects, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		}
+	}
+
+	return fake.NewSimpleClientset(objects...), nil
+}
+
+// blank reports whether a YAML document has only comments and separators.
+func blank(doc []byte) bool {
+	for _, line := range bytes.Split(doc, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && line[0] != '#' && string(line) != "---" {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeManifests decodes every document in a multi-document manifest file.
+func decodeManifests(path string) ([]runtime.Object, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var objects []runtime.Object
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(f))
+	decoder := scheme.Codecs.UniversalDeserializer()
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if blank(doc) {
+			continue
+		}
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if list, ok := obj.(*corev1.List); ok {
+			for _, item := range list.Items {
+				itemObj, _, err := decoder.Decode(item.Raw, nil, nil)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", path, err)
+				}
+				objects = append(objects, itemObj)
+			}
+			continue
+		}
+		objects = append(objects, obj)
+	}
+}
@@ -0,0 +1,201 @@
+// slack/actions.go
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/state"
+)
+
+// Requests older than this are rejected to prevent replay.
+const maxRequestAge = 5 * time.Minute
+
+type interactionPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+		Name     string `json:"name"`
+	} `json:"user"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID       string `json:"action_id"`
+		Value          string `json:"value"`
+		SelectedOption struct {
+			Value string `json:"value"`
+		} `json:"selected_option"`
+	} `json:"actions"`
+}
+
+// ActionHandler receives Slack interactivity callbacks for alert buttons and
+// records the result in the state store.
+type ActionHandler struct {
+	notifier *Notifier
+	store    *state.Store
+}
+
+func NewActionHandler(notifier *Notifier, store *state.Store) *ActionHandler {
+	return &ActionHandler{notifier: notifier, store: store}
+}
+
+func (h *ActionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if err := verifySignature(h.notifier.settings().SigningSecret, r.Header, body); err != nil {
+		log.Printf("Rejected slack callback: %v", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form body", http.StatusBadRequest)
+		return
+	}
+
+	var payload interactionPayload
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	user := payload.User.Username
+	if user == "" {
+		user = payload.User.Name
+	}
+
+	for _, action := range payload.Actions {
+		message, err := h.handleAction(action.ActionID, action.Value, action.SelectedOption.Value, user)
+		if err != nil {
+			log.Printf("Slack action %s by %s failed: %v", action.ActionID, user, err)
+			message = fmt.Sprintf(":warning: %v", err)
+		} else {
+			log.Printf("Slack action %s by %s: %s", action.ActionID, user, message)
+		}
+
+		if payload.ResponseURL != "" {
+			if err := h.notifier.post(payload.ResponseURL, map[string]interface{}{
+				"response_type":    "in_channel",
+				"replace_original": false,
+				"text":             message,
+			}); err != nil {
+				log.Printf("Failed to respond to slack action: %v", err)
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *ActionHandler) handleAction(actionID, value, selected, user string) (string, error) {
+	now := time.Now()
+
+	switch actionID {
+	case ActionAcknowledge:
+		namespace, deployment, err := splitKey(value)
+		if err != nil {
+			return "", err
+		}
+		if _, err := h.store.Acknowledge(namespace, deployment, user, now); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(":eyes: %s/%s acknowledged by %s", namespace, deployment, user), nil
+
+	case ActionSilence:
+		parts := strings.SplitN(selected, "|", 2)
+		if len(parts) != 2 {
+			return "", fmt.Errorf("invalid silence option %q", selected)
+		}
+		namespace, deployment, err := splitKey(parts[0])
+		if err != nil {
+			return "", err
+		}
+		duration, err := time.ParseDuration(parts[1])
+		if err != nil {
+			return "", fmt.Errorf("invalid silence duration %q", parts[1])
+		}
+		err = h.store.AddSilence(state.Silence{
+			Namespace:  namespace,
+			Deployment: deployment,
+			Until:      now.Add(duration),
+			Reason:     "Silenced from Slack",
+			CreatedBy:  user,
+			CreatedAt:  now,
+		})
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(":no_bell: %s/%s silenced for %s by %s", namespace, deployment, duration, user), nil
+
+	case ActionEscalate:
+		namespace, deployment, err := splitKey(value)
+		if err != nil {
+			return "", err
+		}
+		if _, err := h.store.Escalate(namespace, deployment, user, now); err != nil {
+			return "", err
+		}
+		message := fmt.Sprintf(":rotating_light: %s/%s escalated by %s", namespace, deployment, user)
+		if mention := h.notifier.settings().EscalationMention; mention != "" {
+			message += " " + mention
+		}
+		return message, nil
+	}
+
+	return "", fmt.Errorf("unknown action %q", actionID)
+}
+
+// verifySignature checks the Slack request signature (v0 HMAC-SHA256).
+func verifySignature(secret string, header http.Header, body []byte) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	signature := header.Get("X-Slack-Signature")
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("missing signature headers")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	if age := time.Since(time.Unix(ts, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return fmt.Errorf("request timestamp too old")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func splitKey(key string) (string, string, error) {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid service %q", key)
+	}
+	return parts[0], parts[1], nil
+}
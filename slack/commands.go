@@ -0,0 +1,96 @@
+// slack/commands.go
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Slack expects an answer to a slash command within 3 seconds; slower
+// commands answer later through the response URL.
+const commandDeadline = 2500 * time.Millisecond
+
+// How long a slow command may keep running after the initial answer.
+const commandTimeout = 2 * time.Minute
+
+// CommandFunc runs a chat command such as "status payments/checkout" on
+// behalf of user and returns the reply.
+type CommandFunc func(ctx context.Context, user, text string) string
+
+// CommandHandler serves a Slack slash command (e.g. /k8s-health), verified
+// with the app's signing secret.
+type CommandHandler struct {
+	notifier *Notifier
+	run      CommandFunc
+}
+
+func NewCommandHandler(notifier *Notifier, run CommandFunc) *CommandHandler {
+	return &CommandHandler{notifier: notifier, run: run}
+}
+
+func (h *CommandHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if err := verifySignature(h.notifier.settings().SigningSecret, r.Header, body); err != nil {
+		log.Printf("Rejected slack command: %v", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form body", http.StatusBadRequest)
+		return
+	}
+	user, text, responseURL := form.Get("user_name"), form.Get("text"), form.Get("response_url")
+	log.Printf("Slack command %s %q by %s", form.Get("command"), text, user)
+
+	// The request context ends with this response, so commands get their own
+	reply := make(chan string, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		defer cancel()
+		reply <- h.run(ctx, user, text)
+	}()
+
+	select {
+	case message := <-reply:
+		writeCommandResponse(w, message)
+	case <-time.After(commandDeadline):
+		writeCommandResponse(w, ":hourglass_flowing_sand: Working on it...")
+		go func() {
+			message := <-reply
+			if responseURL == "" {
+				return
+			}
+			if err := h.notifier.post(responseURL, map[string]interface{}{
+				"response_type": "in_channel",
+				"text":          message,
+			}); err != nil {
+				log.Printf("Failed to respond to slack command: %v", err)
+			}
+		}()
+	}
+}
+
+func writeCommandResponse(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"response_type": "in_channel",
+		"text":          message,
+	})
+}
@@ -0,0 +1,367 @@
+// slack/notifier.go
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/outbox"
+	"github.com/Bharath-H-R/k8s-health/transport"
+)
+
+// Action IDs used by the interactive alert buttons.
+const (
+	ActionAcknowledge = "acknowledge"
+	ActionSilence     = "silence"
+	ActionEscalate    = "escalate"
+)
+
+// Silence durations offered in the alert's silence menu.
+var silenceOptions = []struct {
+	label    string
+	duration string
+}{
+	{"1 hour", "1h"},
+	{"4 hours", "4h"},
+	{"24 hours", "24h"},
+}
+
+type Notifier struct {
+	mu         sync.RWMutex
+	config     config.SlackConfig
+	httpClient *http.Client
+	// outbox, if set, receives messages instead of Slack
+	outbox *outbox.Dir
+}
+
+func NewNotifier(cfg config.SlackConfig, proxy config.ProxyConfig) (*Notifier, error) {
+	tlsConfig, err := transport.TLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("slack tls: %w", err)
+	}
+	return &Notifier{
+		config:     cfg,
+		httpClient: transport.HTTPClient(proxy, tlsConfig, 10*time.Second),
+	}, nil
+}
+
+// SetOutbox writes messages to the outbox instead of posting them.
+func (n *Notifier) SetOutbox(dir *outbox.Dir) {
+	n.outbox = dir
+}
+
+// SetCredentials replaces the webhook URL and signing secret, e.g. after a
+// Secret rotation.
+func (n *Notifier) SetCredentials(webhookURL, signingSecret string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.config.WebhookURL = webhookURL
+	n.config.SigningSecret = signingSecret
+}
+
+func (n *Notifier) settings() config.SlackConfig {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.config
+}
+
+// SendHealthAlert posts an alert for a failed service. Interactive buttons are
+// only included when a signing secret is configured, since the callbacks
+// can't be verified otherwise.
+func (n *Notifier) SendHealthAlert(failedService health.FailedService) error {
+	return n.post(n.settings().WebhookURL, n.AlertPayload(failedService))
+}
+
+// AlertPayload returns the message SendHealthAlert posts for a failure.
+func (n *Notifier) AlertPayload(failedService health.FailedService) map[string]interface{} {
+	dep := failedService.Deployment
+	key := dep.Namespace + "/" + dep.Name
+
+	header := fmt.Sprintf(":red_circle: %s is DOWN", key)
+	if failedService.Severity == health.SeverityWarning {
+		header = fmt.Sprintf(":large_yellow_circle: %s is unhealthy", key)
+	}
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": plainText(header),
+		},
+		{
+			"type": "section",
+			"fields": []map[string]interface{}{
+				markdown(fmt.Sprintf("*Reason:*\n%s", failedService.FailureReason)),
+				markdown(fmt.Sprintf("*Owner:*\n%s", dep.OwnerEmail)),
+				markdown(fmt.Sprintf("*Classification:*\n%s", failedService.Classification)),
+				markdown(fmt.Sprintf("*Checked at:*\n%s", failedService.CheckTime.Format(time.RFC1123))),
+			},
+		},
+	}
+	if failedService.Severity != "" {
+		fields := blocks[1]["fields"].([]map[string]interface{})
+		blocks[1]["fields"] = append(fields, markdown("*Severity:*\n"+failedService.Severity))
+	}
+	if failedService.IncidentID != "" {
+		fields := blocks[1]["fields"].([]map[string]interface{})
+		blocks[1]["fields"] = append(fields, markdown(fmt.Sprintf("*Incident:*\n`%s`", failedService.IncidentID)))
+	}
+	if dep.Revision != "" || len(dep.Images) > 0 {
+		var images []string
+		for _, image := range dep.Images {
+			images = append(images, fmt.Sprintf("%s: `%s`", image.Container, image.Image))
+		}
+		version := strings.Join(images, "\n")
+		if dep.Revision != "" {
+			version = fmt.Sprintf("revision %s\n%s", dep.Revision, version)
+		}
+		fields := blocks[1]["fields"].([]map[string]interface{})
+		blocks[1]["fields"] = append(fields, markdown("*Version:*\n"+strings.TrimSpace(version)))
+	}
+	if !dep.LastRollout.IsZero() {
+		fields := blocks[1]["fields"].([]map[string]interface{})
+		blocks[1]["fields"] = append(fields, markdown(fmt.Sprintf("*Last rollout:*\n%s (%v before this check)",
+			dep.LastRollout.Format(time.RFC1123), failedService.CheckTime.Sub(dep.LastRollout).Round(time.Minute))))
+	}
+	if len(failedService.Pods) > 1 {
+		fields := blocks[1]["fields"].([]map[string]interface{})
+		blocks[1]["fields"] = append(fields, markdown(fmt.Sprintf("*Failing pods:*\n%d of %d",
+			len(failedService.Pods), failedService.TotalPods)))
+	}
+	if failedService.AcknowledgedBy != "" {
+		fields := blocks[1]["fields"].([]map[string]interface{})
+		blocks[1]["fields"] = append(fields, markdown(fmt.Sprintf("*Acknowledged by:*\n%s at %s",
+			failedService.AcknowledgedBy, failedService.AcknowledgedAt.Format(time.RFC1123))))
+	}
+
+	if change := failedService.Change; change != nil {
+		var parts []string
+		if change.Version != "" {
+			parts = append(parts, "version "+change.Version)
+		}
+		if change.Commit != "" {
+			parts = append(parts, fmt.Sprintf("commit `%s`", change.Commit))
+		}
+		if change.Pipeline != "" {
+			parts = append(parts, "pipeline "+change.Pipeline)
+		}
+		text := "*What changed:* " + strings.Join(parts, ", ")
+		for _, link := range change.Links {
+			text += fmt.Sprintf(" | <%s|%s>", link.URL, link.Name)
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": markdown(text),
+		})
+	}
+
+	if next := failedService.NextSteps; next != nil {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": markdown(fmt.Sprintf(":compass: *What to do next:* <%s|Follow the %s remediation guide>", next.URL, next.Category)),
+		})
+	}
+
+	if len(failedService.LogLinks) > 0 || failedService.DashboardURL != "" || failedService.RunbookURL != "" || failedService.ArchiveURL != "" {
+		var links []string
+		if failedService.RunbookURL != "" {
+			links = append(links, fmt.Sprintf("<%s|Runbook>", failedService.RunbookURL))
+		}
+		if failedService.DashboardURL != "" {
+			links = append(links, fmt.Sprintf("<%s|Dashboard>", failedService.DashboardURL))
+		}
+		for _, link := range failedService.LogLinks {
+			links = append(links, fmt.Sprintf("<%s|%s>", link.URL, link.Name))
+		}
+		if failedService.ArchiveURL != "" {
+			links = append(links, fmt.Sprintf("<%s|Full logs and events>", failedService.ArchiveURL))
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "context",
+			"elements": []map[string]interface{}{
+				markdown(strings.Join(links, " | ")),
+			},
+		})
+	}
+
+	if health.IsPlatform(failedService.Classification) {
+		issue := "an admission webhook is blocking pod creation"
+		if failedService.Classification == health.ClassStuckTerminating {
+			issue = "a pod can't finish terminating, usually because of a finalizer or a volume that won't detach"
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": markdown(":construction: *Platform issue:* " + issue + "; the infrastructure team owns the fix."),
+		})
+	}
+
+	if failedService.Drill {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": markdown(":test_tube: *This is a notification drill.* No service is failing and no action is needed."),
+		})
+	}
+
+	if hotspot := failedService.Hotspot; hotspot != nil {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": markdown(fmt.Sprintf(":globe_with_meridians: *Infrastructure hotspot:* %d of %d failing pods in this scan, from %d services, run on %s `%s`. The infrastructure team has been alerted.",
+				hotspot.FailingPods, hotspot.TotalFailingPods, hotspot.Deployments, hotspot.Kind, hotspot.Name)),
+		})
+	}
+
+	if failedService.UpstreamCause != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": markdown(fmt.Sprintf(":link: *Likely caused by upstream* `%s`, which is also failing.", failedService.UpstreamCause)),
+		})
+	}
+
+	if len(failedService.Restarts) > 0 {
+		lines := []string{"*Last restarts:*"}
+		for _, restart := range failedService.Restarts {
+			line := fmt.Sprintf("• %s `%s/%s` %s, exit code %d", restart.At.Format(time.RFC1123), restart.Pod, restart.Container, restart.Reason, restart.ExitCode)
+			if meaning := restart.ExitMeaning(); meaning != "" {
+				line += " (" + meaning + ")"
+			}
+			if restart.Count > 1 {
+				line += fmt.Sprintf(" ×%d", restart.Count)
+			}
+			lines = append(lines, line)
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": markdown(strings.Join(lines, "\n")),
+		})
+	}
+
+	if failedService.OwnerBounced {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": markdown(fmt.Sprintf(":warning: *Owner address bounced:* mail to %s is undeliverable; please update the service_owner annotation.",
+				failedService.Deployment.OwnerEmail)),
+		})
+	}
+
+	if freeze := failedService.ChangeFreeze; freeze != nil {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": markdown(fmt.Sprintf(":snowflake: *Change freeze active:* %s %s (until %s)",
+				freeze.ID, freeze.Description, freeze.End.Format(time.RFC1123))),
+		})
+	}
+
+	if failedService.DebugCommand != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": markdown(fmt.Sprintf("*Debug:*\n```%s```", failedService.DebugCommand)),
+		})
+	}
+
+	if n.settings().SigningSecret != "" {
+		var options []map[string]interface{}
+		for _, opt := range silenceOptions {
+			options = append(options, map[string]interface{}{
+				"text":  plainText(opt.label),
+				"value": key + "|" + opt.duration,
+			})
+		}
+
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "actions",
+			"block_id": "incident_actions",
+			"elements": []map[string]interface{}{
+				{
+					"type":      "button",
+					"action_id": ActionAcknowledge,
+					"text":      plainText("Acknowledge"),
+					"style":     "primary",
+					"value":     key,
+				},
+				{
+					"type":        "static_select",
+					"action_id":   ActionSilence,
+					"placeholder": plainText("Silence for..."),
+					"options":     options,
+				},
+				{
+					"type":      "button",
+					"action_id": ActionEscalate,
+					"text":      plainText("Escalate"),
+					"style":     "danger",
+					"value":     key,
+				},
+			},
+		})
+	}
+
+	payload := map[string]interface{}{
+		"text":   fmt.Sprintf("%s is DOWN: %s", key, failedService.FailureReason),
+		"blocks": blocks,
+	}
+	// Route to the owning team's channel when the webhook allows overrides
+	if dep.SlackChannel != "" {
+		payload["channel"] = dep.SlackChannel
+	}
+	return payload
+}
+
+// PostMessage posts a plain text message to the configured webhook.
+func (n *Notifier) PostMessage(text string) error {
+	return n.post(n.settings().WebhookURL, map[string]interface{}{"text": text})
+}
+
+func (n *Notifier) post(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode slack payload: %w", err)
+	}
+	if n.outbox != nil {
+		return n.outbox.Write("slack", "json", body)
+	}
+
+	resp, err := n.httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post to slack: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Check verifies the incoming webhook is still valid without posting a
+// message: Slack answers an empty message with 400 no_text for a valid
+// webhook, and with 403 or 404 for a revoked one.
+func (n *Notifier) Check() error {
+	if n.outbox != nil {
+		return nil
+	}
+	resp, err := n.httpClient.Post(n.settings().WebhookURL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		return fmt.Errorf("failed to reach slack: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode == http.StatusBadRequest && strings.TrimSpace(string(body)) == "no_text" {
+		return nil
+	}
+	return fmt.Errorf("slack webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+func plainText(text string) map[string]interface{} {
+	return map[string]interface{}{"type": "plain_text", "text": text}
+}
+
+func markdown(text string) map[string]interface{} {
+	return map[string]interface{}{"type": "mrkdwn", "text": text}
+}
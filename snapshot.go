@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+)
+
+// snapshotBackend is where the state command exports and imports snapshots:
+// the state file directly, or a running daemon's REST API.
+type snapshotBackend interface {
+	Export(w io.Writer, now time.Time) error
+	Import(r io.Reader) (time.Time, error)
+}
+
+// runStateCommand implements
+//
+//	state export [-o file]
+//	state import <file|->
+//
+// to move incidents, cooldowns, silences and the rest of the state between
+// state stores or clusters, and to hand support a readable copy of it.
+// Importing replaces the whole state.
+func runStateCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("state", flag.ExitOnError)
+	configPath := flags.String("config", "./config.yaml", "Path to config file")
+	server := flags.String("server", "", "Base URL of a running daemon, e.g. http://k8s-health:8080")
+	output := flags.String("o", "", "For export, write the snapshot to this file instead of stdout")
+
+	positional := parseInterleaved(flags, args)
+
+	var backend snapshotBackend
+	if *server != "" {
+		backend = newAPIClient(*server)
+	} else {
+		store, err := openStateFile(*configPath)
+		if err != nil {
+			return err
+		}
+		backend = store
+	}
+
+	switch {
+	case len(positional) == 1 && positional[0] == "export":
+		if *output == "" {
+			return backend.Export(out, time.Now())
+		}
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		if err := backend.Export(f, time.Now()); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Exported state to %s\n", *output)
+		return nil
+
+	case len(positional) == 2 && positional[0] == "import":
+		in := io.Reader(os.Stdin)
+		if positional[1] != "-" {
+			f, err := os.Open(positional[1])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+		exportedAt, err := backend.Import(in)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Imported state exported at %s\n", exportedAt.Format(time.RFC3339))
+		return nil
+	}
+
+	return fmt.Errorf("usage: state export [-o file] | state import <file|->")
+}
+
+// serveState implements the state snapshot REST API:
+//
+//	GET /api/v1/state  export a snapshot
+//	PUT /api/v1/state  replace the state with a snapshot
+func (m *monitor) serveState(w http.ResponseWriter, r *http.Request) {
+	principal := auth.FromContext(r.Context())
+	switch r.Method {
+	case http.MethodGet:
+		if !principal.ClusterWide() {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := m.store.Export(w, time.Now()); err != nil {
+			log.Printf("Failed to export state: %v", err)
+		}
+
+	case http.MethodPut:
+		if !principal.CanEdit(auth.AllNamespaces) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		exportedAt, err := m.store.Import(io.LimitReader(r.Body, 64<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		by := "anonymous"
+		if principal != nil {
+			by = principal.Name
+		}
+		log.Printf("State replaced by %s with a snapshot exported at %s", by, exportedAt.Format(time.RFC3339))
+		writeJSON(w, http.StatusOK, map[string]time.Time{"exported_at": exportedAt})
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (c *apiClient) Export(w io.Writer, now time.Time) error {
+	// Exports can be large, so they aren't held to the client's timeout
+	httpClient := *c.httpClient
+	httpClient.Timeout = 0
+	resp, err := httpClient.Get(c.baseURL + "/api/v1/state")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, http.StatusOK); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func (c *apiClient) Import(r io.Reader) (time.Time, error) {
+	req, err := http.NewRequest(http.MethodPut, c.baseURL+"/api/v1/state", r)
+	if err != nil {
+		return time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	httpClient := *c.httpClient
+	httpClient.Timeout = 0
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, http.StatusOK); err != nil {
+		return time.Time{}, err
+	}
+
+	var result struct {
+		ExportedAt time.Time `json:"exported_at"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result.ExportedAt, err
+}
@@ -0,0 +1,56 @@
+package state
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Bounce records that mail to an address was permanently undeliverable.
+type Bounce struct {
+	Address   string    `json:"address"`
+	Status    string    `json:"status,omitempty"`
+	Diagnosis string    `json:"diagnosis,omitempty"`
+	BouncedAt time.Time `json:"bounced_at"`
+}
+
+// RecordBounce marks an address as undeliverable.
+func (s *Store) RecordBounce(bounce Bounce) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bounce.Address = strings.ToLower(bounce.Address)
+	if s.data.Bounces == nil {
+		s.data.Bounces = make(map[string]*Bounce)
+	}
+	s.data.Bounces[bounce.Address] = &bounce
+
+	return s.save()
+}
+
+// Bounced returns the bounce recorded for an address, if any.
+func (s *Store) Bounced(address string) (Bounce, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bounce, ok := s.data.Bounces[strings.ToLower(address)]
+	if !ok {
+		return Bounce{}, false
+	}
+	return *bounce, true
+}
+
+// Bounces returns all recorded bounces, most recent first.
+func (s *Store) Bounces() []Bounce {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bounces := make([]Bounce, 0, len(s.data.Bounces))
+	for _, bounce := range s.data.Bounces {
+		bounces = append(bounces, *bounce)
+	}
+	sort.Slice(bounces, func(i, j int) bool {
+		return bounces[i].BouncedAt.After(bounces[j].BouncedAt)
+	})
+	return bounces
+}
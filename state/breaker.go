@@ -0,0 +1,107 @@
+package state
+
+import (
+	"sort"
+	"time"
+)
+
+// Breaker is the notification circuit breaker. It counts the alerts sent in
+// the last window and trips when there are too many, a sign of a monitor bug
+// or a cluster meltdown; while tripped, alerts are held back.
+type Breaker struct {
+	// Sent are the times of the alerts sent within the window
+	Sent      []time.Time `json:"sent,omitempty"`
+	TrippedAt time.Time   `json:"tripped_at,omitempty"`
+	// Held are the services whose alerts were held back since it tripped
+	Held []string `json:"held,omitempty"`
+	// SummarySent is set once the infra team was told it tripped
+	SummarySent bool `json:"summary_sent,omitempty"`
+}
+
+// Tripped reports whether alerts are being held back.
+func (b Breaker) Tripped() bool {
+	return !b.TrippedAt.IsZero()
+}
+
+// AllowNotification counts an alert for a service against the breaker and
+// reports whether it may be sent. The breaker trips once threshold alerts
+// were sent within window, and resets itself window after tripping.
+func (s *Store) AllowNotification(namespace, deployment string, threshold int, window time.Duration, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.Breaker == nil {
+		s.data.Breaker = &Breaker{}
+	}
+	b := s.data.Breaker
+	if b.Tripped() && now.Sub(b.TrippedAt) >= window {
+		*b = Breaker{}
+	}
+
+	recent := b.Sent[:0]
+	for _, sent := range b.Sent {
+		if now.Sub(sent) < window {
+			recent = append(recent, sent)
+		}
+	}
+	b.Sent = recent
+
+	if !b.Tripped() && len(b.Sent) >= threshold {
+		b.TrippedAt = now
+	}
+	if b.Tripped() {
+		key := Key(namespace, deployment)
+		for _, held := range b.Held {
+			if held == key {
+				return false, nil
+			}
+		}
+		b.Held = append(b.Held, key)
+		sort.Strings(b.Held)
+		return false, s.save()
+	}
+
+	b.Sent = append(b.Sent, now)
+	return true, s.save()
+}
+
+// Breaker returns the notification circuit breaker's state.
+func (s *Store) Breaker() Breaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.Breaker == nil {
+		return Breaker{}
+	}
+	b := *s.data.Breaker
+	b.Sent = append([]time.Time(nil), b.Sent...)
+	b.Held = append([]string(nil), b.Held...)
+	return b
+}
+
+// MarkBreakerSummarySent records that the infra team was told the breaker
+// tripped.
+func (s *Store) MarkBreakerSummarySent() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.Breaker == nil || !s.data.Breaker.Tripped() {
+		return nil
+	}
+	s.data.Breaker.SummarySent = true
+	return s.save()
+}
+
+// ResetBreaker closes the breaker and forgets the alerts counted so far,
+// returning its state before the reset.
+func (s *Store) ResetBreaker() (Breaker, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.Breaker == nil {
+		return Breaker{}, nil
+	}
+	previous := *s.data.Breaker
+	s.data.Breaker = nil
+	return previous, s.save()
+}
@@ -0,0 +1,239 @@
+package state
+
+import (
+	"sort"
+	"time"
+)
+
+// IncidentRecord is a past or ongoing incident, kept after resolution for
+// reporting.
+type IncidentRecord struct {
+	Namespace  string    `json:"namespace"`
+	Deployment string    `json:"deployment"`
+	Reason     string    `json:"reason,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	ResolvedAt time.Time `json:"resolved_at,omitempty"`
+}
+
+// recordOpened appends a history record for a new incident. Callers must
+// hold s.mu.
+func (s *Store) recordOpened(incident Incident) {
+	s.data.History = append(s.data.History, IncidentRecord{
+		Namespace:  incident.Namespace,
+		Deployment: incident.Deployment,
+		Reason:     incident.Reason,
+		StartedAt:  incident.StartedAt,
+	})
+}
+
+// recordResolved closes the history record of a resolved incident. Callers
+// must hold s.mu.
+func (s *Store) recordResolved(incident Incident, now time.Time) {
+	for i := len(s.data.History) - 1; i >= 0; i-- {
+		record := &s.data.History[i]
+		if record.Namespace == incident.Namespace && record.Deployment == incident.Deployment &&
+			record.StartedAt.Equal(incident.StartedAt) {
+			record.ResolvedAt = now
+			return
+		}
+	}
+}
+
+// History returns incidents that started at or after since, oldest first.
+func (s *Store) History(since time.Time) []IncidentRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []IncidentRecord
+	for _, record := range s.data.History {
+		if !record.StartedAt.Before(since) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// PruneHistory removes resolved incidents and crashes older than their
+// retention, folding them into daily rollups, and rollups older than theirs.
+// It returns how many records it removed.
+func (s *Store) PruneHistory(retention Retention, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rollup := retention.Rollups > 0
+	before := now.Add(-retention.Incidents)
+	kept := s.data.History[:0]
+	for _, record := range s.data.History {
+		if record.StartedAt.Before(before) && !record.ResolvedAt.IsZero() {
+			if rollup {
+				r := s.rollup(record.Namespace, record.Deployment, record.StartedAt)
+				r.Incidents++
+				r.Downtime += record.ResolvedAt.Sub(record.StartedAt)
+			}
+			continue
+		}
+		kept = append(kept, record)
+	}
+	removed := len(s.data.History) - len(kept)
+	s.data.History = kept
+
+	before = now.Add(-retention.Crashes)
+	keptCrashes := s.data.Crashes[:0]
+	for _, crash := range s.data.Crashes {
+		if crash.At.Before(before) {
+			if rollup {
+				r := s.rollup(crash.Namespace, crash.Deployment, crash.At)
+				r.Crashes++
+				r.Restarts += crash.Restarts
+			}
+			continue
+		}
+		keptCrashes = append(keptCrashes, crash)
+	}
+	removed += len(s.data.Crashes) - len(keptCrashes)
+	s.data.Crashes = keptCrashes
+
+	before = now.Add(-retention.Rollups)
+	keptRollups := s.data.Rollups[:0]
+	for _, r := range s.data.Rollups {
+		if rollup && !r.Day.Before(before) {
+			keptRollups = append(keptRollups, r)
+		}
+	}
+	removed += len(s.data.Rollups) - len(keptRollups)
+	s.data.Rollups = keptRollups
+
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, s.save()
+}
+
+// Offender is a deployment ranked by how often it failed.
+type Offender struct {
+	Namespace  string
+	Deployment string
+	Incidents  int
+	// Downtime is the total time spent failing; ongoing incidents count
+	// up to now
+	Downtime time.Duration
+}
+
+// TopOffenders ranks deployments by incident count, then downtime, and
+// returns at most n of them.
+func TopOffenders(records []IncidentRecord, n int, now time.Time) []Offender {
+	byKey := make(map[string]*Offender)
+	for _, record := range records {
+		if record.Deployment == "" {
+			continue
+		}
+		key := Key(record.Namespace, record.Deployment)
+		offender, ok := byKey[key]
+		if !ok {
+			offender = &Offender{Namespace: record.Namespace, Deployment: record.Deployment}
+			byKey[key] = offender
+		}
+		offender.Incidents++
+		end := record.ResolvedAt
+		if end.IsZero() {
+			end = now
+		}
+		offender.Downtime += end.Sub(record.StartedAt)
+	}
+
+	offenders := make([]Offender, 0, len(byKey))
+	for _, offender := range byKey {
+		offender.Downtime = offender.Downtime.Round(time.Minute)
+		offenders = append(offenders, *offender)
+	}
+	sort.Slice(offenders, func(i, j int) bool {
+		a, b := offenders[i], offenders[j]
+		if a.Incidents != b.Incidents {
+			return a.Incidents > b.Incidents
+		}
+		if a.Downtime != b.Downtime {
+			return a.Downtime > b.Downtime
+		}
+		return Key(a.Namespace, a.Deployment) < Key(b.Namespace, b.Deployment)
+	})
+	if len(offenders) > n {
+		offenders = offenders[:n]
+	}
+	return offenders
+}
+
+// Heatmap counts incidents started per namespace per day.
+type Heatmap struct {
+	Days []time.Time
+	Rows []HeatmapRow
+}
+
+// HeatmapRow is one namespace's daily incident counts, oldest day first.
+type HeatmapRow struct {
+	Namespace string
+	Cells     []HeatmapCell
+	Total     int
+}
+
+// HeatmapCell is one day's count. Level buckets it from 0 (none) to 3
+// relative to the busiest cell, for shading.
+type HeatmapCell struct {
+	Count int
+	Level int
+}
+
+// NewHeatmap builds a heatmap of the last days days up to now, busiest
+// namespace first. Days are in now's location.
+func NewHeatmap(records []IncidentRecord, days int, now time.Time) Heatmap {
+	if days < 1 {
+		days = 1
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	first := today.AddDate(0, 0, -(days - 1))
+
+	var heatmap Heatmap
+	for i := 0; i < days; i++ {
+		heatmap.Days = append(heatmap.Days, first.AddDate(0, 0, i))
+	}
+
+	byNamespace := make(map[string]*HeatmapRow)
+	max := 0
+	for _, record := range records {
+		started := record.StartedAt.In(now.Location())
+		// Round so days that are 23 or 25 hours long around DST changes
+		// still count as one
+		day := int((time.Date(started.Year(), started.Month(), started.Day(), 0, 0, 0, 0, now.Location()).Sub(first) + 12*time.Hour) / (24 * time.Hour))
+		if day < 0 || day >= days {
+			continue
+		}
+		row, ok := byNamespace[record.Namespace]
+		if !ok {
+			row = &HeatmapRow{Namespace: record.Namespace, Cells: make([]HeatmapCell, days)}
+			byNamespace[record.Namespace] = row
+		}
+		row.Cells[day].Count++
+		row.Total++
+		if row.Cells[day].Count > max {
+			max = row.Cells[day].Count
+		}
+	}
+
+	for _, row := range byNamespace {
+		for i := range row.Cells {
+			if count := row.Cells[i].Count; count > 0 {
+				row.Cells[i].Level = 1 + 2*(count-1)/max
+				if count == max {
+					row.Cells[i].Level = 3
+				}
+			}
+		}
+		heatmap.Rows = append(heatmap.Rows, *row)
+	}
+	sort.Slice(heatmap.Rows, func(i, j int) bool {
+		if heatmap.Rows[i].Total != heatmap.Rows[j].Total {
+			return heatmap.Rows[i].Total > heatmap.Rows[j].Total
+		}
+		return heatmap.Rows[i].Namespace < heatmap.Rows[j].Namespace
+	})
+	return heatmap
+}
@@ -0,0 +1,39 @@
+package state
+
+import "time"
+
+// Onboarded returns when onboarding reports were turned on, zero if they
+// haven't been, and when each namespace known since was recorded.
+func (s *Store) Onboarded() (time.Time, map[string]time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	namespaces := make(map[string]time.Time, len(s.data.Onboarded))
+	for namespace, at := range s.data.Onboarded {
+		namespaces[namespace] = at
+	}
+	return s.data.OnboardingSince, namespaces
+}
+
+// MarkOnboarded records namespaces as known, and forgets known namespaces
+// not in present, so a namespace deleted and created again is new again.
+func (s *Store) MarkOnboarded(namespaces []string, present map[string]bool, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.OnboardingSince.IsZero() {
+		s.data.OnboardingSince = now
+	}
+	if s.data.Onboarded == nil {
+		s.data.Onboarded = make(map[string]time.Time)
+	}
+	for _, namespace := range namespaces {
+		s.data.Onboarded[namespace] = now
+	}
+	for namespace := range s.data.Onboarded {
+		if !present[namespace] {
+			delete(s.data.Onboarded, namespace)
+		}
+	}
+	return s.save()
+}
@@ -0,0 +1,97 @@
+package state
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Ownership records the last time a deployment's owner confirmed it, or asked
+// to hand it over.
+type Ownership struct {
+	Namespace  string `json:"namespace"`
+	Deployment string `json:"deployment"`
+	// Owner is the owner address confirmed; the confirmation no longer counts
+	// once the owner annotation names someone else
+	Owner       string    `json:"owner"`
+	ConfirmedBy string    `json:"confirmed_by,omitempty"`
+	ConfirmedAt time.Time `json:"confirmed_at,omitempty"`
+	// TransferTo is who the owner asked to hand the deployment to, until the
+	// owner annotation is changed
+	TransferTo          string    `json:"transfer_to,omitempty"`
+	TransferRequestedAt time.Time `json:"transfer_requested_at,omitempty"`
+}
+
+// ConfirmedFor reports whether owner confirmed the deployment after since.
+func (o Ownership) ConfirmedFor(owner string, since time.Time) bool {
+	return strings.EqualFold(o.Owner, owner) && o.ConfirmedAt.After(since)
+}
+
+// ConfirmOwnership records that owner still owns a deployment, dropping any
+// pending transfer.
+func (s *Store) ConfirmOwnership(namespace, deployment, owner, by string, now time.Time) (Ownership, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := Ownership{
+		Namespace:   namespace,
+		Deployment:  deployment,
+		Owner:       strings.ToLower(owner),
+		ConfirmedBy: by,
+		ConfirmedAt: now,
+	}
+	s.setOwnership(record)
+	return record, s.save()
+}
+
+// RequestTransfer records that owner asked to hand a deployment to someone
+// else. Until the owner annotation changes the deployment stays unconfirmed.
+func (s *Store) RequestTransfer(namespace, deployment, owner, to, by string, now time.Time) (Ownership, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := Ownership{
+		Namespace:           namespace,
+		Deployment:          deployment,
+		Owner:               strings.ToLower(owner),
+		ConfirmedBy:         by,
+		TransferTo:          to,
+		TransferRequestedAt: now,
+	}
+	s.setOwnership(record)
+	return record, s.save()
+}
+
+func (s *Store) setOwnership(record Ownership) {
+	if s.data.Ownership == nil {
+		s.data.Ownership = make(map[string]*Ownership)
+	}
+	s.data.Ownership[Key(record.Namespace, record.Deployment)] = &record
+}
+
+// Ownership returns the ownership record of a deployment, if any.
+func (s *Store) Ownership(namespace, deployment string) (Ownership, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.data.Ownership[Key(namespace, deployment)]
+	if !ok {
+		return Ownership{}, false
+	}
+	return *record, true
+}
+
+// Ownerships returns all ownership records, sorted by deployment.
+func (s *Store) Ownerships() []Ownership {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]Ownership, 0, len(s.data.Ownership))
+	for _, record := range s.data.Ownership {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return Key(records[i].Namespace, records[i].Deployment) < Key(records[j].Namespace, records[j].Deployment)
+	})
+	return records
+}
@@ -0,0 +1,178 @@
+package state
+
+import (
+	"sort"
+	"time"
+)
+
+// RestartCount is a container's restart counter as seen by a scan, with how
+// it last terminated.
+type RestartCount struct {
+	Pod        string
+	Container  string
+	Count      int32
+	Reason     string
+	ExitCode   int32
+	FinishedAt time.Time
+}
+
+// DeploymentRestarts are the restart counters of a deployment's containers
+// and whether the deployment was healthy when they were read.
+type DeploymentRestarts struct {
+	Namespace  string
+	Deployment string
+	Healthy    bool
+	Containers []RestartCount
+}
+
+// Crash is one or more restarts of a container seen between two scans.
+type Crash struct {
+	Namespace  string    `json:"namespace"`
+	Deployment string    `json:"deployment"`
+	Pod        string    `json:"pod"`
+	Container  string    `json:"container"`
+	Restarts   int32     `json:"restarts"`
+	Reason     string    `json:"reason,omitempty"`
+	ExitCode   int32     `json:"exit_code"`
+	At         time.Time `json:"at"`
+	// SelfRecovered is set when the deployment was healthy again by the
+	// scan that saw the restarts, so no alert went out for them
+	SelfRecovered bool `json:"self_recovered,omitempty"`
+}
+
+// restartBaseline is a deployment's restart counters at its last scan, keyed
+// by pod and container.
+type restartBaseline struct {
+	ScannedAt time.Time        `json:"scanned_at"`
+	Counts    map[string]int32 `json:"counts"`
+}
+
+// ObserveRestarts compares each deployment's restart counters with those of
+// its previous scan, records the restarts in between as crashes and returns
+// them. The first scan of a deployment only sets the baseline.
+func (s *Store) ObserveRestarts(observed []DeploymentRestarts, now time.Time) ([]Crash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.Restarts == nil {
+		s.data.Restarts = make(map[string]restartBaseline)
+	}
+
+	var crashes []Crash
+	for _, dep := range observed {
+		key := Key(dep.Namespace, dep.Deployment)
+		previous, seen := s.data.Restarts[key]
+		counts := make(map[string]int32, len(dep.Containers))
+
+		for _, c := range dep.Containers {
+			container := c.Pod + "/" + c.Container
+			counts[container] = c.Count
+			if !seen {
+				continue
+			}
+
+			// A pod new since the previous scan has no counter yet, so all
+			// of its restarts count
+			before := previous.Counts[container]
+			if c.Count <= before {
+				continue
+			}
+			crash := Crash{
+				Namespace:     dep.Namespace,
+				Deployment:    dep.Deployment,
+				Pod:           c.Pod,
+				Container:     c.Container,
+				Restarts:      c.Count - before,
+				Reason:        c.Reason,
+				ExitCode:      c.ExitCode,
+				At:            c.FinishedAt,
+				SelfRecovered: dep.Healthy,
+			}
+			if crash.At.IsZero() {
+				crash.At = now
+			}
+			crashes = append(crashes, crash)
+		}
+		s.data.Restarts[key] = restartBaseline{ScannedAt: now, Counts: counts}
+	}
+
+	s.data.Crashes = append(s.data.Crashes, crashes...)
+	return crashes, s.save()
+}
+
+// Crashes returns the crashes seen at or after since, oldest first.
+func (s *Store) Crashes(since time.Time) []Crash {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var crashes []Crash
+	for _, crash := range s.data.Crashes {
+		if !crash.At.Before(since) {
+			crashes = append(crashes, crash)
+		}
+	}
+	return crashes
+}
+
+// RecentCrashes returns up to n of a deployment's most recent crashes,
+// newest first.
+func (s *Store) RecentCrashes(namespace, deployment string, n int) []Crash {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var crashes []Crash
+	for i := len(s.data.Crashes) - 1; i >= 0 && len(crashes) < n; i-- {
+		crash := s.data.Crashes[i]
+		if crash.Namespace == namespace && crash.Deployment == deployment {
+			crashes = append(crashes, crash)
+		}
+	}
+	return crashes
+}
+
+// CrashSummary totals a container's crashes.
+type CrashSummary struct {
+	Namespace    string
+	Deployment   string
+	Container    string
+	Crashes      int32
+	LastReason   string
+	LastExitCode int32
+	Last         time.Time
+}
+
+// SummarizeCrashes totals crashes per container, sorted by namespace,
+// deployment and container.
+func SummarizeCrashes(crashes []Crash) []CrashSummary {
+	byContainer := make(map[string]*CrashSummary)
+	var summaries []*CrashSummary
+	for _, crash := range crashes {
+		key := Key(crash.Namespace, crash.Deployment) + "/" + crash.Container
+		summary, ok := byContainer[key]
+		if !ok {
+			summary = &CrashSummary{Namespace: crash.Namespace, Deployment: crash.Deployment, Container: crash.Container}
+			byContainer[key] = summary
+			summaries = append(summaries, summary)
+		}
+		summary.Crashes += crash.Restarts
+		if !crash.At.Before(summary.Last) {
+			summary.Last, summary.LastReason, summary.LastExitCode = crash.At, crash.Reason, crash.ExitCode
+		}
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Deployment != b.Deployment {
+			return a.Deployment < b.Deployment
+		}
+		return a.Container < b.Container
+	})
+	result := make([]CrashSummary, len(summaries))
+	for i, summary := range summaries {
+		result[i] = *summary
+	}
+	return result
+}
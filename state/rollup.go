@@ -0,0 +1,57 @@
+package state
+
+import (
+	"sort"
+	"time"
+)
+
+// Retention says how long the store keeps each kind of history. Resolved
+// incidents and crashes past their retention are folded into daily rollups,
+// which are kept for Rollups; with Rollups 0 they are dropped instead.
+type Retention struct {
+	Incidents time.Duration
+	Crashes   time.Duration
+	Rollups   time.Duration
+}
+
+// Rollup totals a deployment's pruned incidents and crashes over one UTC day.
+type Rollup struct {
+	Namespace  string    `json:"namespace"`
+	Deployment string    `json:"deployment"`
+	Day        time.Time `json:"day"`
+	Incidents  int       `json:"incidents,omitempty"`
+	// Downtime is how long the day's incidents lasted in total
+	Downtime time.Duration `json:"downtime,omitempty"`
+	Crashes  int           `json:"crashes,omitempty"`
+	Restarts int32         `json:"restarts,omitempty"`
+}
+
+// rollup returns the rollup of a deployment's day, adding it if needed.
+// Callers must hold s.mu.
+func (s *Store) rollup(namespace, deployment string, at time.Time) *Rollup {
+	day := at.UTC().Truncate(24 * time.Hour)
+	for i := range s.data.Rollups {
+		r := &s.data.Rollups[i]
+		if r.Day.Equal(day) && r.Namespace == namespace && r.Deployment == deployment {
+			return r
+		}
+	}
+	s.data.Rollups = append(s.data.Rollups, Rollup{Namespace: namespace, Deployment: deployment, Day: day})
+	return &s.data.Rollups[len(s.data.Rollups)-1]
+}
+
+// Rollups returns the daily rollups for days starting at or after since,
+// oldest first.
+func (s *Store) Rollups(since time.Time) []Rollup {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rollups []Rollup
+	for _, r := range s.data.Rollups {
+		if !r.Day.Before(since.UTC().Truncate(24 * time.Hour)) {
+			rollups = append(rollups, r)
+		}
+	}
+	sort.SliceStable(rollups, func(i, j int) bool { return rollups[i].Day.Before(rollups[j].Day) })
+	return rollups
+}
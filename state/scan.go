@@ -0,0 +1,164 @@
+package state
+
+import (
+	"sort"
+	"time"
+)
+
+// Kinds of change between two scans.
+const (
+	ChangeNewlyFailed   = "newly_failed"
+	ChangeRecovered     = "recovered"
+	ChangeReasonChanged = "reason_changed"
+)
+
+// ScanResult is the stored outcome of checking one deployment.
+type ScanResult struct {
+	Namespace      string    `json:"namespace"`
+	Deployment     string    `json:"deployment"`
+	Healthy        bool      `json:"healthy"`
+	Reason         string    `json:"reason,omitempty"`
+	Classification string    `json:"classification,omitempty"`
+	CheckedAt      time.Time `json:"checked_at"`
+	// Stopped is set for deployments deliberately scaled to zero or paused,
+	// which count as healthy
+	Stopped bool `json:"stopped,omitempty"`
+}
+
+// Change is a state transition of a deployment between two scans.
+type Change struct {
+	Kind     string
+	Previous ScanResult
+	Current  ScanResult
+}
+
+// Diff compares two scans keyed by Key(namespace, deployment) and returns only
+// the deployments whose state changed, sorted by key. Deployments missing from
+// the current scan are ignored.
+func Diff(previous, current map[string]ScanResult) []Change {
+	var changes []Change
+
+	for key, curr := range current {
+		prev, seen := previous[key]
+
+		switch {
+		case !curr.Healthy && (!seen || prev.Healthy):
+			changes = append(changes, Change{Kind: ChangeNewlyFailed, Previous: prev, Current: curr})
+		case curr.Healthy && seen && !prev.Healthy:
+			changes = append(changes, Change{Kind: ChangeRecovered, Previous: prev, Current: curr})
+		case !curr.Healthy && !prev.Healthy && reasonChanged(prev, curr):
+			changes = append(changes, Change{Kind: ChangeReasonChanged, Previous: prev, Current: curr})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return Key(changes[i].Current.Namespace, changes[i].Current.Deployment) <
+			Key(changes[j].Current.Namespace, changes[j].Current.Deployment)
+	})
+	return changes
+}
+
+// reasonChanged compares classifications when both scans have one, since
+// reason text embeds pod names that change on every rollout.
+func reasonChanged(prev, curr ScanResult) bool {
+	if prev.Classification != "" && curr.Classification != "" {
+		return prev.Classification != curr.Classification
+	}
+	return prev.Reason != curr.Reason
+}
+
+// LastScan returns a copy of the most recently saved scan.
+func (s *Store) LastScan() map[string]ScanResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make(map[string]ScanResult, len(s.data.LastScan))
+	for key, result := range s.data.LastScan {
+		results[key] = result
+	}
+	return results
+}
+
+// ScanResult returns a deployment's result from the last scan. It doesn't
+// wait for other store operations, so it is fast enough to call from
+// admission webhooks.
+func (s *Store) ScanResult(namespace, deployment string) (ScanResult, bool) {
+	scan := s.scan.Load()
+	if scan == nil {
+		return ScanResult{}, false
+	}
+	result, ok := (*scan)[Key(namespace, deployment)]
+	return result, ok
+}
+
+// publishScan makes the current scan visible to ScanResult. Callers must
+// hold s.mu.
+func (s *Store) publishScan() {
+	scan := make(map[string]ScanResult, len(s.data.LastScan))
+	for key, result := range s.data.LastScan {
+		scan[key] = result
+	}
+	s.scan.Store(&scan)
+}
+
+// SaveScan replaces the stored scan and returns the changes since the
+// previous one.
+func (s *Store) SaveScan(results map[string]ScanResult) ([]Change, error) {
+	return s.SaveScanIn(results, nil)
+}
+
+// SaveScanIn replaces the stored results for the namespaces in scope, or for
+// all namespaces if scope is nil, and returns the changes since the previous
+// scan. Results for other namespaces are kept.
+func (s *Store) SaveScanIn(results map[string]ScanResult, scope func(namespace string) bool) ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes := Diff(s.data.LastScan, results)
+	if scope != nil {
+		for key, result := range s.data.LastScan {
+			if _, ok := results[key]; !ok && !scope(result.Namespace) {
+				results[key] = result
+			}
+		}
+	}
+	s.data.LastScan = results
+	s.publishScan()
+
+	return changes, s.save()
+}
+
+// TrimScanIn drops stored results for deployments in scope, or in all
+// namespaces if scope is nil, that seen doesn't include. A scan saved in
+// parts with MergeScan uses it to forget deleted deployments, as SaveScanIn
+// would.
+func (s *Store) TrimScanIn(seen map[string]bool, scope func(namespace string) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, result := range s.data.LastScan {
+		if !seen[key] && (scope == nil || scope(result.Namespace)) {
+			delete(s.data.LastScan, key)
+		}
+	}
+	s.publishScan()
+	return s.save()
+}
+
+// MergeScan updates the stored scan for the given deployments only, e.g.
+// after rechecking a subset, and returns their changes.
+func (s *Store) MergeScan(results map[string]ScanResult) ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes := Diff(s.data.LastScan, results)
+	if s.data.LastScan == nil {
+		s.data.LastScan = make(map[string]ScanResult, len(results))
+	}
+	for key, result := range results {
+		s.data.LastScan[key] = result
+	}
+	s.publishScan()
+
+	return changes, s.save()
+}
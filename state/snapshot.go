@@ -0,0 +1,60 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SnapshotVersion is the format version written by Export. Import refuses
+// snapshots from a newer version.
+const SnapshotVersion = 1
+
+// snapshot is the portable form of the store: plain, indented JSON whatever
+// the state file's compression, so it can be moved to another store or
+// cluster, or read to see why an alert was or wasn't sent.
+type snapshot struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	State      data      `json:"state"`
+}
+
+// Export writes a snapshot of the whole store.
+func (s *Store) Export(w io.Writer, now time.Time) error {
+	s.mu.Lock()
+	content, err := json.MarshalIndent(snapshot{Version: SnapshotVersion, ExportedAt: now, State: s.data}, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	_, err = w.Write(append(content, '\n'))
+	return err
+}
+
+// Import replaces the whole store with a snapshot written by Export and
+// returns when the snapshot was taken.
+func (s *Store) Import(r io.Reader) (time.Time, error) {
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	if snap.Version == 0 {
+		return time.Time{}, fmt.Errorf("not a state snapshot: version is missing")
+	}
+	if snap.Version > SnapshotVersion {
+		return time.Time{}, fmt.Errorf("snapshot version %d is newer than this monitor supports (%d)", snap.Version, SnapshotVersion)
+	}
+	if snap.State.Incidents == nil {
+		snap.State.Incidents = make(map[string]*Incident)
+	}
+	if snap.State.Silences == nil {
+		snap.State.Silences = make(map[string]*Silence)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = snap.State
+	s.publishScan()
+	return snap.ExportedAt, s.save()
+}
@@ -0,0 +1,450 @@
+// state/store.go
+package state
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Incident tracks an ongoing failure of a single deployment.
+type Incident struct {
+	Namespace      string    `json:"namespace"`
+	Deployment     string    `json:"deployment"`
+	Reason         string    `json:"reason"`
+	StartedAt      time.Time `json:"started_at"`
+	LastNotified   time.Time `json:"last_notified,omitempty"`
+	AcknowledgedBy string    `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt time.Time `json:"acknowledged_at,omitempty"`
+	EscalatedBy    string    `json:"escalated_by,omitempty"`
+	EscalatedAt    time.Time `json:"escalated_at,omitempty"`
+	// MissingSince is set while the workload is absent from scans
+	MissingSince time.Time `json:"missing_since,omitempty"`
+}
+
+// ID returns a stable identifier for the incident, derived from the cluster,
+// deployment and start time, so every channel reports the same value.
+func (i Incident) ID(cluster string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		cluster, i.Namespace, i.Deployment, i.StartedAt.UTC().Format(time.RFC3339Nano),
+	}, "/")))
+	return "INC-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// Silence suppresses notifications for a deployment until a point in time.
+type Silence struct {
+	Namespace  string    `json:"namespace"`
+	Deployment string    `json:"deployment"`
+	Until      time.Time `json:"until"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedBy  string    `json:"created_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	// AlertmanagerID is the Alertmanager silence this one is mirrored to
+	// or from, if silences are synced
+	AlertmanagerID string `json:"alertmanager_id,omitempty"`
+	// StartsAt schedules the silence as a maintenance window; zero
+	// silences from creation
+	StartsAt time.Time `json:"starts_at,omitempty"`
+}
+
+type data struct {
+	Incidents map[string]*Incident  `json:"incidents"`
+	Silences  map[string]*Silence   `json:"silences"`
+	LastScan  map[string]ScanResult `json:"last_scan"`
+	// Bounces are keyed by lower-cased address
+	Bounces map[string]*Bounce `json:"bounces,omitempty"`
+	// History keeps incidents after they resolve, oldest first
+	History []IncidentRecord `json:"history,omitempty"`
+	// Restarts are each deployment's restart counters at its last scan
+	Restarts map[string]restartBaseline `json:"restarts,omitempty"`
+	// Crashes are container restarts seen between scans, oldest first
+	Crashes []Crash `json:"crashes,omitempty"`
+	// Ownership holds owners' confirmations of their deployments
+	Ownership map[string]*Ownership `json:"ownership,omitempty"`
+	// Onboarded are the namespaces known since OnboardingSince, so only
+	// namespaces created later are reported as new
+	Onboarded       map[string]time.Time `json:"onboarded,omitempty"`
+	OnboardingSince time.Time            `json:"onboarding_since,omitempty"`
+	// Rollups are daily totals of pruned incidents and crashes
+	Rollups []Rollup `json:"rollups,omitempty"`
+	// Breaker is the notification circuit breaker
+	Breaker *Breaker `json:"breaker,omitempty"`
+}
+
+// Store is a small JSON-file backed store for incidents and silences. With an
+// empty path it only keeps state in memory for the lifetime of the process.
+type Store struct {
+	path string
+	mu   sync.Mutex
+	data data
+	// scan is a copy of data.LastScan read without taking mu, so lookups
+	// never wait for the state file to be written
+	scan atomic.Pointer[map[string]ScanResult]
+	// compress gzips the state file
+	compress bool
+}
+
+// Key returns the store key for a deployment.
+func Key(namespace, deployment string) string {
+	return namespace + "/" + deployment
+}
+
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		data: data{
+			Incidents: make(map[string]*Incident),
+			Silences:  make(map[string]*Silence),
+		},
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	// Files are read whether or not they are compressed, so compression
+	// can be switched either way
+	if bytes.HasPrefix(content, gzipMagic) {
+		s.compress = true
+		if content, err = gunzip(content); err != nil {
+			return nil, fmt.Errorf("failed to read state file: %w", err)
+		}
+	}
+
+	if err := json.Unmarshal(content, &s.data); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	if s.data.Incidents == nil {
+		s.data.Incidents = make(map[string]*Incident)
+	}
+	if s.data.Silences == nil {
+		s.data.Silences = make(map[string]*Silence)
+	}
+	s.publishScan()
+
+	return s, nil
+}
+
+// RecordFailure opens an incident for the deployment if there isn't one yet
+// and updates its reason.
+func (s *Store) RecordFailure(namespace, deployment, reason string, now time.Time) (Incident, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := Key(namespace, deployment)
+	incident, ok := s.data.Incidents[key]
+	if !ok {
+		incident = &Incident{
+			Namespace:  namespace,
+			Deployment: deployment,
+			StartedAt:  now,
+			Reason:     reason,
+		}
+		s.data.Incidents[key] = incident
+		s.recordOpened(*incident)
+	}
+	incident.Reason = reason
+
+	return *incident, s.save()
+}
+
+// Resolve closes the incident for a deployment, if any.
+func (s *Store) Resolve(namespace, deployment string) (Incident, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := Key(namespace, deployment)
+	incident, ok := s.data.Incidents[key]
+	if !ok {
+		return Incident{}, false, nil
+	}
+	delete(s.data.Incidents, key)
+	s.recordResolved(*incident, time.Now())
+
+	return *incident, true, s.save()
+}
+
+// Incident returns the open incident for a deployment.
+func (s *Store) Incident(namespace, deployment string) (Incident, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incident, ok := s.data.Incidents[Key(namespace, deployment)]
+	if !ok {
+		return Incident{}, false
+	}
+	return *incident, true
+}
+
+// Incidents returns all open incidents, oldest first.
+func (s *Store) Incidents() []Incident {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incidents := make([]Incident, 0, len(s.data.Incidents))
+	for _, incident := range s.data.Incidents {
+		incidents = append(incidents, *incident)
+	}
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].StartedAt.Before(incidents[j].StartedAt)
+	})
+	return incidents
+}
+
+// ShouldNotify reports whether the cooldown since the last notification for
+// the deployment's incident has passed.
+func (s *Store) ShouldNotify(namespace, deployment string, cooldown time.Duration, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incident, ok := s.data.Incidents[Key(namespace, deployment)]
+	if !ok || incident.LastNotified.IsZero() {
+		return true
+	}
+	return now.Sub(incident.LastNotified) >= cooldown
+}
+
+func (s *Store) MarkNotified(namespace, deployment string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incident, ok := s.data.Incidents[Key(namespace, deployment)]
+	if !ok {
+		return nil
+	}
+	incident.LastNotified = now
+
+	return s.save()
+}
+
+func (s *Store) Acknowledge(namespace, deployment, user string, now time.Time) (Incident, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incident, ok := s.data.Incidents[Key(namespace, deployment)]
+	if !ok {
+		return Incident{}, fmt.Errorf("no open incident for %s", Key(namespace, deployment))
+	}
+	incident.AcknowledgedBy = user
+	incident.AcknowledgedAt = now
+
+	return *incident, s.save()
+}
+
+func (s *Store) Escalate(namespace, deployment, user string, now time.Time) (Incident, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incident, ok := s.data.Incidents[Key(namespace, deployment)]
+	if !ok {
+		return Incident{}, fmt.Errorf("no open incident for %s", Key(namespace, deployment))
+	}
+	incident.EscalatedBy = user
+	incident.EscalatedAt = now
+
+	return *incident, s.save()
+}
+
+func (s *Store) AddSilence(silence Silence) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Silences[Key(silence.Namespace, silence.Deployment)] = &silence
+
+	return s.save()
+}
+
+// Silenced returns the active silence for a deployment, if any.
+func (s *Store) Silenced(namespace, deployment string, now time.Time) (Silence, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	silence, ok := s.data.Silences[Key(namespace, deployment)]
+	if !ok || now.After(silence.Until) || now.Before(silence.StartsAt) {
+		return Silence{}, false
+	}
+	return *silence, true
+}
+
+// Silences returns the silences active or scheduled at now, soonest to expire
+// first.
+func (s *Store) Silences(now time.Time) []Silence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var active []Silence
+	for _, silence := range s.data.Silences {
+		if !now.After(silence.Until) {
+			active = append(active, *silence)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].Until.Before(active[j].Until)
+	})
+	return active
+}
+
+// RemoveSilence lifts a deployment's silence early. It reports whether there
+// was one.
+func (s *Store) RemoveSilence(namespace, deployment string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := Key(namespace, deployment)
+	if _, ok := s.data.Silences[key]; !ok {
+		return false, nil
+	}
+	delete(s.data.Silences, key)
+
+	return true, s.save()
+}
+
+// GC prunes state for workloads that are gone. Incidents are dropped once
+// their workload has been missing from scans for retention, and silences once
+// they expired retention ago; until then both stay for historical reporting.
+// Bounces are forgotten after retention so fixed mailboxes get mail again.
+// present reports whether a namespace/deployment (deployment empty for
+// namespace incidents) still exists. GC returns how many entries it removed.
+func (s *Store) GC(present func(namespace, deployment string) bool, retention time.Duration, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for key, incident := range s.data.Incidents {
+		switch {
+		case present(incident.Namespace, incident.Deployment):
+			incident.MissingSince = time.Time{}
+		case incident.MissingSince.IsZero():
+			incident.MissingSince = now
+		case now.Sub(incident.MissingSince) >= retention:
+			delete(s.data.Incidents, key)
+			removed++
+		}
+	}
+
+	// A deployment that comes back starts a new restart baseline
+	for key, baseline := range s.data.Restarts {
+		namespace, deployment, _ := strings.Cut(key, "/")
+		if !present(namespace, deployment) && now.Sub(baseline.ScannedAt) >= retention {
+			delete(s.data.Restarts, key)
+			removed++
+		}
+	}
+
+	for key, silence := range s.data.Silences {
+		if now.Sub(silence.Until) >= retention {
+			delete(s.data.Silences, key)
+			removed++
+		}
+	}
+
+	for key, bounce := range s.data.Bounces {
+		if now.Sub(bounce.BouncedAt) >= retention {
+			delete(s.data.Bounces, key)
+			removed++
+		}
+	}
+
+	for key, record := range s.data.Ownership {
+		last := record.ConfirmedAt
+		if record.TransferRequestedAt.After(last) {
+			last = record.TransferRequestedAt
+		}
+		if !present(record.Namespace, record.Deployment) && now.Sub(last) >= retention {
+			delete(s.data.Ownership, key)
+			removed++
+		}
+	}
+
+	return removed, s.save()
+}
+
+// SetCompressed sets whether the state file is gzipped from the next save.
+// It defaults to how the file was when opened.
+func (s *Store) SetCompressed(compress bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.compress = compress
+}
+
+// Detach stops saving to the state file: the store keeps what it loaded and
+// records later changes in memory only, e.g. for a dry run that must not
+// consume transitions or resolve incidents the next real run alerts on.
+func (s *Store) Detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.path = ""
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+func gunzip(content []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// save writes the state atomically. Callers must hold s.mu.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	var content []byte
+	var err error
+	if s.compress {
+		// Indenting would only cost space
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		err = json.NewEncoder(gz).Encode(s.data)
+		if err == nil {
+			err = gz.Close()
+		}
+		content = buf.Bytes()
+	} else {
+		content, err = json.MarshalIndent(s.data, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".state-*")
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/auth"
+	"github.com/Bharath-H-R/k8s-health/health"
+	"github.com/Bharath-H-R/k8s-health/metrics"
+	"github.com/Bharath-H-R/k8s-health/usage"
+)
+
+// runSummary describes the most recent completed run.
+type runSummary struct {
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration_ns"`
+	Usage     usage.Usage   `json:"usage"`
+	// InvalidOwners are owner annotations that were not mailed
+	InvalidOwners []health.InvalidOwner `json:"invalid_owners,omitempty"`
+	// Incomplete is set when the scan ran past scan.deadline and skipped
+	// SkippedNamespaces
+	Incomplete        bool     `json:"incomplete,omitempty"`
+	SkippedNamespaces []string `json:"skipped_namespaces,omitempty"`
+	// Unchanged counts healthy deployments not checked again because
+	// nothing changed since the last scan
+	Unchanged int `json:"unchanged,omitempty"`
+	// BrokenChannels are the notification channels that failed their last
+	// self-test
+	BrokenChannels []channelStatus `json:"broken_channels,omitempty"`
+}
+
+func (m *monitor) setLastRun(summary runSummary) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastRun = &summary
+}
+
+func (m *monitor) getLastRun() *runSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastRun
+}
+
+// serveUsage reports usage for the last run and since startup as JSON.
+func (m *monitor) serveUsage(w http.ResponseWriter, r *http.Request) {
+	if !auth.FromContext(r.Context()).ClusterWide() {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		LastRun *runSummary `json:"last_run"`
+		Total   usage.Usage `json:"total"`
+	}{
+		LastRun: m.getLastRun(),
+		Total:   m.usage.Snapshot(),
+	})
+}
+
+// checkMetrics describes the monitor's own performance and the health of
+// the cluster over time.
+type checkMetrics struct {
+	checkLatency    *metrics.Histogram
+	logFetchLatency *metrics.Histogram
+	checks          *metrics.CounterVec
+	failures        *metrics.CounterVec
+}
+
+func newCheckMetrics() *checkMetrics {
+	return &checkMetrics{
+		checkLatency:    metrics.NewHistogram(metrics.DefaultBuckets),
+		logFetchLatency: metrics.NewHistogram(metrics.DefaultBuckets),
+		checks:          metrics.NewCounterVec("namespace"),
+		failures:        metrics.NewCounterVec("namespace", "classification"),
+	}
+}
+
+// serveMetrics exposes usage and check metrics in the Prometheus text format.
+func (m *monitor) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	total := m.usage.Snapshot()
+	writeMetric(w, "k8s_health_api_requests_total", "counter", "Kubernetes API requests made.", total.APIRequests)
+	writeMetric(w, "k8s_health_log_bytes_total", "counter", "Bytes of pod logs fetched.", total.LogBytes)
+	writeMetric(w, "k8s_health_notifications_total", "counter", "Notifications sent on all channels.", total.Notifications)
+
+	if last := m.getLastRun(); last != nil {
+		writeMetric(w, "k8s_health_last_run_api_requests", "gauge", "Kubernetes API requests made by the last run.", last.Usage.APIRequests)
+		writeMetric(w, "k8s_health_last_run_log_bytes", "gauge", "Bytes of pod logs fetched by the last run.", last.Usage.LogBytes)
+		writeMetric(w, "k8s_health_last_run_notifications", "gauge", "Notifications sent by the last run.", last.Usage.Notifications)
+		fmt.Fprintf(w, "# HELP k8s_health_last_run_duration_seconds Duration of the last run.\n")
+		fmt.Fprintf(w, "# TYPE k8s_health_last_run_duration_seconds gauge\n")
+		fmt.Fprintf(w, "k8s_health_last_run_duration_seconds %g\n", last.Duration.Seconds())
+	}
+
+	m.metrics.checkLatency.Write(w, "k8s_health_check_duration_seconds", "Time to check one deployment's health.")
+	m.metrics.logFetchLatency.Write(w, "k8s_health_log_fetch_duration_seconds", "Time to fetch one container's logs.")
+	m.metrics.checks.Write(w, "k8s_health_checks_total", "Deployment health checks by namespace.")
+	m.metrics.failures.Write(w, "k8s_health_check_failures_total", "Failed deployment health checks by namespace and classification.")
+	m.writeChannelMetrics(w)
+}
+
+func writeMetric(w http.ResponseWriter, name, kind, help string, value int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/state"
+	"github.com/Bharath-H-R/k8s-health/usage"
+)
+
+// streamScan is runScan for large clusters: deployments are checked as the
+// scan finds them and handled in batches, so notifications go out while the
+// scan is still running. A batch is handled once it holds scan.batch_size
+// deployments and the scan moves on to another namespace, so namespace-level
+// cluster incidents are still seen whole. Handled results keep no logs.
+func (m *monitor) streamScan(ctx, scanCtx context.Context, scope func(namespace string) bool, startTime time.Time, startUsage usage.Usage) []checkedDeployment {
+	var checked, batch []checkedDeployment
+	seen := make(map[string]bool)
+	changes := 0
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		batchChanges, err := m.store.MergeScan(scanResults(batch, startTime))
+		if err != nil {
+			log.Printf("Failed to save scan results: %v", err)
+		}
+		m.handleResults(ctx, batch, batchChanges)
+		changes += len(batchChanges)
+
+		for _, c := range batch {
+			seen[state.Key(c.dep.Namespace, c.dep.Name)] = true
+			result := *c.result
+			result.PodLogs, result.Pods, result.Restarts = "", nil, nil
+			checked = append(checked, checkedDeployment{dep: c.dep, result: &result})
+		}
+		batch = nil
+	}
+
+	err := m.checkEach(scanCtx, scope, func(c checkedDeployment) {
+		if len(batch) >= m.cfg.Scan.BatchSize && batch[len(batch)-1].dep.Namespace != c.dep.Namespace {
+			flush()
+		}
+		batch = append(batch, c)
+	})
+	flush()
+	scope, ok := m.partialScope(ctx, err, scope)
+	if !ok {
+		log.Printf("Failed to scan deployments: %v", err)
+		return checked
+	}
+
+	invalidOwners := m.reportScanProblems(scope)
+	if err := m.store.TrimScanIn(seen, scope); err != nil {
+		log.Printf("Failed to save scan results: %v", err)
+	}
+	m.collectGarbage(checked, scope)
+
+	m.finishRun(startTime, startUsage, changes, invalidOwners)
+	return checked
+}
@@ -0,0 +1,108 @@
+// Package teams serves Microsoft Teams outgoing webhooks, so the monitor can
+// be queried and controlled by @mentioning it in a channel.
+package teams
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Teams waits at most 5 seconds for an outgoing webhook reply.
+const commandDeadline = 4 * time.Second
+
+// How long a slow command may keep running after the reply.
+const commandTimeout = 2 * time.Minute
+
+// mentionPattern matches the @mention of the webhook that prefixes messages.
+var mentionPattern = regexp.MustCompile(`<at>[^<]*</at>`)
+
+type activity struct {
+	Text string `json:"text"`
+	From struct {
+		Name string `json:"name"`
+	} `json:"from"`
+}
+
+// Handler serves a Teams outgoing webhook, verified with its security token.
+type Handler struct {
+	secret []byte
+	run    func(ctx context.Context, user, text string) string
+}
+
+// NewHandler returns a handler for the webhook whose security token (as shown
+// by Teams, base64-encoded) is secret. run executes a command and returns the
+// reply.
+func NewHandler(secret string, run func(ctx context.Context, user, text string) string) (*Handler, error) {
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid outgoing webhook secret: %w", err)
+	}
+	return &Handler{secret: key, run: run}, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if !h.verify(r.Header.Get("Authorization"), body) {
+		log.Printf("Rejected teams command: signature mismatch")
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var msg activity
+	if err := json.Unmarshal(body, &msg); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	text := strings.TrimSpace(mentionPattern.ReplaceAllString(msg.Text, ""))
+	log.Printf("Teams command %q by %s", text, msg.From.Name)
+
+	// The request context ends with this response, so commands get their own
+	reply := make(chan string, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		defer cancel()
+		reply <- h.run(ctx, msg.From.Name, text)
+	}()
+
+	// Outgoing webhooks can't reply later, so slow commands finish unseen
+	message := "Still working on it; the result will show up in alerts and the API."
+	select {
+	case message = <-reply:
+	case <-time.After(commandDeadline):
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"type": "message", "text": message})
+}
+
+// verify checks the "HMAC <base64 HMAC-SHA256 of the body>" authorization.
+func (h *Handler) verify(authorization string, body []byte) bool {
+	signature, ok := strings.CutPrefix(authorization, "HMAC ")
+	if !ok {
+		return false
+	}
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write(body)
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
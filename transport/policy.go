@@ -0,0 +1,104 @@
+package transport
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+)
+
+// policy is the process-wide TLS policy applied to every outbound TLS
+// connection. It is set once at startup by SetPolicy.
+var policy struct {
+	sync.RWMutex
+	minVersion     uint16
+	cipherSuites   []uint16
+	requireSMTPTLS bool
+	description    string
+}
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// SetPolicy validates and installs the TLS policy. Cipher suites are named as
+// in crypto/tls (e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256); suites Go
+// considers insecure are rejected. TLS 1.3 suites are not configurable.
+func SetPolicy(cfg config.TLSPolicyConfig) error {
+	minVersion, ok := tlsVersions[cfg.MinVersion]
+	if !ok {
+		return fmt.Errorf("tls_policy.min_version %q is not supported (use 1.2 or 1.3)", cfg.MinVersion)
+	}
+
+	secure := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		secure[suite.Name] = suite.ID
+	}
+	insecure := make(map[string]bool)
+	for _, suite := range tls.InsecureCipherSuites() {
+		insecure[suite.Name] = true
+	}
+
+	var suites []uint16
+	for _, name := range cfg.CipherSuites {
+		id, ok := secure[name]
+		switch {
+		case insecure[name]:
+			return fmt.Errorf("tls_policy.cipher_suites: %s is insecure", name)
+		case !ok:
+			return fmt.Errorf("tls_policy.cipher_suites: unknown cipher suite %s", name)
+		}
+		suites = append(suites, id)
+	}
+
+	policy.Lock()
+	defer policy.Unlock()
+	policy.minVersion = minVersion
+	policy.cipherSuites = suites
+	policy.requireSMTPTLS = cfg.RequireSMTPTLS
+	policy.description = "TLS >= " + cfg.MinVersion
+	if len(cfg.CipherSuites) > 0 {
+		policy.description += " with ciphers " + strings.Join(cfg.CipherSuites, ", ")
+	}
+	return nil
+}
+
+// applyPolicy returns a copy of tlsConfig (or a fresh config) restricted by
+// the TLS policy.
+func applyPolicy(tlsConfig *tls.Config) *tls.Config {
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+
+	policy.RLock()
+	defer policy.RUnlock()
+	if policy.minVersion > tlsConfig.MinVersion {
+		tlsConfig.MinVersion = policy.minVersion
+	}
+	if len(policy.cipherSuites) > 0 {
+		tlsConfig.CipherSuites = policy.cipherSuites
+	}
+	return tlsConfig
+}
+
+// RequireSMTPTLS reports whether mail may only be sent over STARTTLS.
+func RequireSMTPTLS() bool {
+	policy.RLock()
+	defer policy.RUnlock()
+	return policy.requireSMTPTLS
+}
+
+// PolicyError explains a TLS failure in terms of the configured policy.
+func PolicyError(endpoint string, err error) error {
+	policy.RLock()
+	defer policy.RUnlock()
+	if policy.description == "" {
+		return err
+	}
+	return fmt.Errorf("%s cannot meet tls_policy (%s): %w", endpoint, policy.description, err)
+}
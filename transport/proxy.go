@@ -0,0 +1,126 @@
+// Package transport builds the network clients used for outbound
+// notifications.
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+)
+
+// Proxy returns a notifier's proxy settings: its own override when set,
+// otherwise the global ones. An override with an empty http_proxy disables
+// the proxy for that notifier.
+func Proxy(global config.ProxyConfig, override *config.ProxyConfig) config.ProxyConfig {
+	if override != nil {
+		return *override
+	}
+	return global
+}
+
+// proxyFunc returns nil when no proxy is configured.
+func proxyFunc(proxy config.ProxyConfig) func(*url.URL) (*url.URL, error) {
+	if proxy.HTTPProxy == "" {
+		return nil
+	}
+	return (&httpproxy.Config{
+		HTTPProxy:  proxy.HTTPProxy,
+		HTTPSProxy: proxy.HTTPProxy,
+		NoProxy:    proxy.NoProxy,
+	}).ProxyFunc()
+}
+
+// HTTPClient returns a client that sends requests through the proxy, if any,
+// using tlsConfig when it is non-nil. Without a proxy, the standard
+// HTTP_PROXY/NO_PROXY environment variables apply.
+func HTTPClient(proxy config.ProxyConfig, tlsConfig *tls.Config, timeout time.Duration) *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = applyPolicy(tlsConfig)
+	if fn := proxyFunc(proxy); fn != nil {
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			return fn(req.URL)
+		}
+	}
+	return &http.Client{Timeout: timeout, Transport: t}
+}
+
+// Dial opens a TCP connection to addr, tunnelling it through the proxy with
+// HTTP CONNECT unless no_proxy excludes the host.
+func Dial(ctx context.Context, proxy config.ProxyConfig, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+
+	var proxyURL *url.URL
+	if fn := proxyFunc(proxy); fn != nil {
+		var err error
+		// The scheme only selects which proxy setting applies
+		if proxyURL, err = fn(&url.URL{Scheme: "https", Host: addr}); err != nil {
+			return nil, fmt.Errorf("invalid proxy: %w", err)
+		}
+	}
+	if proxyURL == nil {
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", proxyURL.Host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach proxy %s: %w", proxyURL.Host, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+		req.Header["Proxy-Authorization"] = req.Header["Authorization"]
+		req.Header.Del("Authorization")
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT to proxy: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read CONNECT response: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused CONNECT to %s: %s", addr, resp.Status)
+	}
+	// The server may have spoken first, e.g. an SMTP greeting, in the same
+	// read as the proxy's response
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were already read into r.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,42 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+)
+
+// TLSConfig builds the client TLS settings for an endpoint, restricted by the
+// TLS policy.
+func TLSConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	if cfg == nil {
+		return applyPolicy(nil), nil
+	}
+
+	tlsConfig := applyPolicy(&tls.Config{ServerName: cfg.ServerName})
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,82 @@
+// Package usage counts the load the monitor puts on the Kubernetes API and
+// on notification channels.
+package usage
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Usage is a set of counters, either totals since startup or for one run.
+type Usage struct {
+	APIRequests   int64 `json:"api_requests"`
+	LogBytes      int64 `json:"log_bytes"`
+	Notifications int64 `json:"notifications"`
+}
+
+// Sub returns the usage accrued since an earlier snapshot.
+func (u Usage) Sub(earlier Usage) Usage {
+	return Usage{
+		APIRequests:   u.APIRequests - earlier.APIRequests,
+		LogBytes:      u.LogBytes - earlier.LogBytes,
+		Notifications: u.Notifications - earlier.Notifications,
+	}
+}
+
+// Tracker accumulates usage. It is safe for concurrent use.
+type Tracker struct {
+	apiRequests   atomic.Int64
+	logBytes      atomic.Int64
+	notifications atomic.Int64
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Snapshot returns the totals since startup.
+func (t *Tracker) Snapshot() Usage {
+	return Usage{
+		APIRequests:   t.apiRequests.Load(),
+		LogBytes:      t.logBytes.Load(),
+		Notifications: t.notifications.Load(),
+	}
+}
+
+func (t *Tracker) AddNotification() {
+	t.notifications.Add(1)
+}
+
+// WrapTransport counts every API request made through rt, and the bytes read
+// from pod log responses.
+func (t *Tracker) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return roundTripper{tracker: t, next: rt}
+}
+
+type roundTripper struct {
+	tracker *Tracker
+	next    http.RoundTripper
+}
+
+func (r roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.tracker.apiRequests.Add(1)
+
+	resp, err := r.next.RoundTrip(req)
+	if err == nil && strings.HasSuffix(req.URL.Path, "/log") {
+		resp.Body = &countingBody{ReadCloser: resp.Body, counter: &r.tracker.logBytes}
+	}
+	return resp, err
+}
+
+type countingBody struct {
+	io.ReadCloser
+	counter *atomic.Int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.counter.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,123 @@
+// webhook/notifier.go
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Bharath-H-R/k8s-health/config"
+	"github.com/Bharath-H-R/k8s-health/outbox"
+	"github.com/Bharath-H-R/k8s-health/payload"
+	"github.com/Bharath-H-R/k8s-health/transport"
+)
+
+// Notifier posts versioned alert payloads to generic webhook endpoints.
+type Notifier struct {
+	targets []target
+	// outbox, if set, receives payloads instead of the targets
+	outbox *outbox.Dir
+}
+
+type target struct {
+	config.WebhookConfig
+	httpClient *http.Client
+}
+
+func NewNotifier(targets []config.WebhookConfig, proxy config.ProxyConfig) (*Notifier, error) {
+	n := &Notifier{}
+	for _, cfg := range targets {
+		tlsConfig, err := transport.TLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s tls: %w", cfg.Name, err)
+		}
+		n.targets = append(n.targets, target{
+			WebhookConfig: cfg,
+			httpClient:    transport.HTTPClient(transport.Proxy(proxy, cfg.Proxy), tlsConfig, 10*time.Second),
+		})
+	}
+	return n, nil
+}
+
+// SetOutbox writes payloads to the outbox, once per target, instead of
+// posting them.
+func (n *Notifier) SetOutbox(dir *outbox.Dir) {
+	n.outbox = dir
+}
+
+// Send delivers an alert to every target and returns the first error, after
+// trying all of them.
+func (n *Notifier) Send(alert payload.Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	var firstErr error
+	for _, target := range n.targets {
+		if err := n.post(target, alert.SchemaVersion, body); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("webhook %s: %w", target.Name, err)
+		}
+	}
+	return firstErr
+}
+
+// Check sends a HEAD request to every target and returns the error of each
+// that is unreachable or rejects the request as unauthorized or not found,
+// keyed by target name. Targets that only accept POST still pass.
+func (n *Notifier) Check() map[string]error {
+	errs := make(map[string]error)
+	if n.outbox != nil {
+		return errs
+	}
+	for _, target := range n.targets {
+		req, err := http.NewRequest(http.MethodHead, target.URL, nil)
+		if err != nil {
+			errs[target.Name] = fmt.Errorf("failed to build request: %w", err)
+			continue
+		}
+		for name, value := range target.Headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := target.httpClient.Do(req)
+		if err != nil {
+			errs[target.Name] = fmt.Errorf("failed to reach: %w", err)
+			continue
+		}
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden,
+			resp.StatusCode == http.StatusNotFound, resp.StatusCode >= 500:
+			errs[target.Name] = fmt.Errorf("returned status %d", resp.StatusCode)
+		}
+	}
+	return errs
+}
+
+func (n *Notifier) post(target target, version string, body []byte) error {
+	if n.outbox != nil {
+		return n.outbox.Write("webhook-"+target.Name, "json", body)
+	}
+	req, err := http.NewRequest(http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Schema-Version", version)
+	for name, value := range target.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := target.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("returned status %d", resp.StatusCode)
+	}
+	return nil
+}